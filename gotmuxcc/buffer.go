@@ -0,0 +1,316 @@
+package gotmuxcc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+func (r queryResult) toBuffer() *Buffer {
+	return &Buffer{
+		Name:        r.get(varBufferName),
+		Size:        atoi(r.get(varBufferSize)),
+		Sample:      r.get(varBufferSample),
+		CreatedTime: parseUnix(r.get(varBufferCreated)),
+	}
+}
+
+// ListBuffers lists the server's paste buffers, most recent first.
+func (t *Tmux) ListBuffers() ([]*Buffer, error) {
+	output, err := t.query().
+		cmd("list-buffers").
+		vars(varBufferName, varBufferSize, varBufferSample, varBufferCreated).
+		run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buffers: %w", err)
+	}
+	results := output.collect()
+	buffers := make([]*Buffer, 0, len(results))
+	for _, result := range results {
+		buffers = append(buffers, result.toBuffer())
+	}
+	return buffers, nil
+}
+
+// bufferNames lists the server's paste buffers, most recent first.
+func (t *Tmux) bufferNames() ([]string, error) {
+	output, err := t.query().
+		cmd("list-buffers").
+		vars(varBufferName).
+		run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buffers: %w", err)
+	}
+	results := output.collect()
+	names := make([]string, 0, len(results))
+	for _, result := range results {
+		names = append(names, result.get(varBufferName))
+	}
+	return names, nil
+}
+
+// SetBuffer sets the named paste buffer to data, creating it if needed. An
+// empty name creates a new automatically named buffer. With op.NewName the
+// buffer, or the most recent one if name is empty, is renamed instead and
+// data must be empty.
+func (t *Tmux) SetBuffer(name, data string, op *SetBufferOptions) error {
+	if op == nil {
+		op = &SetBufferOptions{}
+	}
+	if op.NewName != "" && (data != "" || op.Append) {
+		return invalidOptions("set-buffer", "NewName", "cannot be combined with data or Append")
+	}
+
+	q := t.query().cmd("set-buffer")
+	if name != "" {
+		q.fargs("-b", name)
+	}
+	if op.NewName != "" {
+		_, err := q.fargs("-n", op.NewName).run()
+		if err != nil {
+			return fmt.Errorf("failed to rename buffer %s: %w", name, bufferError(name, err))
+		}
+		return nil
+	}
+	if op.Append {
+		q.fargs("-a")
+	}
+	if _, err := q.pargs(data).run(); err != nil {
+		return fmt.Errorf("failed to set buffer %s: %w", name, err)
+	}
+	return nil
+}
+
+// LoadBuffer sets the named paste buffer to the contents of r, or creates a
+// new automatically named buffer if name is empty. The data passes through
+// a local temporary file, so the server must run on this machine.
+func (t *Tmux) LoadBuffer(name string, r io.Reader) error {
+	f, err := os.CreateTemp("", "gotmuxcc-buffer-")
+	if err != nil {
+		return fmt.Errorf("failed to create buffer file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write buffer file: %w", err)
+	}
+
+	q := t.query().cmd("load-buffer")
+	if name != "" {
+		q.fargs("-b", name)
+	}
+	if _, err := q.pargs(f.Name()).run(); err != nil {
+		return fmt.Errorf("failed to load buffer %s: %w", name, err)
+	}
+	return nil
+}
+
+// SaveBuffer writes the contents of the named paste buffer, or the most
+// recent one if name is empty, to w. Like LoadBuffer, it uses a local
+// temporary file.
+func (t *Tmux) SaveBuffer(name string, w io.Writer) error {
+	dir, err := os.MkdirTemp("", "gotmuxcc-buffer-")
+	if err != nil {
+		return fmt.Errorf("failed to create buffer directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "buffer")
+
+	q := t.query().cmd("save-buffer")
+	if name != "" {
+		q.fargs("-b", name)
+	}
+	if _, err := q.pargs(path).run(); err != nil {
+		return fmt.Errorf("failed to save buffer %s: %w", name, bufferError(name, err))
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read buffer file: %w", err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(w, f); err != nil {
+		return fmt.Errorf("failed to copy buffer %s: %w", name, err)
+	}
+	return nil
+}
+
+// ShowBuffer returns the contents of the named paste buffer, or the most
+// recent one if name is empty.
+func (t *Tmux) ShowBuffer(name string) (string, error) {
+	return t.showBuffer(name)
+}
+
+// showBuffer returns the contents of the named paste buffer.
+func (t *Tmux) showBuffer(name string) (string, error) {
+	q := t.query().cmd("show-buffer")
+	if name != "" {
+		q.fargs("-b", name)
+	}
+	output, err := q.run()
+	if err != nil {
+		return "", fmt.Errorf("failed to show buffer %s: %w", name, bufferError(name, err))
+	}
+	return unvis(strings.Join(output.result.Lines, "\n")), nil
+}
+
+// DeleteBuffer deletes the named paste buffer, or the most recent one if
+// name is empty.
+func (t *Tmux) DeleteBuffer(name string) error {
+	return t.deleteBuffer(name)
+}
+
+// deleteBuffer deletes the named paste buffer.
+func (t *Tmux) deleteBuffer(name string) error {
+	q := t.query().cmd("delete-buffer")
+	if name != "" {
+		q.fargs("-b", name)
+	}
+	if _, err := q.run(); err != nil {
+		return fmt.Errorf("failed to delete buffer %s: %w", name, bufferError(name, err))
+	}
+	return nil
+}
+
+// bufferError converts tmux's errors for a missing buffer into ErrNotFound.
+func bufferError(name string, err error) error {
+	var cmdErr *commandError
+	if errors.As(err, &cmdErr) {
+		text := strings.Join(cmdErr.Result.Lines, "\n")
+		if strings.Contains(text, "no buffer") || strings.Contains(text, "unknown buffer") {
+			return notFound("buffer", name)
+		}
+	}
+	return err
+}
+
+// PasteBuffer pastes a paste buffer into the pane.
+func (p *Pane) PasteBuffer(op *PasteBufferOptions) error {
+	if op == nil {
+		op = &PasteBufferOptions{}
+	}
+	if op.NoReplace && op.Separator != "" {
+		return invalidOptions("paste-buffer", "Separator", "cannot be combined with NoReplace")
+	}
+
+	q := p.tmux.query().
+		cmd("paste-buffer").
+		fargs("-t", p.Id)
+	if op.Buffer != "" {
+		q.fargs("-b", op.Buffer)
+	}
+	if op.Bracketed {
+		q.fargs("-p")
+	}
+	if op.Separator != "" {
+		q.fargs("-s", op.Separator)
+	}
+	if op.NoReplace {
+		q.fargs("-r")
+	}
+	if op.Delete {
+		q.fargs("-d")
+	}
+	if _, err := q.run(); err != nil {
+		err = bufferError(op.Buffer, lookupError("pane", p.Id, err))
+		return fmt.Errorf("failed to paste buffer: %w", err)
+	}
+	return nil
+}
+
+// WatchBuffers calls fn, on the calling goroutine, for each paste buffer
+// that is set or deleted, until ctx is cancelled or the connection closes.
+// It returns nil once ctx is cancelled. tmux sends the notifications it
+// relies on from version 3.4; older servers report no changes.
+func (t *Tmux) WatchBuffers(ctx context.Context, fn func(BufferEvent)) error {
+	var (
+		mu      sync.Mutex
+		pending []BufferEvent
+		closed  bool
+	)
+	wake := make(chan struct{}, 1)
+	stop := t.listen(func(evt Event) {
+		mu.Lock()
+		switch evt.Name {
+		case "paste-buffer-changed":
+			pending = append(pending, BufferEvent{Name: evt.Data})
+		case "paste-buffer-deleted":
+			pending = append(pending, BufferEvent{Name: evt.Data, Deleted: true})
+		case "exit":
+			closed = true
+		default:
+			mu.Unlock()
+			return
+		}
+		mu.Unlock()
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	})
+	defer stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-wake:
+		}
+		mu.Lock()
+		events, done := pending, closed
+		pending = nil
+		mu.Unlock()
+		for _, evt := range events {
+			fn(evt)
+		}
+		if done {
+			return ErrTransportClosed
+		}
+	}
+}
+
+// cStyleEscapes maps the C-style escapes tmux's vis encoding uses.
+var cStyleEscapes = map[byte]byte{
+	'a': '\a', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t', 'v': '\v', 's': ' ',
+}
+
+// unvis decodes text tmux has escaped for a control client, as show-buffer
+// does: C-style escapes, octal escapes of up to three digits and \\.
+func unvis(text string) string {
+	if !strings.Contains(text, `\`) {
+		return text
+	}
+	var b strings.Builder
+	b.Grow(len(text))
+	for idx := 0; idx < len(text); idx++ {
+		c := text[idx]
+		if c != '\\' || idx+1 == len(text) {
+			b.WriteByte(c)
+			continue
+		}
+		idx++
+		next := text[idx]
+		switch {
+		case next >= '0' && next <= '7':
+			value := 0
+			end := min(idx+3, len(text))
+			for ; idx < end && text[idx] >= '0' && text[idx] <= '7'; idx++ {
+				value = value*8 + int(text[idx]-'0')
+			}
+			idx--
+			b.WriteByte(byte(value))
+		case cStyleEscapes[next] != 0:
+			b.WriteByte(cStyleEscapes[next])
+		default:
+			b.WriteByte(next)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,135 @@
+package gotmuxcc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUnvis(t *testing.T) {
+	// As printed by show-buffer to a control client in tmux 3.3.
+	got := unvis(`a\001\177\200\r\a\b\f\v\0z \\ "x\t\033[1m\1234\`)
+	want := "a\x01\x7f\x80\r\a\b\f\v\x00z \\ \"x\t\x1b[1m\x534\\"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestListBuffers(t *testing.T) {
+	vars := []string{varBufferName, varBufferSize, varBufferSample, varBufferCreated}
+	tr := newScriptedTransport([]scriptedResponse{
+		{match: "list-buffers", lines: []string{
+			"%begin 1 1 0",
+			formatRecord(vars, map[string]string{varBufferName: "b2", varBufferSize: "4", varBufferSample: `a\nb`, varBufferCreated: "1700000000"}),
+			formatRecord(vars, map[string]string{varBufferName: "buffer0", varBufferSize: "1", varBufferSample: "x"}),
+			"%end 1 1 0",
+		}},
+	})
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	buffers, err := tmux.ListBuffers()
+	if err != nil {
+		t.Fatalf("ListBuffers returned error: %v", err)
+	}
+	if len(buffers) != 2 {
+		t.Fatalf("expected 2 buffers, got %d", len(buffers))
+	}
+	want := Buffer{Name: "b2", Size: 4, Sample: `a\nb`, CreatedTime: time.Unix(1700000000, 0)}
+	if *buffers[0] != want || buffers[1].Name != "buffer0" || !buffers[1].CreatedTime.IsZero() {
+		t.Fatalf("unexpected buffers %+v %+v", *buffers[0], *buffers[1])
+	}
+}
+
+func TestSetAndPasteBuffer(t *testing.T) {
+	ok := []string{"%begin 1 1 0", "%end 1 1 0"}
+	tr := newScriptedTransport([]scriptedResponse{
+		{match: "set-buffer -b b1 -a -- 'x y'", lines: ok},
+		{match: "set-buffer -n b2", lines: ok},
+		{match: "set-buffer -b gone -n b3", lines: []string{"%begin 1 1 0", "unknown buffer: gone", "%error 1 1 0"}},
+		{match: "paste-buffer -t %1 -b b2 -p -s '|' -d", lines: ok},
+		{match: "paste-buffer -t %1 -b gone -r", lines: []string{"%begin 1 1 0", "no buffer gone", "%error 1 1 0"}},
+	})
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	if err := tmux.SetBuffer("b1", "x y", &SetBufferOptions{Append: true}); err != nil {
+		t.Fatalf("SetBuffer returned error: %v", err)
+	}
+	if err := tmux.SetBuffer("", "", &SetBufferOptions{NewName: "b2"}); err != nil {
+		t.Fatalf("SetBuffer returned error: %v", err)
+	}
+	if err := tmux.SetBuffer("gone", "", &SetBufferOptions{NewName: "b3"}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	pane := &Pane{Id: "%1", tmux: tmux}
+	if err := pane.PasteBuffer(&PasteBufferOptions{Buffer: "b2", Bracketed: true, Separator: "|", Delete: true}); err != nil {
+		t.Fatalf("PasteBuffer returned error: %v", err)
+	}
+	if err := pane.PasteBuffer(&PasteBufferOptions{Buffer: "gone", NoReplace: true}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestBufferOptionsInvalid(t *testing.T) {
+	tmux := &Tmux{}
+	var invalid *InvalidOptionsError
+	if err := tmux.SetBuffer("b", "data", &SetBufferOptions{NewName: "c"}); !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidOptionsError, got %v", err)
+	}
+	pane := &Pane{Id: "%1", tmux: tmux}
+	if err := pane.PasteBuffer(&PasteBufferOptions{Separator: "|", NoReplace: true}); !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidOptionsError, got %v", err)
+	}
+}
+
+func TestWatchBuffers(t *testing.T) {
+	tr := newScriptedTransport([]scriptedResponse{
+		{match: "set-buffer -b 'my buf' -- x", lines: []string{
+			"%begin 1 1 0", "%end 1 1 0",
+			"%paste-buffer-changed my buf",
+			"%paste-buffer-deleted buffer0",
+		}},
+	})
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan BufferEvent, 2)
+	done := make(chan error, 1)
+	go func() {
+		done <- tmux.WatchBuffers(ctx, func(evt BufferEvent) { events <- evt })
+	}()
+	for {
+		tmux.router.mu.Lock()
+		listening := len(tmux.router.listeners) > 0
+		tmux.router.mu.Unlock()
+		if listening {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := tmux.SetBuffer("my buf", "x", nil); err != nil {
+		t.Fatalf("SetBuffer returned error: %v", err)
+	}
+	for _, want := range []BufferEvent{{Name: "my buf"}, {Name: "buffer0", Deleted: true}} {
+		select {
+		case got := <-events:
+			if got != want {
+				t.Fatalf("expected %+v, got %+v", want, got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for %+v", want)
+		}
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("WatchBuffers returned error: %v", err)
+	}
+}
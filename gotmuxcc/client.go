@@ -1,6 +1,9 @@
 package gotmuxcc
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 func (q *query) clientVars() *query {
 	return q.vars(
@@ -57,12 +60,36 @@ func (q queryResult) toClient(t *Tmux) *Client {
 		Utf8:         isOne(q.get(varClientUtf8)),
 		Width:        atoi(q.get(varClientWidth)),
 		Written:      q.get(varClientWritten),
+		activity:     parseUnix(q.get(varClientActivity)),
+		created:      parseUnix(q.get(varClientCreated)),
+		discarded:    atoi64(q.get(varClientDiscarded)),
+		written:      atoi64(q.get(varClientWritten)),
 		tmux:         t,
 	}
 
 	return client
 }
 
+// ActivityTime returns the time of the client's last activity.
+func (c *Client) ActivityTime() time.Time {
+	return c.activity
+}
+
+// CreatedTime returns when the client connected.
+func (c *Client) CreatedTime() time.Time {
+	return c.created
+}
+
+// DiscardedBytes returns the number of output bytes tmux dropped for a slow client.
+func (c *Client) DiscardedBytes() int64 {
+	return c.discarded
+}
+
+// WrittenBytes returns the number of bytes tmux has written to the client.
+func (c *Client) WrittenBytes() int64 {
+	return c.written
+}
+
 // ListClients enumerates tmux clients.
 func (t *Tmux) ListClients() ([]*Client, error) {
 	output, err := t.query().
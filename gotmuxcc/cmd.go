@@ -0,0 +1,333 @@
+package gotmuxcc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Cmd runs a program in a new tmux pane, as exec.Cmd runs a subprocess, so it
+// can be watched live in tmux. Like the pipes Pane.PipeTo uses, its streams go
+// through FIFOs in a local temporary directory, so the server must run on
+// this machine.
+type Cmd struct {
+	// Path is the program to run, looked up in the pane's PATH.
+	Path string
+	// Args holds the command-line arguments, including the program name as
+	// Args[0].
+	Args []string
+	// Dir is the working directory; by default tmux chooses one.
+	Dir string
+	// Env holds "KEY=value" pairs added to the environment the pane would
+	// otherwise get.
+	Env []string
+	// Stdin is read by the program. When nil, it reads the pane's terminal.
+	Stdin io.Reader
+	// Stdout receives the pane's output. As on a terminal, this includes
+	// standard error unless Stderr is set.
+	Stdout io.Writer
+	// Stderr receives the program's standard error, which then no longer
+	// appears in the pane.
+	Stderr io.Writer
+	// KeepPane leaves the pane open once the program has exited. By default
+	// Wait kills it. tmux can't close a dead pane's pipe, so with Stdout set
+	// Wait then allows a second for the last output to arrive.
+	KeepPane bool
+
+	// Pane is the pane the program runs in, set by Start.
+	Pane *Pane
+	// ProcessState describes how the program exited, set by Wait.
+	ProcessState *ExitStatus
+
+	ctx     context.Context
+	session *Session
+	split   *Pane
+
+	files      *panePipe
+	stdout     chan error
+	stopStdout context.CancelFunc
+	stderr     chan error
+	finished   bool
+}
+
+// Command returns a Cmd that runs name with args in a new window of the
+// session, which is not selected.
+func (s *Session) Command(name string, args ...string) *Cmd {
+	return s.CommandContext(context.Background(), name, args...)
+}
+
+// CommandContext is like Command but includes a context. If the context is
+// done before the program exits, Wait kills its pane and returns the
+// context's error.
+func (s *Session) CommandContext(ctx context.Context, name string, args ...string) *Cmd {
+	if ctx == nil {
+		panic("gotmuxcc: nil Context")
+	}
+	return &Cmd{Path: name, Args: append([]string{name}, args...), ctx: ctx, session: s}
+}
+
+// Command returns a Cmd that runs name with args in a new pane split from
+// this one, which stays active.
+func (p *Pane) Command(name string, args ...string) *Cmd {
+	return p.CommandContext(context.Background(), name, args...)
+}
+
+// CommandContext is like Command but includes a context, as
+// Session.CommandContext does.
+func (p *Pane) CommandContext(ctx context.Context, name string, args ...string) *Cmd {
+	if ctx == nil {
+		panic("gotmuxcc: nil Context")
+	}
+	return &Cmd{Path: name, Args: append([]string{name}, args...), ctx: ctx, split: p}
+}
+
+// Run starts the command and waits for it to finish.
+func (c *Cmd) Run() error {
+	if err := c.Start(); err != nil {
+		return err
+	}
+	return c.Wait()
+}
+
+// Start starts the command without waiting for it. The program runs only
+// once Stdout is being captured, so no output is missed.
+func (c *Cmd) Start() error {
+	if c.Pane != nil {
+		return errors.New("gotmuxcc: Cmd already started")
+	}
+	if c.Path == "" {
+		return invalidOptions("new-window", "Path", "must not be empty")
+	}
+	if c.session == nil && c.split == nil {
+		return errors.New("gotmuxcc: Cmd has no session or pane to run in")
+	}
+	if err := c.context().Err(); err != nil {
+		return err
+	}
+	env, err := cmdEnvironment(c.Env)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "gotmuxcc-cmd-")
+	if err != nil {
+		return fmt.Errorf("failed to create command directory: %w", err)
+	}
+	c.files = &panePipe{dir: dir}
+	if err := c.start(env); err != nil {
+		if c.Pane != nil {
+			c.Pane.Kill()
+		}
+		c.release()
+		return err
+	}
+	return nil
+}
+
+func (c *Cmd) start(env map[string]string) error {
+	gate, err := c.files.fifo("gate")
+	if err != nil {
+		return err
+	}
+	var in, errPath string
+	if c.Stdin != nil {
+		if in, err = c.files.fifo("in"); err != nil {
+			return err
+		}
+	}
+	if c.Stderr != nil {
+		if errPath, err = c.files.fifo("err"); err != nil {
+			return err
+		}
+	}
+
+	line := c.shellCommand(gate, in, errPath)
+	if c.session != nil {
+		window, err := c.session.NewWindow(&NewWindowOptions{
+			StartDirectory: c.Dir,
+			DoNotAttach:    true,
+			ShellCommand:   line,
+			Environment:    env,
+		})
+		if err != nil {
+			return err
+		}
+		panes, err := window.ListPanes()
+		if err != nil {
+			return err
+		}
+		if len(panes) == 0 {
+			return notFound("window", window.Id)
+		}
+		c.Pane = panes[0]
+	} else {
+		c.Pane, err = c.split.SplitWindow(&SplitWindowOptions{
+			StartDirectory: c.Dir,
+			DoNotSelect:    true,
+			ShellCommand:   line,
+			Environment:    env,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// The program is held at the gate, so the status can't be lost.
+	_, err = c.Pane.tmux.query().
+		cmd("set-option").
+		fargs("-p", "-t", c.Pane.Id).
+		pargs("remain-on-exit", "on").
+		run()
+	if err != nil {
+		return fmt.Errorf("failed to set remain-on-exit: %w", lookupError("pane", c.Pane.Id, err))
+	}
+	if c.Stdout != nil {
+		pipe, err := c.Pane.startPipe(c.Stdout, &PipeOptions{})
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		c.stdout = make(chan error, 1)
+		c.stopStdout = cancel
+		go func() {
+			// Clean up before reporting, so Wait leaves nothing behind.
+			err := pipe.wait(ctx)
+			pipe.cleanup()
+			c.stdout <- err
+		}()
+	}
+	if c.Stdin != nil {
+		go c.files.copyIn(in, c.Stdin)
+	}
+	if c.Stderr != nil {
+		c.stderr = make(chan error, 1)
+		c.files.copying.Add(1)
+		go func() {
+			defer c.files.copying.Done()
+			c.stderr <- c.files.copyOut(c.Stderr, errPath)
+		}()
+	}
+
+	// Holding the gate open lets the program past it; cleanup closes it.
+	f, err := openFIFONonblock(gate, os.O_RDWR)
+	if err != nil {
+		return fmt.Errorf("failed to open command FIFO: %w", err)
+	}
+	c.files.mu.Lock()
+	c.files.files = append(c.files.files, f)
+	c.files.mu.Unlock()
+	return nil
+}
+
+// shellCommand returns the command line the pane runs: it waits at the
+// gate FIFO, then replaces the shell with the program, redirecting stdin
+// and stderr to the other FIFOs if they are set.
+func (c *Cmd) shellCommand(gate, in, errPath string) string {
+	var b strings.Builder
+	b.WriteString(": < " + shellQuote(gate) + " && exec")
+	for idx, arg := range c.Args {
+		if idx == 0 {
+			arg = c.Path
+		}
+		b.WriteString(" " + shellQuote(arg))
+	}
+	if in != "" {
+		b.WriteString(" < " + shellQuote(in))
+	}
+	if errPath != "" {
+		b.WriteString(" 2> " + shellQuote(errPath))
+	}
+	return b.String()
+}
+
+// Wait waits for the command to exit and for its output to be copied. It
+// returns an *ExitError if the program exits unsuccessfully, and an error
+// wrapping ErrNotFound if its pane is killed. If the Cmd's context is done
+// first, Wait kills the pane, even with KeepPane, and returns the context's
+// error.
+func (c *Cmd) Wait() error {
+	if c.Pane == nil {
+		return errors.New("gotmuxcc: Cmd not started")
+	}
+	if c.finished {
+		return errors.New("gotmuxcc: Wait was already called")
+	}
+	c.finished = true
+
+	ctx := c.context()
+	status, err := c.Pane.Wait(ctx, nil)
+	switch {
+	case err == nil:
+		c.ProcessState = status
+		if !c.KeepPane {
+			// Killing the pane closes its pipe, ending Stdout promptly.
+			err = c.Pane.Kill()
+		}
+	case ctx.Err() != nil:
+		c.Pane.Kill()
+	}
+	copyErr := c.finishStdout()
+	if c.stderr != nil {
+		// The program may have died before opening its stderr FIFO.
+		select {
+		case err := <-c.stderr:
+			if copyErr == nil {
+				copyErr = err
+			}
+		case <-time.After(pipeDrainTimeout):
+		}
+	}
+	c.release()
+	if err != nil {
+		return err
+	}
+	if status.Code != 0 || status.Signal != 0 {
+		return &ExitError{ExitStatus: status}
+	}
+	return copyErr
+}
+
+// context returns the Cmd's context, which is Background for a Cmd not made
+// by a constructor.
+func (c *Cmd) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// finishStdout stops copying Stdout and returns the copy's error once its
+// pipe is cleaned up.
+func (c *Cmd) finishStdout() error {
+	if c.stdout == nil {
+		return nil
+	}
+	c.stopStdout()
+	err := <-c.stdout
+	c.stdout = nil
+	return err
+}
+
+// release stops copying Stdout, closes the FIFOs and removes their
+// directories.
+func (c *Cmd) release() {
+	c.finishStdout()
+	c.files.cleanup()
+}
+
+// cmdEnvironment converts "KEY=value" pairs to new-window's -e variables.
+func cmdEnvironment(env []string) (map[string]string, error) {
+	vars := make(map[string]string, len(env))
+	for _, pair := range env {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, invalidOptions("new-window", "Env", fmt.Sprintf("invalid variable %q", pair))
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
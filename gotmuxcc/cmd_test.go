@@ -0,0 +1,74 @@
+package gotmuxcc
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCmdShellCommand(t *testing.T) {
+	c := (&Pane{}).Command("prog", "a b", "it's")
+	c.Path = "/bin/prog"
+	cases := []struct{ in, err, want string }{
+		{"", "", `: < '/g' && exec '/bin/prog' 'a b' 'it'\''s'`},
+		{"/i", "/e", `: < '/g' && exec '/bin/prog' 'a b' 'it'\''s' < '/i' 2> '/e'`},
+	}
+	for _, tc := range cases {
+		if got := c.shellCommand("/g", tc.in, tc.err); got != tc.want {
+			t.Errorf("expected %q, got %q", tc.want, got)
+		}
+	}
+}
+
+func TestCmdEnvironment(t *testing.T) {
+	vars, err := cmdEnvironment([]string{"A=1", "B=x=y", "C="})
+	if err != nil {
+		t.Fatalf("cmdEnvironment returned error: %v", err)
+	}
+	if len(vars) != 3 || vars["A"] != "1" || vars["B"] != "x=y" || vars["C"] != "" {
+		t.Fatalf("unexpected variables %v", vars)
+	}
+	var invalid *InvalidOptionsError
+	for _, pair := range []string{"A", "=1"} {
+		if _, err := cmdEnvironment([]string{pair}); !errors.As(err, &invalid) {
+			t.Errorf("expected InvalidOptionsError for %q, got %v", pair, err)
+		}
+	}
+}
+
+func TestCmdStartInvalid(t *testing.T) {
+	var invalid *InvalidOptionsError
+	c := (&Session{Id: "$1", tmux: &Tmux{}}).Command("")
+	if err := c.Start(); !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidOptionsError, got %v", err)
+	}
+	c = (&Session{Id: "$1", tmux: &Tmux{}}).Command("true")
+	c.Env = []string{"BAD"}
+	if err := c.Start(); !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidOptionsError, got %v", err)
+	}
+	if err := c.Wait(); err == nil {
+		t.Fatal("expected Wait to fail before Start")
+	}
+}
+
+func TestCmdStartCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := (&Pane{Id: "%1", tmux: &Tmux{}}).CommandContext(ctx, "true")
+	if err := c.Start(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if c.Pane != nil || c.files != nil {
+		t.Fatal("expected nothing to be started")
+	}
+}
+
+func TestCmdNilContext(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected CommandContext to panic on a nil context")
+		}
+	}()
+	(&Session{}).CommandContext(nil, "true")
+}
@@ -11,8 +11,9 @@ import (
 
 func newControlTransport(ctx context.Context, socketPath string) (controlTransport, error) {
 	cfg := control.Config{
-		SocketPath: socketPath,
-		ExtraArgs:  initialAttachArgs("", socketPath),
+		SocketPath:          socketPath,
+		ExtraArgs:           initialAttachArgs("", socketPath),
+		DiscardStartupReply: true,
 	}
 	transport, err := control.New(ctx, cfg)
 	if err != nil {
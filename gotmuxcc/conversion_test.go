@@ -1,6 +1,9 @@
 package gotmuxcc
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestClientConversion(t *testing.T) {
 	qr := queryResult{
@@ -192,3 +195,52 @@ func TestHelperFunctions(t *testing.T) {
 		t.Fatalf("expected list of length 3")
 	}
 }
+
+func TestTypedFieldConversion(t *testing.T) {
+	sess := queryResult{
+		varSessionActivity:     "1700000100",
+		varSessionCreated:      "1700000000",
+		varSessionLastAttached: "",
+	}.toSession(&Tmux{})
+	if !sess.CreatedTime().Equal(time.Unix(1700000000, 0)) || !sess.ActivityTime().Equal(time.Unix(1700000100, 0)) {
+		t.Fatalf("unexpected session times: created=%v activity=%v", sess.CreatedTime(), sess.ActivityTime())
+	}
+	if !sess.LastAttachedTime().IsZero() {
+		t.Fatalf("expected zero last-attached time, got %v", sess.LastAttachedTime())
+	}
+	if sess.Created != "1700000000" {
+		t.Fatalf("expected raw created field to be preserved, got %q", sess.Created)
+	}
+
+	pane := queryResult{
+		varPaneLeft:     "81",
+		varPaneTop:      "0",
+		varPaneRight:    "159",
+		varPaneBottom:   "47",
+		varPaneWidth:    "79",
+		varPaneHeight:   "48",
+		varPaneDeadTime: "1700000200",
+	}.toPane(&Tmux{})
+	want := PaneGeometry{Left: 81, Top: 0, Right: 159, Bottom: 47, Width: 79, Height: 48}
+	if pane.Geometry() != want {
+		t.Fatalf("unexpected pane geometry: %#v", pane.Geometry())
+	}
+	if !pane.DeathTime().Equal(time.Unix(1700000200, 0)) {
+		t.Fatalf("unexpected pane death time: %v", pane.DeathTime())
+	}
+
+	client := queryResult{
+		varClientActivity:  "1700000300",
+		varClientCreated:   "1700000000",
+		varClientDiscarded: "12",
+		varClientWritten:   "4096",
+	}.toClient(&Tmux{})
+	if !client.ActivityTime().Equal(time.Unix(1700000300, 0)) || client.DiscardedBytes() != 12 || client.WrittenBytes() != 4096 {
+		t.Fatalf("unexpected client typed fields: %#v", client)
+	}
+
+	window := queryResult{varWindowActivity: "1700000400"}.toWindow(&Tmux{})
+	if !window.ActivityTime().Equal(time.Unix(1700000400, 0)) {
+		t.Fatalf("unexpected window activity time: %v", window.ActivityTime())
+	}
+}
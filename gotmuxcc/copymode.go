@@ -0,0 +1,244 @@
+package gotmuxcc
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// copyBufferSeq makes the buffer prefixes CopyMode copies through unique.
+var copyBufferSeq atomic.Uint64
+
+// CopyMode drives a pane's copy mode. Its methods other than Enter and State
+// fail if the pane is not in copy mode.
+type CopyMode struct {
+	pane *Pane
+}
+
+// CopyMode returns a controller for the pane's copy mode.
+func (p *Pane) CopyMode() *CopyMode {
+	return &CopyMode{pane: p}
+}
+
+// Enter puts the pane into copy mode. It does nothing if it already is.
+func (c *CopyMode) Enter() error {
+	_, err := c.pane.tmux.query().
+		cmd("copy-mode").
+		fargs("-t", c.pane.Id).
+		run()
+	if err != nil {
+		return fmt.Errorf("failed to enter copy mode: %w", err)
+	}
+	return nil
+}
+
+// Exit leaves copy mode, or whichever mode the pane is in.
+func (c *CopyMode) Exit() error {
+	_, err := c.pane.tmux.query().
+		cmd("copy-mode").
+		fargs("-q", "-t", c.pane.Id).
+		run()
+	if err != nil {
+		return fmt.Errorf("failed to exit copy mode: %w", err)
+	}
+	return nil
+}
+
+// copyModeStateVars is the pane state a CopyModeState is built from.
+var copyModeStateVars = []string{
+	varPaneInMode,
+	varPaneMode,
+	varCopyCursorX,
+	varCopyCursorY,
+	varScrollPosition,
+	varHistorySize,
+	varSearchPresent,
+	varSelectionPresent,
+	varSelectionActive,
+	varSelectionStartX,
+	varSelectionStartY,
+	varSelectionEndX,
+	varSelectionEndY,
+	varPaneSearchString,
+	varSearchMatch,
+	varCopyCursorWord,
+	varCopyCursorLine,
+}
+
+// State returns the pane's copy-mode state.
+func (c *CopyMode) State() (*CopyModeState, error) {
+	output, err := c.pane.tmux.query().
+		cmd("display-message").
+		fargs("-t", c.pane.Id).
+		vars(copyModeStateVars...).
+		run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query copy mode: %w", lookupError("pane", c.pane.Id, err))
+	}
+	r := output.one()
+	if len(r) == 0 {
+		return nil, notFound("pane", c.pane.Id)
+	}
+	mode := r.get(varPaneMode)
+	return &CopyModeState{
+		Active:           isOne(r.get(varPaneInMode)) && (mode == "copy-mode" || mode == "view-mode"),
+		CursorX:          atoi(r.get(varCopyCursorX)),
+		CursorY:          atoi(r.get(varCopyCursorY)),
+		CursorLine:       r.get(varCopyCursorLine),
+		CursorWord:       r.get(varCopyCursorWord),
+		ScrollPosition:   atoi(r.get(varScrollPosition)),
+		HistorySize:      atoi(r.get(varHistorySize)),
+		SearchString:     r.get(varPaneSearchString),
+		SearchPresent:    isOne(r.get(varSearchPresent)),
+		SearchMatch:      r.get(varSearchMatch),
+		SelectionPresent: isOne(r.get(varSelectionPresent)),
+		SelectionActive:  isOne(r.get(varSelectionActive)),
+		SelectionStartX:  atoi(r.get(varSelectionStartX)),
+		SelectionStartY:  atoi(r.get(varSelectionStartY)),
+		SelectionEndX:    atoi(r.get(varSelectionEndX)),
+		SelectionEndY:    atoi(r.get(varSelectionEndY)),
+	}, nil
+}
+
+// Line returns the cursor's line, numbered like CaptureLine.Number.
+func (s *CopyModeState) Line() int {
+	return s.HistorySize - s.ScrollPosition + s.CursorY
+}
+
+// GotoLine moves the cursor to the start of line, numbered like
+// CaptureLine.Number, scrolling it to the top of the screen where possible.
+func (c *CopyMode) GotoLine(line int) error {
+	state, err := c.State()
+	if err != nil {
+		return err
+	}
+	line = max(line, 0)
+	position := max(state.HistorySize-line, 0)
+	keys := NewKeys().
+		CopyMode("goto-line", strconv.Itoa(position)).
+		CopyMode("top-line").
+		CopyMode("start-of-line")
+	if down := line - (state.HistorySize - position); down > 0 {
+		keys.CopyMode("cursor-down").Repeat(down)
+	}
+	return c.send(keys)
+}
+
+// SearchForward moves the cursor to the next match of the regular expression
+// pattern, wrapping around if wrap-search is on. It reports whether the
+// cursor moved, so a pattern matching only under the cursor reports false.
+func (c *CopyMode) SearchForward(pattern string) (bool, error) {
+	return c.search("search-forward", pattern)
+}
+
+// SearchBackward moves the cursor to the previous match of pattern, as
+// SearchForward does.
+func (c *CopyMode) SearchBackward(pattern string) (bool, error) {
+	return c.search("search-backward", pattern)
+}
+
+func (c *CopyMode) search(command, pattern string) (bool, error) {
+	before, err := c.State()
+	if err != nil {
+		return false, err
+	}
+	if err := c.send(NewKeys().CopyMode(command, pattern)); err != nil {
+		return false, err
+	}
+	after, err := c.State()
+	if err != nil {
+		return false, err
+	}
+	return after.Line() != before.Line() || after.CursorX != before.CursorX, nil
+}
+
+// BeginSelection starts a selection at the cursor.
+func (c *CopyMode) BeginSelection() error {
+	return c.send(NewKeys().CopyMode("begin-selection"))
+}
+
+// SelectLine selects the cursor's line.
+func (c *CopyMode) SelectLine() error {
+	return c.send(NewKeys().CopyMode("select-line"))
+}
+
+// ExtendSelection runs a cursor motion such as "cursor-down", "end-of-line"
+// or "next-word-end" count times, extending the selection.
+func (c *CopyMode) ExtendSelection(motion string, count int) error {
+	if count < 1 {
+		return invalidOptions("send-keys", "count", "must be positive")
+	}
+	return c.send(NewKeys().CopyMode(motion).Repeat(count))
+}
+
+// ClearSelection clears the selection, leaving copy mode active.
+func (c *CopyMode) ClearSelection() error {
+	return c.send(NewKeys().CopyMode("clear-selection"))
+}
+
+// SelectedText returns the selected text, or "" if nothing is selected. The
+// selection is kept.
+func (c *CopyMode) SelectedText() (string, error) {
+	name, err := c.copySelection()
+	if err != nil || name == "" {
+		return "", err
+	}
+	text, err := c.pane.tmux.showBuffer(name)
+	if err != nil {
+		return "", err
+	}
+	if err := c.pane.tmux.deleteBuffer(name); err != nil {
+		return "", err
+	}
+	return text, nil
+}
+
+// CopyToBuffer copies the selection to the named paste buffer, replacing
+// it. The selection is kept.
+func (c *CopyMode) CopyToBuffer(buffer string) error {
+	if buffer == "" {
+		return invalidOptions("set-buffer", "buffer", "must not be empty")
+	}
+	name, err := c.copySelection()
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		return fmt.Errorf("failed to copy selection of pane %s: nothing is selected", c.pane.Id)
+	}
+	_, err = c.pane.tmux.query().
+		cmd("set-buffer").
+		fargs("-b", name, "-n", buffer).
+		run()
+	if err != nil {
+		return fmt.Errorf("failed to rename buffer %s: %w", name, err)
+	}
+	return nil
+}
+
+// copySelection copies the selection to a new automatic buffer with a
+// unique prefix and returns its name, or "" if nothing is selected.
+func (c *CopyMode) copySelection() (string, error) {
+	prefix := fmt.Sprintf("gotmuxcc-%d-%d-", os.Getpid(), copyBufferSeq.Add(1))
+	if err := c.send(NewKeys().CopyMode("copy-selection-no-clear", prefix)); err != nil {
+		return "", err
+	}
+	names, err := c.pane.tmux.bufferNames()
+	if err != nil {
+		return "", err
+	}
+	if idx := slices.IndexFunc(names, func(name string) bool { return strings.HasPrefix(name, prefix) }); idx >= 0 {
+		return names[idx], nil
+	}
+	return "", nil
+}
+
+func (c *CopyMode) send(keys *Keys) error {
+	if err := c.pane.Send(keys); err != nil {
+		return fmt.Errorf("failed to run copy-mode command: %w", err)
+	}
+	return nil
+}
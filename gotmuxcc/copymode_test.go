@@ -0,0 +1,71 @@
+package gotmuxcc
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestCopyModeSelectedText(t *testing.T) {
+	ok := []string{"%begin 1 1 0", "%end 1 1 0"}
+	first := fmt.Sprintf("gotmuxcc-%d-%d-", os.Getpid(), copyBufferSeq.Load()+1)
+	second := fmt.Sprintf("gotmuxcc-%d-%d-", os.Getpid(), copyBufferSeq.Load()+2)
+	responses := []scriptedResponse{
+		// Nothing selected, so no buffer is created.
+		{match: "send-keys -t %1 -X -- copy-selection-no-clear " + first, lines: ok},
+		{match: "list-buffers -F", lines: []string{"%begin 1 1 0", "buffer0", "%end 1 1 0"}},
+		{match: "send-keys -t %1 -X -- copy-selection-no-clear " + second, lines: ok},
+		{match: "list-buffers -F", lines: []string{"%begin 1 1 0", second + "3", "buffer0", "%end 1 1 0"}},
+		{match: "show-buffer -b " + second + "3", lines: []string{"%begin 1 1 0", `error:\tboom \\x`, "", "%end 1 1 0"}},
+		{match: "delete-buffer -b " + second + "3", lines: ok},
+	}
+	tr := newScriptedTransport(responses)
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	cm := (&Pane{Id: "%1", tmux: tmux}).CopyMode()
+	if text, err := cm.SelectedText(); err != nil || text != "" {
+		t.Fatalf("expected no selection, got %q, %v", text, err)
+	}
+	if text, err := cm.SelectedText(); err != nil || text != "error:\tboom \\x\n" {
+		t.Fatalf("unexpected selection %q, %v", text, err)
+	}
+}
+
+func TestCopyModeGotoLine(t *testing.T) {
+	ok := []string{"%begin 1 1 0", "%end 1 1 0"}
+	state := []string{
+		"%begin 1 1 0",
+		formatRecord(copyModeStateVars, map[string]string{varPaneInMode: "1", varPaneMode: "copy-mode", varHistorySize: "50"}),
+		"%end 1 1 0",
+	}
+	responses := []scriptedResponse{
+		{match: "display-message -t %1", lines: state},
+		{match: "send-keys -t %1 -X -- goto-line 20", lines: ok},
+		{match: "send-keys -t %1 -X -- top-line", lines: ok},
+		{match: "send-keys -t %1 -X -- start-of-line", lines: ok},
+		// Lines on the last screen cannot reach the top, so the cursor moves.
+		{match: "display-message -t %1", lines: state},
+		{match: "send-keys -t %1 -X -- goto-line 0", lines: ok},
+		{match: "send-keys -t %1 -X -- top-line", lines: ok},
+		{match: "send-keys -t %1 -X -- start-of-line", lines: ok},
+		{match: "send-keys -t %1 -X -N 3 -- cursor-down", lines: ok},
+	}
+	tr := newScriptedTransport(responses)
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	cm := (&Pane{Id: "%1", tmux: tmux}).CopyMode()
+	for _, line := range []int{30, 53} {
+		if err := cm.GotoLine(line); err != nil {
+			t.Fatalf("GotoLine(%d) returned error: %v", line, err)
+		}
+	}
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	if len(tr.sent) != len(responses) {
+		t.Fatalf("expected %d commands, got %v", len(responses), tr.sent)
+	}
+}
@@ -0,0 +1,191 @@
+package gotmuxcc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EnvironmentVariable is a single entry of a tmux environment.
+type EnvironmentVariable struct {
+	Name  string
+	Value string
+	// Removed is set for entries tmux reports as -NAME: the variable is
+	// removed from the environment of processes started by tmux.
+	Removed bool
+	// Hidden variables are only used for format expansion and are not passed
+	// to processes (set-environment -h).
+	Hidden bool
+}
+
+// Environment maps variable names to their entries.
+type Environment map[string]*EnvironmentVariable
+
+// Lookup returns the value of name and whether it is set. Removed entries are
+// reported as unset.
+func (e Environment) Lookup(name string) (string, bool) {
+	variable, ok := e[name]
+	if !ok || variable.Removed {
+		return "", false
+	}
+	return variable.Value, true
+}
+
+// Environment returns the global environment.
+func (t *Tmux) Environment() (Environment, error) {
+	return t.showEnvironment("")
+}
+
+// SetEnv sets a variable in the global environment.
+func (t *Tmux) SetEnv(name, value string) error {
+	return t.setEnvironment("", nil, name, value)
+}
+
+// SetHiddenEnv sets a hidden variable in the global environment.
+func (t *Tmux) SetHiddenEnv(name, value string) error {
+	return t.setEnvironment("", []string{"-h"}, name, value)
+}
+
+// UnsetEnv deletes a variable from the global environment.
+func (t *Tmux) UnsetEnv(name string) error {
+	return t.setEnvironment("", []string{"-u"}, name)
+}
+
+// RemoveEnv marks a global variable as removed so it is cleared from the
+// environment of new processes.
+func (t *Tmux) RemoveEnv(name string) error {
+	return t.setEnvironment("", []string{"-r"}, name)
+}
+
+// UpdateEnvironment returns the variables copied from a client's environment
+// into the session environment when it attaches (the update-environment option).
+func (t *Tmux) UpdateEnvironment() ([]string, error) {
+	output, err := t.query().
+		cmd("show-options").
+		fargs("-g", "-v", "update-environment").
+		run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve update-environment: %w", err)
+	}
+
+	names := make([]string, 0, len(output.result.Lines))
+	for _, line := range output.result.Lines {
+		if line = strings.TrimSpace(line); line != "" {
+			names = append(names, line)
+		}
+	}
+	return names, nil
+}
+
+// SetUpdateEnvironment replaces the update-environment option.
+func (t *Tmux) SetUpdateEnvironment(names ...string) error {
+	_, err := t.query().
+		cmd("set-option").
+		fargs("-g").
+		pargs("update-environment", strings.Join(names, " ")).
+		run()
+	if err != nil {
+		return fmt.Errorf("failed to set update-environment: %w", err)
+	}
+	return nil
+}
+
+// Environment returns the session environment. It does not include variables
+// inherited from the global environment.
+func (s *Session) Environment() (Environment, error) {
+	return s.tmux.showEnvironment(s.target())
+}
+
+// SetEnv sets a variable in the session environment.
+func (s *Session) SetEnv(name, value string) error {
+	return s.tmux.setEnvironment(s.target(), nil, name, value)
+}
+
+// SetHiddenEnv sets a hidden variable in the session environment.
+func (s *Session) SetHiddenEnv(name, value string) error {
+	return s.tmux.setEnvironment(s.target(), []string{"-h"}, name, value)
+}
+
+// UnsetEnv deletes a variable from the session environment, so the global
+// value applies again.
+func (s *Session) UnsetEnv(name string) error {
+	return s.tmux.setEnvironment(s.target(), []string{"-u"}, name)
+}
+
+// RemoveEnv marks a variable as removed in the session environment, hiding
+// any global value from processes started in the session.
+func (s *Session) RemoveEnv(name string) error {
+	return s.tmux.setEnvironment(s.target(), []string{"-r"}, name)
+}
+
+func (t *Tmux) showEnvironment(target string) (Environment, error) {
+	env := make(Environment)
+	for _, hidden := range []bool{false, true} {
+		q := t.query().cmd("show-environment")
+		if target == "" {
+			q.fargs("-g")
+		} else {
+			q.fargs("-t", target)
+		}
+		if hidden {
+			q.fargs("-h")
+		}
+
+		output, err := q.run()
+		if err != nil {
+			return nil, fmt.Errorf("failed to show environment: %w", err)
+		}
+		for _, variable := range parseEnvironment(output.result.Lines, hidden) {
+			env[variable.Name] = variable
+		}
+	}
+	return env, nil
+}
+
+// setEnvironment runs set-environment with args holding the variable name and,
+// unless unsetting or removing, its value.
+func (t *Tmux) setEnvironment(target string, flags []string, args ...string) error {
+	if name := args[0]; !checkEnvironmentName(name) {
+		return invalidOptions("set-environment", "name", fmt.Sprintf("invalid variable name %q", name))
+	}
+
+	q := t.query().cmd("set-environment")
+	if target == "" {
+		q.fargs("-g")
+	} else {
+		q.fargs("-t", target)
+	}
+	q.fargs(flags...)
+	q.pargs(args...)
+
+	if _, err := q.run(); err != nil {
+		return fmt.Errorf("failed to set environment: %w", err)
+	}
+	return nil
+}
+
+func parseEnvironment(lines []string, hidden bool) []*EnvironmentVariable {
+	variables := make([]*EnvironmentVariable, 0, len(lines))
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "-") {
+			variables = append(variables, &EnvironmentVariable{
+				Name:    line[1:],
+				Removed: true,
+				Hidden:  hidden,
+			})
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		variables = append(variables, &EnvironmentVariable{
+			Name:   name,
+			Value:  value,
+			Hidden: hidden,
+		})
+	}
+	return variables
+}
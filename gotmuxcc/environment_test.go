@@ -0,0 +1,96 @@
+package gotmuxcc
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSessionEnvironmentParsing(t *testing.T) {
+	tr := newScriptedTransport([]scriptedResponse{
+		{match: "show-environment -t work", lines: []string{
+			"%begin 1 1 0",
+			"-DISPLAY",
+			"SSH_AUTH_SOCK=/tmp/agent.sock",
+			"EQUALS=a=b",
+			"%end 1 1 0",
+		}},
+		{match: "show-environment -t work -h", lines: []string{
+			"%begin 1 1 0",
+			"TOKEN=secret",
+			"%end 1 1 0",
+		}},
+	})
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	session := &Session{Name: "work", tmux: tmux}
+	env, err := session.Environment()
+	if err != nil {
+		t.Fatalf("Environment returned error: %v", err)
+	}
+
+	if value, ok := env.Lookup("SSH_AUTH_SOCK"); !ok || value != "/tmp/agent.sock" {
+		t.Fatalf("unexpected SSH_AUTH_SOCK lookup: %q %v", value, ok)
+	}
+	if value, _ := env.Lookup("EQUALS"); value != "a=b" {
+		t.Fatalf("expected value to keep embedded '=', got %q", value)
+	}
+	if display := env["DISPLAY"]; display == nil || !display.Removed {
+		t.Fatalf("expected DISPLAY to be marked removed: %#v", display)
+	}
+	if _, ok := env.Lookup("DISPLAY"); ok {
+		t.Fatalf("expected removed variable to be reported unset")
+	}
+	if token := env["TOKEN"]; token == nil || !token.Hidden || token.Value != "secret" {
+		t.Fatalf("unexpected hidden variable: %#v", token)
+	}
+}
+
+func TestEnvironmentCommands(t *testing.T) {
+	tr := newAutoTransport()
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	session := &Session{Name: "work", tmux: tmux}
+	steps := []func() error{
+		func() error { return tmux.SetEnv("EDITOR", "vim -u NONE") },
+		func() error { return tmux.SetHiddenEnv("TOKEN", "x") },
+		func() error { return tmux.UnsetEnv("EDITOR") },
+		func() error { return tmux.RemoveEnv("DISPLAY") },
+		func() error { return session.SetEnv("SSH_AUTH_SOCK", "/tmp/a") },
+		func() error { return session.UnsetEnv("SSH_AUTH_SOCK") },
+		func() error { return session.RemoveEnv("DISPLAY") },
+		func() error { return tmux.SetUpdateEnvironment("DISPLAY", "SSH_AUTH_SOCK") },
+	}
+	for idx, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("step %d returned error: %v", idx, err)
+		}
+	}
+
+	tr.sendMu.Lock()
+	sent := strings.Join(tr.sent, "\n")
+	tr.sendMu.Unlock()
+	for _, expected := range []string{
+		"set-environment -g -- EDITOR 'vim -u NONE'",
+		"set-environment -g -h -- TOKEN x",
+		"set-environment -g -u -- EDITOR",
+		"set-environment -g -r -- DISPLAY",
+		"set-environment -t work -- SSH_AUTH_SOCK /tmp/a",
+		"set-environment -t work -u -- SSH_AUTH_SOCK",
+		"set-environment -t work -r -- DISPLAY",
+		"set-option -g -- update-environment 'DISPLAY SSH_AUTH_SOCK'",
+	} {
+		if !strings.Contains(sent, expected) {
+			t.Fatalf("expected %q in commands:\n%s", expected, sent)
+		}
+	}
+
+	var optErr *InvalidOptionsError
+	if err := tmux.SetEnv("BAD=NAME", "x"); !errors.As(err, &optErr) {
+		t.Fatalf("expected InvalidOptionsError for bad name, got %v", err)
+	}
+}
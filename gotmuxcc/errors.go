@@ -0,0 +1,52 @@
+package gotmuxcc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// InvalidOptionsError reports option values, or combinations of values, that
+// tmux would reject. It is returned before any command is sent.
+type InvalidOptionsError struct {
+	Command string // tmux command the options were destined for
+	Option  string // offending option field
+	Reason  string
+}
+
+func (e *InvalidOptionsError) Error() string {
+	return fmt.Sprintf("gotmuxcc: invalid %s option %s: %s", e.Command, e.Option, e.Reason)
+}
+
+func invalidOptions(command, option, reason string) error {
+	return &InvalidOptionsError{Command: command, Option: option, Reason: reason}
+}
+
+// ErrNotFound is returned when a session, window, pane, paste buffer or
+// option does not exist.
+var ErrNotFound = errors.New("gotmuxcc: object not found")
+
+func notFound(kind, target string) error {
+	return fmt.Errorf("%s %s: %w", kind, target, ErrNotFound)
+}
+
+// lookupError converts tmux's "can't find" errors for target into ErrNotFound.
+func lookupError(kind, target string, err error) error {
+	var cmdErr *commandError
+	if errors.As(err, &cmdErr) && strings.Contains(strings.Join(cmdErr.Result.Lines, "\n"), "can't find") {
+		return notFound(kind, target)
+	}
+	return err
+}
+
+// ExitError reports a Cmd whose program exited unsuccessfully.
+type ExitError struct {
+	*ExitStatus
+}
+
+func (e *ExitError) Error() string {
+	if e.Signal != 0 {
+		return fmt.Sprintf("gotmuxcc: killed by signal %d", e.Signal)
+	}
+	return fmt.Sprintf("gotmuxcc: exit status %d", e.Code)
+}
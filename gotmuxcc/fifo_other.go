@@ -0,0 +1,16 @@
+//go:build !unix
+
+package gotmuxcc
+
+import (
+	"errors"
+	"os"
+)
+
+func mkfifo(string) error {
+	return errors.ErrUnsupported
+}
+
+func openFIFONonblock(string, int) (*os.File, error) {
+	return nil, errors.ErrUnsupported
+}
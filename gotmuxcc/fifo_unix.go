@@ -0,0 +1,17 @@
+//go:build unix
+
+package gotmuxcc
+
+import (
+	"os"
+	"syscall"
+)
+
+func mkfifo(path string) error {
+	return syscall.Mkfifo(path, 0o600)
+}
+
+// openFIFONonblock opens a FIFO without waiting for the other end.
+func openFIFONonblock(path string, flag int) (*os.File, error) {
+	return os.OpenFile(path, flag|syscall.O_NONBLOCK, 0)
+}
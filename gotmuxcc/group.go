@@ -0,0 +1,100 @@
+package gotmuxcc
+
+import "fmt"
+
+// SessionGroup represents a set of tmux sessions sharing the same windows.
+// Each member session keeps its own current window and size, so clients
+// attached to different members get independent views of one window set.
+type SessionGroup struct {
+	Name     string
+	Sessions []*Session
+	Windows  []*Window
+
+	tmux *Tmux
+}
+
+// ListSessionGroups returns every session group on the server.
+func (t *Tmux) ListSessionGroups() ([]*SessionGroup, error) {
+	sessions, err := t.ListSessions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list session groups: %w", err)
+	}
+
+	groups := make([]*SessionGroup, 0)
+	byName := make(map[string]*SessionGroup)
+	for _, session := range sessions {
+		if !session.Grouped || session.Group == "" {
+			continue
+		}
+		group, ok := byName[session.Group]
+		if !ok {
+			group = &SessionGroup{Name: session.Group, tmux: t}
+			byName[session.Group] = group
+			groups = append(groups, group)
+		}
+		group.Sessions = append(group.Sessions, session)
+	}
+
+	for _, group := range groups {
+		windows, err := group.Sessions[0].ListWindows()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list session groups: %w", err)
+		}
+		group.Windows = windows
+	}
+
+	return groups, nil
+}
+
+// GetSessionGroupByName retrieves a session group by its name.
+func (t *Tmux) GetSessionGroupByName(name string) (*SessionGroup, error) {
+	groups, err := t.ListSessionGroups()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session group by name: %w", err)
+	}
+
+	for _, group := range groups {
+		if group.Name == name {
+			return group, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// SessionGroup returns the group this session belongs to, or nil if the
+// session is not grouped.
+func (s *Session) SessionGroup() (*SessionGroup, error) {
+	if !s.Grouped || s.Group == "" {
+		return nil, nil
+	}
+	return s.tmux.GetSessionGroupByName(s.Group)
+}
+
+// NewSession creates a session that joins the group. Name, StartDirectory,
+// Width and Height are honoured; GroupTarget is set to an existing member.
+func (g *SessionGroup) NewSession(op *SessionOptions) (*Session, error) {
+	if len(g.Sessions) == 0 {
+		// tmux removes a group with its last member.
+		return nil, fmt.Errorf("failed to create session: %w", notFound("session group", g.Name))
+	}
+
+	opts := SessionOptions{}
+	if op != nil {
+		opts = *op
+	}
+	opts.GroupTarget = g.Sessions[0].Id
+
+	return g.tmux.NewSession(&opts)
+}
+
+// Kill terminates every session in the group, which also destroys the
+// shared windows.
+func (g *SessionGroup) Kill() error {
+	for _, session := range g.Sessions {
+		if err := session.Kill(); err != nil {
+			return fmt.Errorf("failed to kill session group: %w", err)
+		}
+	}
+	return nil
+}
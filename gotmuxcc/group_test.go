@@ -0,0 +1,110 @@
+package gotmuxcc
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestListSessionGroups(t *testing.T) {
+	sessionVars := func() []string {
+		q := newQuery(nil)
+		q.sessionVars()
+		return append([]string(nil), q.variables...)
+	}()
+	windowVars := func() []string {
+		q := newQuery(nil)
+		q.windowVars()
+		return append([]string(nil), q.variables...)
+	}()
+
+	responses := []scriptedResponse{
+		{match: "list-sessions", lines: []string{
+			"%begin 1 1 0",
+			formatRecord(sessionVars, map[string]string{
+				varSessionName:    "shared",
+				varSessionId:      "$1",
+				varSessionGroup:   "shared",
+				varSessionGrouped: "1",
+			}),
+			formatRecord(sessionVars, map[string]string{
+				varSessionName: "solo",
+				varSessionId:   "$2",
+			}),
+			formatRecord(sessionVars, map[string]string{
+				varSessionName:    "alice",
+				varSessionId:      "$3",
+				varSessionGroup:   "shared",
+				varSessionGrouped: "1",
+			}),
+			"%end 1 1 0",
+		}},
+		{match: "list-windows -t '$1'", lines: []string{
+			"%begin 1 1 0",
+			formatRecord(windowVars, map[string]string{
+				varWindowId:    "@1",
+				varWindowName:  "editor",
+				varSessionName: "shared",
+			}),
+			"%end 1 1 0",
+		}},
+	}
+
+	tr := newScriptedTransport(responses)
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	groups, err := tmux.ListSessionGroups()
+	if err != nil {
+		t.Fatalf("ListSessionGroups returned error: %v", err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("expected one group, got %d", len(groups))
+	}
+	group := groups[0]
+	if group.Name != "shared" || len(group.Sessions) != 2 || group.Sessions[1].Name != "alice" {
+		t.Fatalf("unexpected group members: %#v", group)
+	}
+	if len(group.Windows) != 1 || group.Windows[0].Id != "@1" {
+		t.Fatalf("unexpected group windows: %#v", group.Windows)
+	}
+}
+
+func TestSessionGroupNewSession(t *testing.T) {
+	tr := newAutoTransport()
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	group := &SessionGroup{
+		Name:     "shared",
+		Sessions: []*Session{{Id: "$1", Name: "shared", tmux: tmux}},
+		tmux:     tmux,
+	}
+	if _, err := group.NewSession(&SessionOptions{Name: "bob"}); err != nil {
+		t.Fatalf("NewSession returned error: %v", err)
+	}
+
+	tr.sendMu.Lock()
+	sent := strings.Join(tr.sent, "\n")
+	tr.sendMu.Unlock()
+	if !strings.Contains(sent, "new-session -d -P -s bob -t '$1'") {
+		t.Fatalf("expected grouped new-session command, saw %s", sent)
+	}
+}
+
+func TestSessionGroupNewSessionEmpty(t *testing.T) {
+	group := &SessionGroup{Name: "gone", tmux: &Tmux{}}
+	if _, err := group.NewSession(nil); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestNewSessionRejectsGroupWithCommand(t *testing.T) {
+	tmux := &Tmux{}
+	_, err := tmux.NewSession(&SessionOptions{GroupTarget: "shared", ShellCommand: "top"})
+	if err == nil {
+		t.Fatalf("expected error when combining group target and shell command")
+	}
+}
@@ -1,8 +1,11 @@
 package gotmuxcc
 
 import (
+	"fmt"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func checkSessionName(name string) bool {
@@ -18,6 +21,12 @@ func checkSessionName(name string) bool {
 	return true
 }
 
+// checkEnvironmentName reports whether name can be an environment variable
+// name: tmux splits NAME=value at the first '='.
+func checkEnvironmentName(name string) bool {
+	return name != "" && !strings.ContainsRune(name, '=')
+}
+
 func isOne(value string) bool {
 	return value == "1"
 }
@@ -37,3 +46,57 @@ func atoi(value string) int {
 func atoi32(value string) int32 {
 	return int32(atoi(value))
 }
+
+func atoi64(value string) int64 {
+	n, _ := strconv.ParseInt(value, 10, 64)
+	return n
+}
+
+// parseUnix converts a tmux epoch-seconds field into a time.Time. Missing or
+// zero values yield the zero time so callers can test with IsZero.
+func parseUnix(value string) time.Time {
+	secs := atoi64(value)
+	if secs <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(secs, 0)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sizeArgument(n int) string {
+	if n == SizeFromClient {
+		return "-"
+	}
+	return strconv.Itoa(n)
+}
+
+func validateEnvironment(command string, env map[string]string) error {
+	for key := range env {
+		if !checkEnvironmentName(key) {
+			return invalidOptions(command, "Environment", fmt.Sprintf("invalid variable name %q", key))
+		}
+	}
+	return nil
+}
+
+// escapeFormat escapes text so a tmux format expands it literally, including
+// inside a conditional or comparison argument.
+func escapeFormat(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch r {
+		case '#', ',', '}':
+			b.WriteByte('#')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
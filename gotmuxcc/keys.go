@@ -0,0 +1,272 @@
+package gotmuxcc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Key is a tmux key name, such as "Enter", "C-c" or "M-Left".
+type Key string
+
+// Common keys. Any name ParseKey accepts may be used as a Key.
+const (
+	KeyEnter     Key = "Enter"
+	KeyEscape    Key = "Escape"
+	KeyTab       Key = "Tab"
+	KeyBackTab   Key = "BTab"
+	KeySpace     Key = "Space"
+	KeyBackspace Key = "BSpace"
+	KeyUp        Key = "Up"
+	KeyDown      Key = "Down"
+	KeyLeft      Key = "Left"
+	KeyRight     Key = "Right"
+	KeyHome      Key = "Home"
+	KeyEnd       Key = "End"
+	KeyPageUp    Key = "PPage"
+	KeyPageDown  Key = "NPage"
+	KeyInsert    Key = "IC"
+	KeyDelete    Key = "DC"
+	KeyF1        Key = "F1"
+	KeyF2        Key = "F2"
+	KeyF3        Key = "F3"
+	KeyF4        Key = "F4"
+	KeyF5        Key = "F5"
+	KeyF6        Key = "F6"
+	KeyF7        Key = "F7"
+	KeyF8        Key = "F8"
+	KeyF9        Key = "F9"
+	KeyF10       Key = "F10"
+	KeyF11       Key = "F11"
+	KeyF12       Key = "F12"
+)
+
+// Ctrl returns key with the control modifier, for example Ctrl("c") is C-c.
+func Ctrl(key Key) Key { return "C-" + key }
+
+// Meta returns key with the meta (alt) modifier.
+func Meta(key Key) Key { return "M-" + key }
+
+// Shift returns key with the shift modifier.
+func Shift(key Key) Key { return "S-" + key }
+
+// keyNames is tmux's key name table, in tmux's order so the first spelling of
+// a key is the one tmux prints. Lookups ignore case.
+var keyNames = []string{
+	"F1", "F2", "F3", "F4", "F5", "F6", "F7", "F8", "F9", "F10", "F11", "F12",
+	"IC", "Insert",
+	"DC", "Delete",
+	"Home", "End",
+	"NPage", "PageDown", "PgDn",
+	"PPage", "PageUp", "PgUp",
+	"BTab", "Space", "BSpace", "Enter", "Escape", "Tab",
+	"Up", "Down", "Left", "Right",
+	"KP/", "KP*", "KP-", "KP7", "KP8", "KP9", "KP+", "KP4", "KP5", "KP6",
+	"KP1", "KP2", "KP3", "KPEnter", "KP0", "KP.",
+}
+
+// keyAliases maps each lower-cased spelling to the name tmux prints.
+var keyAliases = map[string]string{
+	"insert":   "IC",
+	"delete":   "DC",
+	"pagedown": "NPage",
+	"pgdn":     "NPage",
+	"pageup":   "PPage",
+	"pgup":     "PPage",
+}
+
+// KeyStroke is a parsed key: a base key and its modifiers. Base is either a
+// name from tmux's key table or a single character.
+type KeyStroke struct {
+	Base  string
+	Ctrl  bool
+	Meta  bool
+	Shift bool
+}
+
+// ParseKey parses a tmux key string such as "C-M-Left", "^a" or "PageDown".
+// Names are matched without regard to case and normalised to the spelling
+// tmux prints, so ParseKey(k.String()) returns k.
+func ParseKey(s string) (KeyStroke, error) {
+	var key KeyStroke
+	rest := s
+	if len(rest) > 1 && rest[0] == '^' {
+		key.Ctrl = true
+		rest = rest[1:]
+	}
+	for len(rest) > 2 && rest[1] == '-' {
+		switch rest[0] {
+		case 'C', 'c':
+			key.Ctrl = true
+		case 'M', 'm':
+			key.Meta = true
+		case 'S', 's':
+			key.Shift = true
+		default:
+			return KeyStroke{}, fmt.Errorf("gotmuxcc: unknown key modifier in %q", s)
+		}
+		rest = rest[2:]
+	}
+
+	if r, size := utf8.DecodeRuneInString(rest); size == len(rest) && r != utf8.RuneError {
+		if r < ' ' {
+			return KeyStroke{}, fmt.Errorf("gotmuxcc: unknown key %q", s)
+		}
+		key.Base = rest
+		return key, nil
+	}
+	lower := strings.ToLower(rest)
+	if name, ok := keyAliases[lower]; ok {
+		key.Base = name
+		return key, nil
+	}
+	for _, name := range keyNames {
+		if strings.ToLower(name) == lower {
+			key.Base = name
+			return key, nil
+		}
+	}
+	return KeyStroke{}, fmt.Errorf("gotmuxcc: unknown key %q", s)
+}
+
+// String formats the key the way tmux does, with modifiers in C-M-S- order.
+func (k KeyStroke) String() string {
+	var b strings.Builder
+	if k.Ctrl {
+		b.WriteString("C-")
+	}
+	if k.Meta {
+		b.WriteString("M-")
+	}
+	if k.Shift {
+		b.WriteString("S-")
+	}
+	b.WriteString(k.Base)
+	return b.String()
+}
+
+// Key returns the key as a Key.
+func (k KeyStroke) Key() Key { return Key(k.String()) }
+
+// Keys builds input for a pane. Each step becomes its own send-keys command,
+// since flags such as -l apply to all of a command's arguments. Consecutive
+// steps of the same kind are merged.
+type Keys struct {
+	steps []keyStep
+	err   error
+}
+
+type keyStep struct {
+	flag   string // "-l", "-H", "-X", "-R" or "" for key names
+	args   []string
+	repeat int
+}
+
+// NewKeys returns an empty key sequence.
+func NewKeys() *Keys {
+	return &Keys{}
+}
+
+// Text types text literally, without looking any of it up as a key name.
+func (k *Keys) Text(text string) *Keys {
+	if text == "" {
+		return k
+	}
+	return k.add("-l", text)
+}
+
+// Key presses the named keys, which must be valid tmux key names.
+func (k *Keys) Key(keys ...Key) *Keys {
+	for _, key := range keys {
+		stroke, err := ParseKey(string(key))
+		if err != nil {
+			k.fail("Key", fmt.Sprintf("unknown key %q", key))
+			return k
+		}
+		k.add("", stroke.String())
+	}
+	return k
+}
+
+// Hex sends raw bytes to the pane.
+func (k *Keys) Hex(data ...byte) *Keys {
+	for _, b := range data {
+		k.add("-H", strconv.FormatUint(uint64(b), 16))
+	}
+	return k
+}
+
+// CopyMode runs a copy-mode command, such as "cursor-up" or
+// "search-forward", in a pane that is in copy mode.
+func (k *Keys) CopyMode(command string, args ...string) *Keys {
+	if command == "" {
+		k.fail("CopyMode", "command must not be empty")
+		return k
+	}
+	k.steps = append(k.steps, keyStep{flag: "-X", args: append([]string{command}, args...)})
+	return k
+}
+
+// Reset resets the pane's terminal state.
+func (k *Keys) Reset() *Keys {
+	k.steps = append(k.steps, keyStep{flag: "-R"})
+	return k
+}
+
+// Repeat sends the most recent step n times. Directly preceding calls of the
+// same kind, such as Text("a").Text("b"), form one step.
+func (k *Keys) Repeat(n int) *Keys {
+	switch {
+	case len(k.steps) == 0:
+		k.fail("Repeat", "must follow a step")
+	case n < 1:
+		k.fail("Repeat", "must be positive")
+	default:
+		k.steps[len(k.steps)-1].repeat = n
+	}
+	return k
+}
+
+// add appends arg to the last step if it has the same kind and no repeat
+// count, and starts a new step otherwise.
+func (k *Keys) add(flag, arg string) *Keys {
+	if last := len(k.steps) - 1; last >= 0 && k.steps[last].flag == flag && k.steps[last].repeat == 0 {
+		k.steps[last].args = append(k.steps[last].args, arg)
+		return k
+	}
+	k.steps = append(k.steps, keyStep{flag: flag, args: []string{arg}})
+	return k
+}
+
+func (k *Keys) fail(option, reason string) {
+	if k.err == nil {
+		k.err = invalidOptions("send-keys", option, reason)
+	}
+}
+
+// Send sends keys to the pane, one send-keys command per step. Steps before
+// a failing one have already been sent.
+func (p *Pane) Send(keys *Keys) error {
+	if keys == nil {
+		return nil
+	}
+	if keys.err != nil {
+		return keys.err
+	}
+	for _, step := range keys.steps {
+		q := p.tmux.query().
+			cmd("send-keys").
+			fargs("-t", p.Id)
+		if step.flag != "" {
+			q.fargs(step.flag)
+		}
+		if step.repeat > 0 {
+			q.fargs("-N", strconv.Itoa(step.repeat))
+		}
+		if _, err := q.pargs(step.args...).run(); err != nil {
+			return fmt.Errorf("failed to send keys: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package gotmuxcc
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseKeyRoundTrips(t *testing.T) {
+	cases := map[string]string{
+		"Enter":      "Enter",
+		"enter":      "Enter",
+		"C-c":        "C-c",
+		"^a":         "C-a",
+		"m-left":     "M-Left",
+		"S-M-C-Up":   "C-M-S-Up",
+		"F12":        "F12",
+		"PageDown":   "NPage",
+		"PgUp":       "PPage",
+		"Delete":     "DC",
+		"kpenter":    "KPEnter",
+		"C--":        "C--",
+		"-":          "-",
+		"é":          "é",
+		"M-;":        "M-;",
+		"C-M-Space":  "C-M-Space",
+		"S-F1":       "S-F1",
+		"KP*":        "KP*",
+		"C-Escape":   "C-Escape",
+		"M-BSpace":   "M-BSpace",
+		"BTab":       "BTab",
+		"C-M-S-End":  "C-M-S-End",
+		"s-insert":   "S-IC",
+		"c-pagedown": "C-NPage",
+	}
+	for in, want := range cases {
+		key, err := ParseKey(in)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", in, err)
+			continue
+		}
+		if key.String() != want {
+			t.Errorf("%q: expected %q, got %q", in, want, key.String())
+		}
+		again, err := ParseKey(key.String())
+		if err != nil || again != key {
+			t.Errorf("%q: %q did not round-trip: %+v %v", in, key.String(), again, err)
+		}
+	}
+	for _, in := range []string{"", "Foo", "C-", "X-a", "C-Foo", "ab", "\n"} {
+		if _, err := ParseKey(in); err == nil {
+			t.Errorf("%q: expected an error", in)
+		}
+	}
+}
+
+func TestPaneSendKeys(t *testing.T) {
+	ok := []string{"%begin 1 1 0", "%end 1 1 0"}
+	responses := []scriptedResponse{
+		{match: "send-keys -t %1 -l -- 'echo hi' '; ls'", lines: ok},
+		{match: "send-keys -t %1 -- Enter C-c", lines: ok},
+		{match: "send-keys -t %1 -H -- 1b 0", lines: ok},
+		{match: "send-keys -t %1 -N 3 -- Up", lines: ok},
+		{match: "send-keys -t %1 -X -- search-forward 'a b'", lines: ok},
+		{match: "send-keys -t %1 -R", lines: ok},
+	}
+	tr := newScriptedTransport(responses)
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	keys := NewKeys().
+		Text("echo hi").Text("; ls").
+		Key(KeyEnter, Ctrl("c")).
+		Hex(0x1b, 0).
+		Key(KeyUp).Repeat(3).
+		CopyMode("search-forward", "a b").
+		Reset()
+	if err := (&Pane{Id: "%1", tmux: tmux}).Send(keys); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	if len(tr.sent) != len(responses) {
+		t.Fatalf("expected %d commands, got %v", len(responses), tr.sent)
+	}
+}
+
+func TestPaneSendDashText(t *testing.T) {
+	ok := []string{"%begin 1 1 0", "%end 1 1 0"}
+	responses := []scriptedResponse{
+		// Text that looks like flags must follow "--" or tmux rejects it.
+		{match: "send-keys -t %1 -l -- --version", lines: ok},
+		{match: "send-keys -t %1 -- Enter -", lines: ok},
+		{match: "send-keys -t %1 -l -- -x", lines: ok},
+	}
+	tr := newScriptedTransport(responses)
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	keys := NewKeys().Text("--version").Key(KeyEnter, "-").Text("-x")
+	if err := (&Pane{Id: "%1", tmux: tmux}).Send(keys); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	if len(tr.sent) != len(responses) {
+		t.Fatalf("expected %d commands, got %v", len(responses), tr.sent)
+	}
+}
+
+func TestPaneSendKeysInvalid(t *testing.T) {
+	pane := &Pane{Id: "%1", tmux: &Tmux{}}
+	var invalid *InvalidOptionsError
+	for name, keys := range map[string]*Keys{
+		"unknown key":        NewKeys().Text("x").Key("Foo"),
+		"repeat first":       NewKeys().Repeat(2),
+		"zero repeat":        NewKeys().Key(KeyEnter).Repeat(0),
+		"empty copy command": NewKeys().CopyMode(""),
+	} {
+		if err := pane.Send(keys); !errors.As(err, &invalid) {
+			t.Errorf("%s: expected InvalidOptionsError, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,482 @@
+package gotmuxcc
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrLayoutChecksum is returned by ParseLayout when a layout string's checksum
+// does not match its body.
+var ErrLayoutChecksum = errors.New("gotmuxcc: layout checksum mismatch")
+
+// LayoutType distinguishes pane cells from the two kinds of split.
+type LayoutType int
+
+const (
+	// LayoutPane is a leaf cell holding a single pane.
+	LayoutPane LayoutType = iota
+	// LayoutLeftRight places its children side by side ({...}).
+	LayoutLeftRight
+	// LayoutTopBottom stacks its children vertically ([...]).
+	LayoutTopBottom
+)
+
+// Layout is a cell of a tmux window layout. Splits hold child cells, separated
+// from each other by a one cell border; panes are the leaves.
+type Layout struct {
+	Type   LayoutType
+	Width  int
+	Height int
+	X      int
+	Y      int
+	// PaneId is the numeric pane ID of a leaf (%N without the %). select-layout
+	// assigns panes to leaves in order and ignores it.
+	PaneId   int
+	Children []*Layout
+}
+
+// ParseLayout parses a layout string such as window_layout, verifying its
+// checksum.
+func ParseLayout(layout string) (*Layout, error) {
+	sum, body, ok := strings.Cut(layout, ",")
+	if !ok || len(sum) != 4 {
+		return nil, fmt.Errorf("invalid layout %q: missing checksum", layout)
+	}
+	want, err := strconv.ParseUint(sum, 16, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid layout %q: bad checksum: %w", layout, err)
+	}
+	if uint16(want) != layoutChecksum(body) {
+		return nil, fmt.Errorf("invalid layout %q: %w", layout, ErrLayoutChecksum)
+	}
+
+	p := &layoutParser{input: body}
+	cell, err := p.cell()
+	if err != nil {
+		return nil, fmt.Errorf("invalid layout %q: %w", layout, err)
+	}
+	if p.pos != len(p.input) {
+		return nil, fmt.Errorf("invalid layout %q: trailing data at offset %d", layout, p.pos)
+	}
+	return cell, nil
+}
+
+// String serialises the layout with its checksum, ready for select-layout.
+func (l *Layout) String() string {
+	body := l.body()
+	return fmt.Sprintf("%04x,%s", layoutChecksum(body), body)
+}
+
+// Panes returns the layout's leaf cells in the order tmux assigns panes to
+// them.
+func (l *Layout) Panes() []*Layout {
+	if l.Type == LayoutPane {
+		return []*Layout{l}
+	}
+	panes := make([]*Layout, 0, len(l.Children))
+	for _, child := range l.Children {
+		panes = append(panes, child.Panes()...)
+	}
+	return panes
+}
+
+func (l *Layout) body() string {
+	var b strings.Builder
+	l.write(&b)
+	return b.String()
+}
+
+func (l *Layout) write(b *strings.Builder) {
+	fmt.Fprintf(b, "%dx%d,%d,%d", l.Width, l.Height, l.X, l.Y)
+	open, closing := "", ""
+	switch l.Type {
+	case LayoutPane:
+		fmt.Fprintf(b, ",%d", l.PaneId)
+		return
+	case LayoutLeftRight:
+		open, closing = "{", "}"
+	case LayoutTopBottom:
+		open, closing = "[", "]"
+	}
+	b.WriteString(open)
+	for idx, child := range l.Children {
+		if idx > 0 {
+			b.WriteByte(',')
+		}
+		child.write(b)
+	}
+	b.WriteString(closing)
+}
+
+// layoutChecksum is tmux's layout_checksum: a 16-bit rotate-and-add over the
+// layout body.
+func layoutChecksum(body string) uint16 {
+	var sum uint16
+	for idx := 0; idx < len(body); idx++ {
+		sum = (sum >> 1) + ((sum & 1) << 15)
+		sum += uint16(body[idx])
+	}
+	return sum
+}
+
+type layoutParser struct {
+	input string
+	pos   int
+}
+
+func (p *layoutParser) cell() (*Layout, error) {
+	cell := &Layout{}
+	var err error
+	if cell.Width, err = p.number(); err != nil {
+		return nil, err
+	}
+	if err = p.expect('x'); err != nil {
+		return nil, err
+	}
+	if cell.Height, err = p.number(); err != nil {
+		return nil, err
+	}
+	if err = p.expect(','); err != nil {
+		return nil, err
+	}
+	if cell.X, err = p.number(); err != nil {
+		return nil, err
+	}
+	if err = p.expect(','); err != nil {
+		return nil, err
+	}
+	if cell.Y, err = p.number(); err != nil {
+		return nil, err
+	}
+
+	var closing byte
+	switch p.peek() {
+	case ',':
+		p.pos++
+		if cell.PaneId, err = p.number(); err != nil {
+			return nil, err
+		}
+		return cell, nil
+	case '{':
+		cell.Type, closing = LayoutLeftRight, '}'
+	case '[':
+		cell.Type, closing = LayoutTopBottom, ']'
+	default:
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.peek(), p.pos)
+	}
+
+	p.pos++
+	for {
+		child, err := p.cell()
+		if err != nil {
+			return nil, err
+		}
+		cell.Children = append(cell.Children, child)
+		if p.peek() == ',' {
+			p.pos++
+			continue
+		}
+		if err := p.expect(closing); err != nil {
+			return nil, err
+		}
+		return cell, nil
+	}
+}
+
+func (p *layoutParser) peek() byte {
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *layoutParser) expect(c byte) error {
+	if p.peek() != c {
+		return fmt.Errorf("expected %q at offset %d", c, p.pos)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *layoutParser) number() (int, error) {
+	start := p.pos
+	for p.pos < len(p.input) && p.input[p.pos] >= '0' && p.input[p.pos] <= '9' {
+		p.pos++
+	}
+	if start == p.pos {
+		return 0, fmt.Errorf("expected number at offset %d", start)
+	}
+	return strconv.Atoi(p.input[start:p.pos])
+}
+
+// GridLayout divides a width x height window into rows of columns cells.
+func GridLayout(width, height, columns, rows int) (*Layout, error) {
+	if columns < 1 || rows < 1 {
+		return nil, invalidOptions("layout", "GridLayout", "columns and rows must be positive")
+	}
+	heights, err := splitSize(height, rows)
+	if err != nil {
+		return nil, err
+	}
+	widths, err := splitSize(width, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &Layout{Type: LayoutTopBottom, Width: width, Height: height}
+	y := 0
+	for _, rowHeight := range heights {
+		row := &Layout{Type: LayoutLeftRight, Width: width, Height: rowHeight, Y: y}
+		x := 0
+		for _, columnWidth := range widths {
+			row.Children = append(row.Children, &Layout{Width: columnWidth, Height: rowHeight, X: x, Y: y})
+			x += columnWidth + 1
+		}
+		root.Children = append(root.Children, row)
+		y += rowHeight + 1
+	}
+	return root.normalise(), nil
+}
+
+// GoldenLayout arranges panes in a golden-ratio spiral: each pane takes 61.8%
+// of the remaining space along its longer side.
+func GoldenLayout(width, height, panes int) (*Layout, error) {
+	if panes < 1 {
+		return nil, invalidOptions("layout", "GoldenLayout", "panes must be positive")
+	}
+	root, err := goldenCell(0, 0, width, height, panes)
+	if err != nil {
+		return nil, err
+	}
+	return root.normalise(), nil
+}
+
+func goldenCell(x, y, width, height, panes int) (*Layout, error) {
+	if width < 1 || height < 1 {
+		return nil, invalidOptions("layout", "GoldenLayout", "window too small for pane count")
+	}
+	if panes == 1 {
+		return &Layout{Width: width, Height: height, X: x, Y: y}, nil
+	}
+
+	cell := &Layout{Width: width, Height: height, X: x, Y: y}
+	if width >= height*2 {
+		// Terminal cells are about twice as tall as they are wide.
+		first := goldenShare(width)
+		cell.Type = LayoutLeftRight
+		rest, err := goldenCell(x+first+1, y, width-first-1, height, panes-1)
+		if err != nil {
+			return nil, err
+		}
+		cell.Children = []*Layout{{Width: first, Height: height, X: x, Y: y}, rest}
+	} else {
+		first := goldenShare(height)
+		cell.Type = LayoutTopBottom
+		rest, err := goldenCell(x, y+first+1, width, height-first-1, panes-1)
+		if err != nil {
+			return nil, err
+		}
+		cell.Children = []*Layout{{Width: width, Height: first, X: x, Y: y}, rest}
+	}
+	return cell, nil
+}
+
+func goldenShare(size int) int {
+	share := (size - 1) * 618 / 1000
+	if share < 1 {
+		share = 1
+	}
+	return share
+}
+
+// SidebarLayout places a sidebarWidth column on the left (or right) of the
+// window and stacks the remaining panes evenly beside it. The sidebar is the
+// first pane, or the last when right is set.
+func SidebarLayout(width, height, sidebarWidth, panes int, right bool) (*Layout, error) {
+	if panes < 2 {
+		return nil, invalidOptions("layout", "SidebarLayout", "needs at least two panes")
+	}
+	if sidebarWidth < 1 || sidebarWidth >= width-1 {
+		return nil, invalidOptions("layout", "SidebarLayout", fmt.Sprintf("sidebar width %d does not fit in %d columns", sidebarWidth, width))
+	}
+	mainWidth := width - sidebarWidth - 1
+	heights, err := splitSize(height, panes-1)
+	if err != nil {
+		return nil, err
+	}
+
+	sidebarX, mainX := 0, sidebarWidth+1
+	if right {
+		sidebarX, mainX = mainWidth+1, 0
+	}
+	sidebar := &Layout{Width: sidebarWidth, Height: height, X: sidebarX}
+	var main *Layout
+	if len(heights) == 1 {
+		main = &Layout{Width: mainWidth, Height: height, X: mainX}
+	} else {
+		main = &Layout{Type: LayoutTopBottom, Width: mainWidth, Height: height, X: mainX}
+		y := 0
+		for _, paneHeight := range heights {
+			main.Children = append(main.Children, &Layout{Width: mainWidth, Height: paneHeight, X: mainX, Y: y})
+			y += paneHeight + 1
+		}
+	}
+
+	root := &Layout{Type: LayoutLeftRight, Width: width, Height: height, Children: []*Layout{sidebar, main}}
+	if right {
+		root.Children = []*Layout{main, sidebar}
+	}
+	return root.normalise(), nil
+}
+
+// splitSize divides size into count parts separated by one cell borders,
+// giving any remainder to the first parts.
+func splitSize(size, count int) ([]int, error) {
+	available := size - (count - 1)
+	if count < 1 || available < count {
+		return nil, invalidOptions("layout", "size", fmt.Sprintf("%d cells cannot hold %d panes", size, count))
+	}
+	sizes := make([]int, count)
+	for idx := range sizes {
+		sizes[idx] = available / count
+		if idx < available%count {
+			sizes[idx]++
+		}
+	}
+	return sizes, nil
+}
+
+// normalise collapses single-child splits and numbers the leaves in order.
+func (l *Layout) normalise() *Layout {
+	root := l.collapse()
+	for idx, pane := range root.Panes() {
+		pane.PaneId = idx
+	}
+	return root
+}
+
+func (l *Layout) collapse() *Layout {
+	if l.Type != LayoutPane && len(l.Children) == 1 {
+		return l.Children[0].collapse()
+	}
+	for idx, child := range l.Children {
+		l.Children[idx] = child.collapse()
+	}
+	return l
+}
+
+// layoutMatches reports whether the window_layout string current already has
+// the shape select-layout would give it. A layout string must match exactly,
+// apart from pane IDs. Presets are compared by structure, since their sizes
+// depend on options such as main-pane-width; the even presets also require
+// the panes to share the space as evenly as tmux spreads them.
+func layoutMatches(current string, want WindowLayout) bool {
+	got, err := ParseLayout(current)
+	if err != nil {
+		return false
+	}
+	got = got.normalise()
+	count := len(got.Panes())
+
+	var shape *Layout
+	switch want {
+	case WindowLayoutEvenHorizontal:
+		shape = layoutSplit(LayoutLeftRight, count)
+	case WindowLayoutEvenVertical:
+		shape = layoutSplit(LayoutTopBottom, count)
+	case WindowLayoutMainHorizontal:
+		shape = &Layout{Type: LayoutTopBottom, Children: []*Layout{{}, layoutSplit(LayoutLeftRight, count-1)}}
+	case WindowLayoutMainVertical:
+		shape = &Layout{Type: LayoutLeftRight, Children: []*Layout{{}, layoutSplit(LayoutTopBottom, count-1)}}
+	case WindowLayoutTiled:
+		shape = tiledShape(count)
+	default:
+		custom, err := ParseLayout(string(want))
+		return err == nil && custom.normalise().String() == got.String()
+	}
+	if !got.sameShape(shape.collapse()) {
+		return false
+	}
+	if want == WindowLayoutEvenHorizontal || want == WindowLayoutEvenVertical {
+		return got.even()
+	}
+	return true
+}
+
+// layoutSplit returns an unsized split of count panes, or a single pane.
+func layoutSplit(kind LayoutType, count int) *Layout {
+	if count < 2 {
+		return &Layout{}
+	}
+	split := &Layout{Type: kind, Children: make([]*Layout, count)}
+	for idx := range split.Children {
+		split.Children[idx] = &Layout{}
+	}
+	return split
+}
+
+// tiledShape mirrors the grid tmux's tiled preset builds: rows of up to
+// columns panes, with any shortfall in the last row.
+func tiledShape(count int) *Layout {
+	rows, columns := 1, 1
+	for rows*columns < count {
+		rows++
+		if rows*columns < count {
+			columns++
+		}
+	}
+	root := &Layout{Type: LayoutTopBottom}
+	for left := count; left > 0; left -= columns {
+		root.Children = append(root.Children, layoutSplit(LayoutLeftRight, min(columns, left)))
+	}
+	return root
+}
+
+// sameShape compares the split structure of two layouts, ignoring sizes.
+func (l *Layout) sameShape(other *Layout) bool {
+	if l.Type != other.Type || len(l.Children) != len(other.Children) {
+		return false
+	}
+	for idx, child := range l.Children {
+		if !child.sameShape(other.Children[idx]) {
+			return false
+		}
+	}
+	return true
+}
+
+// even reports whether a split's children differ in size by less than their
+// number, the most tmux leaves over when it spreads them.
+func (l *Layout) even() bool {
+	lo, hi := -1, -1
+	for _, child := range l.Children {
+		size := child.Width
+		if l.Type == LayoutTopBottom {
+			size = child.Height
+		}
+		if lo < 0 || size < lo {
+			lo = size
+		}
+		hi = max(hi, size)
+	}
+	return hi-lo < len(l.Children)
+}
+
+// LayoutTree parses the window's layout.
+func (w *Window) LayoutTree() (*Layout, error) {
+	return ParseLayout(w.Layout)
+}
+
+// ApplyLayout sets a custom layout on the window with select-layout. tmux
+// does not rescale it: the panes take the recorded sizes and the window is
+// resized to the layout's, so build it for the window's current size. It fails
+// if the window has more panes than the layout has leaves; spare leaves are
+// dropped from the bottom right.
+func (w *Window) ApplyLayout(layout *Layout) (*Window, error) {
+	if err := w.SelectLayout(WindowLayout(layout.String())); err != nil {
+		return nil, err
+	}
+	return w.refreshed()
+}
@@ -0,0 +1,136 @@
+package gotmuxcc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParseLayoutRoundTrip(t *testing.T) {
+	cases := []string{
+		"7f31,160x48,0,0{80x48,0,0,0,79x48,81,0[79x24,81,0,1,79x23,81,25,2]}",
+		"b262,80x24,0,0,5",
+		"edbe,160x48,0,0[160x24,0,0{80x24,0,0,1,79x24,81,0,2},160x23,0,25,3]",
+	}
+	for _, layout := range cases {
+		parsed, err := ParseLayout(layout)
+		if err != nil {
+			t.Fatalf("ParseLayout(%q) returned error: %v", layout, err)
+		}
+		if got := parsed.String(); got != layout {
+			t.Fatalf("round trip mismatch: %q -> %q", layout, got)
+		}
+	}
+
+	parsed, _ := ParseLayout(cases[2])
+	if parsed.Type != LayoutTopBottom || len(parsed.Children) != 2 {
+		t.Fatalf("unexpected root: %#v", parsed)
+	}
+	panes := parsed.Panes()
+	if len(panes) != 3 || panes[1].PaneId != 2 || panes[1].X != 81 || panes[2].Y != 25 {
+		t.Fatalf("unexpected panes: %#v", panes)
+	}
+}
+
+func TestParseLayoutErrors(t *testing.T) {
+	if _, err := ParseLayout("0000,160x48,0,0,1"); !errors.Is(err, ErrLayoutChecksum) {
+		t.Fatalf("expected ErrLayoutChecksum, got %v", err)
+	}
+	for _, body := range []string{"160x48,0,0", "160x48,0,0{80x48,0,0,1", "160x48,0,0,1x"} {
+		layout := fmt.Sprintf("%04x,%s", layoutChecksum(body), body)
+		if _, err := ParseLayout(layout); err == nil || errors.Is(err, ErrLayoutChecksum) {
+			t.Fatalf("expected syntax error for %q, got %v", layout, err)
+		}
+	}
+}
+
+func TestLayoutMatches(t *testing.T) {
+	withSum := func(body string) string { return fmt.Sprintf("%04x,%s", layoutChecksum(body), body) }
+	// Layouts as tmux 3.3a draws its presets in an 80x24 window.
+	evenH := "764c,80x24,0,0{19x24,0,0,0,19x24,20,0,1,19x24,40,0,2,20x24,60,0,3}"
+	evenV := "a0c0,80x24,0,0[80x5,0,0,0,80x5,0,6,1,80x5,0,12,2,80x6,0,18,3]"
+	mainH := "4317,80x24,0,0[80x22,0,0,0,80x1,0,23{26x1,0,23,1,26x1,27,23,2,26x1,54,23,3}]"
+	mainV := "5624,80x24,0,0{78x24,0,0,0,1x24,79,0[1x7,79,0,1,1x7,79,8,2,1x8,79,16,3]}"
+	tiled := "30d6,80x24,0,0[80x11,0,0{39x11,0,0,0,40x11,40,0,1},80x12,0,12{39x12,0,12,2,40x12,40,12,3}]"
+	tiled3 := "a7cf,80x24,0,0[80x11,0,0{39x11,0,0,0,40x11,40,0,1},80x12,0,12,2]"
+	pair := "89f5,80x24,0,0{39x24,0,0,0,40x24,40,0,1}"
+
+	cases := []struct {
+		current string
+		want    WindowLayout
+		match   bool
+	}{
+		{evenH, WindowLayoutEvenHorizontal, true},
+		{evenV, WindowLayoutEvenVertical, true},
+		{mainH, WindowLayoutMainHorizontal, true},
+		{mainV, WindowLayoutMainVertical, true},
+		{tiled, WindowLayoutTiled, true},
+		{tiled3, WindowLayoutTiled, true},
+		{pair, WindowLayoutEvenHorizontal, true},
+		{pair, WindowLayoutMainVertical, true},
+		{evenH, WindowLayoutEvenVertical, false},
+		{evenV, WindowLayoutTiled, false},
+		{tiled, WindowLayoutMainHorizontal, false},
+		{mainV, WindowLayoutEvenHorizontal, false},
+		{pair, WindowLayoutTiled, false},
+		// Resized by hand: the structure matches but the panes are uneven.
+		{withSum("80x24,0,0{10x24,0,0,0,69x24,11,0,1}"), WindowLayoutEvenHorizontal, false},
+		// Layout strings match regardless of pane IDs.
+		{tiled3, WindowLayout(withSum("80x24,0,0[80x11,0,0{39x11,0,0,7,40x11,40,0,8},80x12,0,12,9]")), true},
+		{tiled3, WindowLayout(withSum("80x24,0,0[80x12,0,0{39x12,0,0,7,40x12,40,0,8},80x11,0,13,9]")), false},
+		{"", WindowLayoutTiled, false},
+		{tiled, "bogus", false},
+	}
+	for _, tc := range cases {
+		if got := layoutMatches(tc.current, tc.want); got != tc.match {
+			t.Errorf("layoutMatches(%q, %q) = %v, want %v", tc.current, tc.want, got, tc.match)
+		}
+	}
+}
+
+func TestLayoutGenerators(t *testing.T) {
+	grid, err := GridLayout(160, 48, 2, 2)
+	if err != nil {
+		t.Fatalf("GridLayout returned error: %v", err)
+	}
+	if got, want := grid.body(), "160x48,0,0[160x24,0,0{80x24,0,0,0,79x24,81,0,1},160x23,0,25{80x23,0,25,2,79x23,81,25,3}]"; got != want {
+		t.Fatalf("unexpected grid:\n got %s\nwant %s", got, want)
+	}
+
+	row, err := GridLayout(80, 24, 3, 1)
+	if err != nil {
+		t.Fatalf("GridLayout returned error: %v", err)
+	}
+	if got, want := row.body(), "80x24,0,0{26x24,0,0,0,26x24,27,0,1,26x24,54,0,2}"; got != want {
+		t.Fatalf("unexpected single row grid: %s", got)
+	}
+
+	sidebar, err := SidebarLayout(120, 40, 30, 3, false)
+	if err != nil {
+		t.Fatalf("SidebarLayout returned error: %v", err)
+	}
+	if got, want := sidebar.body(), "120x40,0,0{30x40,0,0,0,89x40,31,0[89x20,31,0,1,89x19,31,21,2]}"; got != want {
+		t.Fatalf("unexpected sidebar layout: %s", got)
+	}
+	right, _ := SidebarLayout(120, 40, 30, 2, true)
+	if got, want := right.body(), "120x40,0,0{89x40,0,0,0,30x40,90,0,1}"; got != want {
+		t.Fatalf("unexpected right sidebar layout: %s", got)
+	}
+
+	golden, err := GoldenLayout(160, 48, 3)
+	if err != nil {
+		t.Fatalf("GoldenLayout returned error: %v", err)
+	}
+	panes := golden.Panes()
+	if len(panes) != 3 || panes[0].Width != 98 || panes[1].Height != 29 {
+		t.Fatalf("unexpected golden layout: %s", golden.body())
+	}
+
+	var invalid *InvalidOptionsError
+	if _, err := GridLayout(5, 5, 4, 1); !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidOptionsError for oversized grid, got %v", err)
+	}
+	if _, err := SidebarLayout(40, 10, 40, 2, false); !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidOptionsError for oversized sidebar, got %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package gotmuxcc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultMetaNamespace is the namespace Metadata stores keys in unless another
+// is chosen.
+const DefaultMetaNamespace = "meta"
+
+// Metadata is a key/value store on a session, window or pane. Each value is
+// JSON-encoded in a user option named "@namespace.key", so it lives in the
+// tmux server and outlasts the process that set it.
+type Metadata struct {
+	options   *ScopedOptions
+	namespace string
+}
+
+// Meta returns the pane's metadata in DefaultMetaNamespace.
+func (p *Pane) Meta() *Metadata {
+	return &Metadata{options: p.tmux.OptionsAt(PaneScope(p.Id), nil), namespace: DefaultMetaNamespace}
+}
+
+// Meta returns the window's metadata in DefaultMetaNamespace.
+func (w *Window) Meta() *Metadata {
+	return &Metadata{options: w.tmux.OptionsAt(WindowScope(w.Id), nil), namespace: DefaultMetaNamespace}
+}
+
+// Meta returns the session's metadata in DefaultMetaNamespace.
+func (s *Session) Meta() *Metadata {
+	return &Metadata{options: s.tmux.OptionsAt(SessionScope(s.target()), nil), namespace: DefaultMetaNamespace}
+}
+
+// Namespace returns the same object's metadata in another namespace.
+func (m *Metadata) Namespace(name string) *Metadata {
+	return &Metadata{options: m.options, namespace: name}
+}
+
+// Set stores value, encoded as JSON, under key.
+func (m *Metadata) Set(key string, value any) error {
+	name, err := m.optionName(key)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata %s: %w", key, err)
+	}
+	return m.options.Set(name, string(data), nil)
+}
+
+// Get decodes the value stored under key into value. It returns an error
+// wrapping ErrNotFound if the key is not set on this object; values on a
+// parent, such as a pane's window, are not consulted.
+func (m *Metadata) Get(key string, value any) error {
+	name, err := m.optionName(key)
+	if err != nil {
+		return err
+	}
+	option, err := m.options.Get(name, nil)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(option.Value), value); err != nil {
+		return fmt.Errorf("failed to decode metadata %s: %w", key, err)
+	}
+	return nil
+}
+
+// GetAll returns every value in the namespace, by key, with one command.
+func (m *Metadata) GetAll() (map[string]json.RawMessage, error) {
+	if err := validateMetaName("namespace", m.namespace); err != nil {
+		return nil, err
+	}
+	options, err := m.options.List(nil)
+	if err != nil {
+		return nil, err
+	}
+	prefix := "@" + m.namespace + "."
+	values := make(map[string]json.RawMessage)
+	for _, option := range options {
+		key, ok := strings.CutPrefix(option.Key, prefix)
+		if !ok {
+			continue
+		}
+		if !json.Valid([]byte(option.Value)) {
+			return nil, fmt.Errorf("failed to decode metadata %s: value is not JSON", key)
+		}
+		values[key] = json.RawMessage(option.Value)
+	}
+	return values, nil
+}
+
+// Delete removes key.
+func (m *Metadata) Delete(key string) error {
+	name, err := m.optionName(key)
+	if err != nil {
+		return err
+	}
+	return m.options.Unset(name)
+}
+
+// optionName returns the user option key is stored in.
+func (m *Metadata) optionName(key string) (string, error) {
+	if err := validateMetaName("namespace", m.namespace); err != nil {
+		return "", err
+	}
+	if err := validateMetaName("key", key); err != nil {
+		return "", err
+	}
+	return "@" + m.namespace + "." + key, nil
+}
+
+// validateMetaName accepts names made of letters, digits, '-' and '_', so
+// they can't collide with the namespace separator or array indices.
+func validateMetaName(option, name string) error {
+	if name == "" {
+		return invalidOptions("meta", option, "must not be empty")
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return invalidOptions("meta", option, fmt.Sprintf("invalid character %q in %q", r, name))
+		}
+	}
+	return nil
+}
+
+// FindPanesByMeta returns the panes whose metadata in DefaultMetaNamespace
+// has value under key.
+func (t *Tmux) FindPanesByMeta(key string, value any) ([]*Pane, error) {
+	return t.FindPanesByMetaNamespace(DefaultMetaNamespace, key, value)
+}
+
+// FindPanesByMetaNamespace returns the panes whose metadata in namespace has
+// value under key. tmux compares the encoded values itself, in a list-panes
+// filter. As in any format, a pane without the key sees its window's or
+// session's value, so metadata set there matches all their panes.
+func (t *Tmux) FindPanesByMetaNamespace(namespace, key string, value any) ([]*Pane, error) {
+	name, err := (&Metadata{namespace: namespace}).optionName(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode metadata %s: %w", key, err)
+	}
+	output, err := t.query().
+		cmd("list-panes").
+		fargs("-a", "-f", fmt.Sprintf("#{==:#{%s},%s}", name, escapeFormat(string(data)))).
+		paneVars().
+		run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find panes: %w", err)
+	}
+
+	results := output.collect()
+	panes := make([]*Pane, 0, len(results))
+	for _, entry := range results {
+		panes = append(panes, entry.toPane(t))
+	}
+	return panes, nil
+}
@@ -0,0 +1,97 @@
+package gotmuxcc
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPaneMeta(t *testing.T) {
+	ok := []string{"%begin 1 1 0", "%end 1 1 0"}
+	tr := newScriptedTransport([]scriptedResponse{
+		{match: `set-option -p -t %1 -- @meta.owner '{"name":"a b","ids":[1,2]}'`, lines: ok},
+		{match: "show-options -p -t %1 -v -- @meta.owner", lines: []string{"%begin 1 1 0", `{"name":"a b","ids":[1,2]}`, "%end 1 1 0"}},
+		{match: "show-options -p -t %1 -v -- @app.missing", lines: []string{"%begin 1 1 0", "invalid option: @app.missing", "%error 1 1 0"}},
+		{match: "show-options -p -t %1", lines: []string{
+			"%begin 1 1 0",
+			`@meta.owner "{\"name\":\"a b\",\"ids\":[1,2]}"`,
+			`@meta.role '"db"'`,
+			`@app.role '"web"'`,
+			"remain-on-exit on",
+			"%end 1 1 0",
+		}},
+		{match: "set-option -p -t %1 -u -- @meta.role", lines: ok},
+	})
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	type owner struct {
+		Name string `json:"name"`
+		IDs  []int  `json:"ids"`
+	}
+	meta := (&Pane{Id: "%1", tmux: tmux}).Meta()
+	want := owner{Name: "a b", IDs: []int{1, 2}}
+	if err := meta.Set("owner", want); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	var got owner
+	if err := meta.Get("owner", &got); err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got %+v %v", want, got, err)
+	}
+	if err := meta.Namespace("app").Get("missing", &got); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	all, err := meta.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll returned error: %v", err)
+	}
+	wantAll := map[string]json.RawMessage{
+		"owner": json.RawMessage(`{"name":"a b","ids":[1,2]}`),
+		"role":  json.RawMessage(`"db"`),
+	}
+	if !reflect.DeepEqual(all, wantAll) {
+		t.Fatalf("expected %s, got %s", wantAll, all)
+	}
+	if err := meta.Delete("role"); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+}
+
+func TestFindPanesByMeta(t *testing.T) {
+	q := newQuery(nil)
+	q.paneVars()
+	tr := newScriptedTransport([]scriptedResponse{
+		{match: `list-panes -a -f '#{==:#{@meta.role},{"a":"x#,y#}##"#}}'`, lines: []string{
+			"%begin 1 1 0",
+			formatRecord(q.variables, map[string]string{varPaneId: "%4"}),
+			"%end 1 1 0",
+		}},
+	})
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	panes, err := tmux.FindPanesByMeta("role", map[string]string{"a": "x,y}#"})
+	if err != nil {
+		t.Fatalf("FindPanesByMeta returned error: %v", err)
+	}
+	if len(panes) != 1 || panes[0].Id != "%4" {
+		t.Fatalf("unexpected panes %+v", panes)
+	}
+}
+
+func TestMetaInvalidNames(t *testing.T) {
+	meta := (&Pane{Id: "%1", tmux: &Tmux{}}).Meta()
+	var invalid *InvalidOptionsError
+	if err := meta.Set("a.b", 1); !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidOptionsError, got %v", err)
+	}
+	if err := meta.Namespace("").Delete("a"); !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidOptionsError, got %v", err)
+	}
+	if _, err := (&Tmux{}).FindPanesByMetaNamespace("x y", "a", 1); !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidOptionsError, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package gotmuxcc
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -88,41 +89,72 @@ func newOption(key, value string) *Option {
 	return &Option{Key: key, Value: value}
 }
 
+// toOptions parses show-options output. Array options, listed as one
+// "name[index]" line per value, are grouped into a single option, and an
+// inherited value's name is marked with "*".
 func (o *queryOutput) toOptions() []*Option {
 	lines := o.result.Lines
 	options := make([]*Option, 0, len(lines))
+	arrays := map[string]*Option{}
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
 		if line == "" {
 			continue
 		}
-		parts := strings.SplitN(line, " ", 2)
-		if len(parts) != 2 {
+		key, value, hasValue := strings.Cut(line, " ")
+		key, inherited := strings.CutSuffix(key, "*")
+		value = unquoteOptionValue(value)
+
+		m := optionIndex.FindStringSubmatch(key)
+		if m == nil && hasValue {
+			option := newOption(key, value)
+			option.Inherited = inherited
+			options = append(options, option)
 			continue
 		}
-		options = append(options, newOption(parts[0], parts[1]))
+		// An array option with no values is listed as its bare name.
+		name := key
+		if m != nil {
+			name = m[1]
+		}
+		option, ok := arrays[name]
+		if !ok {
+			option = &Option{Key: name, Values: []string{}, Inherited: inherited}
+			arrays[name] = option
+			options = append(options, option)
+		}
+		if m == nil {
+			continue
+		}
+		idx, err := strconv.Atoi(m[2])
+		if err != nil {
+			continue
+		}
+		for len(option.Values) <= idx {
+			option.Values = append(option.Values, "")
+		}
+		option.Values[idx] = value
+		option.Value = strings.Join(option.Values, "\n")
 	}
 	return options
 }
 
-func buildCommand(parts []string) (string, error) {
-	if len(parts) == 0 {
-		return "", errEmptyCommand
-	}
-	escaped := make([]string, 0, len(parts))
-	for _, part := range parts {
-		escaped = append(escaped, quoteArgument(part))
+// unquoteOptionValue reverses the quoting show-options applies to a value:
+// double or single quotes around it, or a backslash before a lone special
+// character, and vis escapes inside.
+func unquoteOptionValue(value string) string {
+	if len(value) >= 2 {
+		first, last := value[0], value[len(value)-1]
+		if (first == '"' || first == '\'') && last == first {
+			value = value[1 : len(value)-1]
+		}
 	}
-	return strings.Join(escaped, " "), nil
+	return unvis(value)
 }
 
-func quoteArgument(arg string) string {
-	if arg == "" {
-		return "''"
-	}
-	if strings.ContainsAny(arg, " \t\n'\"\\") {
-		escaped := strings.ReplaceAll(arg, "'", "'\\''")
-		return "'" + escaped + "'"
+func buildCommand(parts []string) (string, error) {
+	if len(parts) == 0 {
+		return "", errEmptyCommand
 	}
-	return arg
+	return strings.Join(quoteArguments(parts), " "), nil
 }
@@ -1,6 +1,7 @@
 package gotmuxcc
 
 import (
+	"reflect"
 	"strings"
 	"sync"
 	"testing"
@@ -201,6 +202,49 @@ func TestOptionsRetrieval(t *testing.T) {
 	}
 }
 
+func TestOptionsParsing(t *testing.T) {
+	// As printed by show-options -A in tmux 3.3.
+	output := &queryOutput{result: commandResult{Lines: []string{
+		"@plain value",
+		`@words "two words"`,
+		`@dq 'a"b'`,
+		`@vis "say \"hi\" \$x"`,
+		`@slash a\\b`,
+		`@tab tab\there`,
+		`@tilde \~home`,
+		"@empty ''",
+		`status-format[0]* "#[align=left]"`,
+		"status-format[1]* left",
+		"status* on",
+		"update-environment[0] DISPLAY",
+		"update-environment[2] TERM",
+		"terminal-overrides",
+	}}}
+	want := []Option{
+		{Key: "@plain", Value: "value"},
+		{Key: "@words", Value: "two words"},
+		{Key: "@dq", Value: `a"b`},
+		{Key: "@vis", Value: `say "hi" $x`},
+		{Key: "@slash", Value: `a\b`},
+		{Key: "@tab", Value: "tab\there"},
+		{Key: "@tilde", Value: "~home"},
+		{Key: "@empty", Value: ""},
+		{Key: "status-format", Value: "#[align=left]\nleft", Values: []string{"#[align=left]", "left"}, Inherited: true},
+		{Key: "status", Value: "on", Inherited: true},
+		{Key: "update-environment", Value: "DISPLAY\n\nTERM", Values: []string{"DISPLAY", "", "TERM"}},
+		{Key: "terminal-overrides", Values: []string{}},
+	}
+	options := output.toOptions()
+	if len(options) != len(want) {
+		t.Fatalf("expected %d options, got %d", len(want), len(options))
+	}
+	for idx, option := range options {
+		if !reflect.DeepEqual(*option, want[idx]) {
+			t.Errorf("expected %#v, got %#v", want[idx], *option)
+		}
+	}
+}
+
 func TestCommandMultiLineOutput(t *testing.T) {
 	rt := newRecordTransport()
 	tmux := &Tmux{transport: rt}
@@ -1,6 +1,12 @@
 package gotmuxcc
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
 
 func (q *query) paneVars() *query {
 	return q.vars(
@@ -45,44 +51,111 @@ func (q *query) paneVars() *query {
 		varPaneUnseenChanges,
 		varPaneWidth,
 		varPaneWindowIndex,
+		varSessionId,
 	)
 }
 
 // CapturePane runs the tmux capture-pane command against the provided target.
+// When op.Buffer is set the capture is stored there and "" is returned.
 func (t *Tmux) CapturePane(target string, op *CaptureOptions) (string, error) {
+	if op == nil {
+		op = &CaptureOptions{}
+	}
+	if err := op.validate(); err != nil {
+		return "", err
+	}
+	start, end := op.bounds()
+	output, err := t.capturePane(target, op, start, end)
+	if err != nil {
+		return "", err
+	}
+	return output.raw(), nil
+}
+
+func (op *CaptureOptions) validate() error {
+	if op.FromHistoryStart && op.Start != nil {
+		return invalidOptions("capture-pane", "Start", "cannot be combined with FromHistoryStart")
+	}
+	if op.ToVisibleEnd && op.End != nil {
+		return invalidOptions("capture-pane", "End", "cannot be combined with ToVisibleEnd")
+	}
+	return nil
+}
+
+func (op *CaptureOptions) bounds() (start, end string) {
+	switch {
+	case op.FromHistoryStart:
+		start = "-"
+	case op.Start != nil:
+		start = strconv.Itoa(*op.Start)
+	}
+	switch {
+	case op.ToVisibleEnd:
+		end = "-"
+	case op.End != nil:
+		end = strconv.Itoa(*op.End)
+	}
+	return start, end
+}
+
+func (t *Tmux) capturePane(target string, op *CaptureOptions, start, end string) (*queryOutput, error) {
 	q := t.query().cmd("capture-pane")
 	if target != "" {
 		q.fargs("-t", target)
 	}
-	q.fargs("-p")
-
-	if op != nil {
-		if op.EscTxtNBgAttr {
-			q.fargs("-e")
-		}
-		if op.EscNonPrintables {
-			q.fargs("-C")
-		}
-		if op.IgnoreTrailing {
-			q.fargs("-T")
-		}
-		if op.PreserveTrailing {
-			q.fargs("-N")
-		}
-		if op.PreserveAndJoin {
-			q.fargs("-J")
-		}
+	if op.Buffer != "" {
+		q.fargs("-b", op.Buffer)
+	} else {
+		q.fargs("-p")
+	}
+	if op.EscTxtNBgAttr {
+		q.fargs("-e")
+	}
+	if op.EscNonPrintables {
+		q.fargs("-C")
+	}
+	if op.IgnoreTrailing {
+		q.fargs("-T")
+	}
+	if op.PreserveTrailing {
+		q.fargs("-N")
+	}
+	if op.PreserveAndJoin {
+		q.fargs("-J")
+	}
+	if op.AlternateScreen {
+		q.fargs("-a")
+	}
+	if op.PendingInput {
+		q.fargs("-P")
+	}
+	if op.Quiet {
+		q.fargs("-q")
+	}
+	if start != "" {
+		q.fargs("-S", start)
+	}
+	if end != "" {
+		q.fargs("-E", end)
 	}
 
 	output, err := q.run()
 	if err != nil {
-		return "", fmt.Errorf("failed to capture pane: %w", err)
+		return nil, fmt.Errorf("failed to capture pane: %w", err)
 	}
-
-	return output.raw(), nil
+	return output, nil
 }
 
 func (r queryResult) toPane(t *Tmux) *Pane {
+	geometry := PaneGeometry{
+		Left:   atoi(r.get(varPaneLeft)),
+		Top:    atoi(r.get(varPaneTop)),
+		Right:  atoi(r.get(varPaneRight)),
+		Bottom: atoi(r.get(varPaneBottom)),
+		Width:  atoi(r.get(varPaneWidth)),
+		Height: atoi(r.get(varPaneHeight)),
+	}
+
 	return &Pane{
 		Active:         isOne(r.get(varPaneActive)),
 		AtBottom:       isOne(r.get(varPaneAtBottom)),
@@ -125,15 +198,67 @@ func (r queryResult) toPane(t *Tmux) *Pane {
 		UnseenChanges:  isOne(r.get(varPaneUnseenChanges)),
 		Width:          atoi(r.get(varPaneWidth)),
 		WindowIndex:    atoi(r.get(varPaneWindowIndex)),
+		geometry:       geometry,
+		sessionId:      r.get(varSessionId),
+		deadTime:       parseUnix(r.get(varPaneDeadTime)),
 		tmux:           t,
 	}
 }
 
+// Geometry returns the pane's position and size within its window.
+func (p *Pane) Geometry() PaneGeometry {
+	return p.geometry
+}
+
+// DeathTime returns when the pane's process exited. It is the zero time while
+// the pane is alive.
+func (p *Pane) DeathTime() time.Time {
+	return p.deadTime
+}
+
+// Refresh reloads the pane's fields from tmux. It returns an error wrapping
+// ErrNotFound if the pane no longer exists.
+func (p *Pane) Refresh() error {
+	output, err := p.tmux.query().
+		cmd("display-message").
+		fargs("-t", p.Id).
+		paneVars().
+		run()
+	if err != nil {
+		return fmt.Errorf("failed to refresh pane: %w", lookupError("pane", p.Id, err))
+	}
+	refreshed := output.one().toPane(p.tmux)
+	if refreshed.Id != p.Id {
+		return fmt.Errorf("failed to refresh pane: %w", notFound("pane", p.Id))
+	}
+	*p = *refreshed
+	return nil
+}
+
+// Exists reports whether the pane is still present on the server.
+func (p *Pane) Exists() bool {
+	return p.tmux.exists(p.Id, varPaneId)
+}
+
+// exists reports whether target resolves to an object whose idVar matches it.
+// tmux prints an empty expansion, rather than failing, for some missing
+// targets. The ID is prefixed, as a line starting with a pane ID would read as
+// a notification, and compared here: display-message passes formats through
+// strftime, which mangles a literal pane ID.
+func (t *Tmux) exists(target, idVar string) bool {
+	output, err := t.query().
+		cmd("display-message").
+		fargs("-t", target).
+		format("exists", "=#{"+idVar+"}").
+		run()
+	return err == nil && output.one().get("exists") == "="+target
+}
+
 // ListPanes lists panes within a session.
 func (s *Session) ListPanes() ([]*Pane, error) {
 	output, err := s.tmux.query().
 		cmd("list-panes").
-		fargs("-s", "-t", s.Name).
+		fargs("-s", "-t", s.target()).
 		paneVars().
 		run()
 	if err != nil {
@@ -162,7 +287,8 @@ func (w *Window) GetPaneByIndex(idx int) (*Pane, error) {
 	return nil, nil
 }
 
-// SendKeys sends keys to the pane.
+// SendKeys sends line as a single argument, which tmux presses as a key if it
+// is a key name and types literally otherwise. Use Send for anything richer.
 func (p *Pane) SendKeys(line string) error {
 	_, err := p.tmux.query().
 		cmd("send-keys").
@@ -208,33 +334,365 @@ func (p *Pane) Select() error {
 	return p.SelectPane(nil)
 }
 
-// SplitWindow splits the pane into another pane.
-func (p *Pane) SplitWindow(op *SplitWindowOptions) error {
+// SetTitle sets the pane's title. Unlike select-pane -T, the title is taken
+// literally rather than expanded as a format.
+func (p *Pane) SetTitle(title string) (*Pane, error) {
+	return p.selectPane("set pane title", "-T", strings.ReplaceAll(title, "#", "##"))
+}
+
+// Mark makes the pane the server's marked pane, replacing any other.
+func (p *Pane) Mark() (*Pane, error) {
+	// select-pane -m clears the mark if the pane already has it.
+	if err := p.Refresh(); err != nil {
+		return nil, err
+	}
+	if p.Marked {
+		return p, nil
+	}
+	return p.selectPane("mark pane", "-m")
+}
+
+// Unmark clears the mark if this pane has it.
+func (p *Pane) Unmark() (*Pane, error) {
+	if err := p.Refresh(); err != nil {
+		return nil, err
+	}
+	if !p.Marked {
+		return p, nil
+	}
+	return p.selectPane("unmark pane", "-M")
+}
+
+// DisableInput makes the pane ignore input.
+func (p *Pane) DisableInput() (*Pane, error) {
+	return p.selectPane("disable pane input", "-d")
+}
+
+// EnableInput makes the pane accept input again.
+func (p *Pane) EnableInput() (*Pane, error) {
+	return p.selectPane("enable pane input", "-e")
+}
+
+// selectPane runs select-pane with args, which leave the active pane alone,
+// and returns the refreshed pane.
+func (p *Pane) selectPane(action string, args ...string) (*Pane, error) {
+	_, err := p.tmux.query().
+		cmd("select-pane").
+		fargs("-t", p.Id).
+		fargs(args...).
+		run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s: %w", action, lookupError("pane", p.Id, err))
+	}
+	return p.refreshed()
+}
+
+// MarkedPane returns the server's marked pane, or nil if there is none.
+func (t *Tmux) MarkedPane() (*Pane, error) {
+	output, err := t.query().
+		cmd("display-message").
+		fargs("-t", "{marked}").
+		paneVars().
+		run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get marked pane: %w", err)
+	}
+	pane := output.one().toPane(t)
+	// Without a mark tmux expands the format against nothing.
+	if pane.Id == "" || !pane.Marked {
+		return nil, nil
+	}
+	return pane, nil
+}
+
+// ClearMark clears the server's marked pane, if any.
+func (t *Tmux) ClearMark() error {
+	pane, err := t.MarkedPane()
+	if err != nil || pane == nil {
+		return err
+	}
+	_, err = pane.Unmark()
+	return err
+}
+
+// SplitWindow splits the pane and returns the new pane.
+func (p *Pane) SplitWindow(op *SplitWindowOptions) (*Pane, error) {
+	if op == nil {
+		op = &SplitWindowOptions{}
+	}
+	size, err := paneSizeArgument("split-window", op.Size, op.Percentage)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateEnvironment("split-window", op.Environment); err != nil {
+		return nil, err
+	}
+
 	q := p.tmux.query().
 		cmd("split-window").
-		fargs("-t", p.Id)
+		fargs("-P", "-t", p.Id)
+	if op.SplitDirection != "" {
+		q.fargs(string(op.SplitDirection))
+	}
+	if op.Before {
+		q.fargs("-b")
+	}
+	if op.FullSize {
+		q.fargs("-f")
+	}
+	if size != "" {
+		q.fargs("-l", size)
+	}
+	if op.DoNotSelect {
+		q.fargs("-d")
+	}
+	if op.StartDirectory != "" {
+		q.fargs("-c", op.StartDirectory)
+	}
+	for _, key := range sortedKeys(op.Environment) {
+		q.fargs("-e", key+"="+op.Environment[key])
+	}
+	q.paneVars()
+	if op.ShellCommand != "" {
+		q.pargs(op.ShellCommand)
+	}
 
-	if op != nil {
-		if op.SplitDirection != "" {
-			q.fargs(string(op.SplitDirection))
+	output, err := q.run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to split pane: %w", err)
+	}
+	pane := output.one().toPane(p.tmux)
+	if pane.Id == "" {
+		return nil, errors.New("failed to split pane: tmux did not report the new pane")
+	}
+	return pane, nil
+}
+
+// Split splits with default options.
+func (p *Pane) Split() (*Pane, error) {
+	return p.SplitWindow(nil)
+}
+
+// JoinPane moves this pane into window, splitting the window's active pane.
+func (p *Pane) JoinPane(window *Window, op *JoinPaneOptions) (*Pane, error) {
+	return p.join("join-pane", window.Id, op)
+}
+
+// MovePane moves this pane next to target, which may be in another window.
+func (p *Pane) MovePane(target *Pane, op *JoinPaneOptions) (*Pane, error) {
+	return p.join("move-pane", target.Id, op)
+}
+
+func (p *Pane) join(command, target string, op *JoinPaneOptions) (*Pane, error) {
+	if op == nil {
+		op = &JoinPaneOptions{}
+	}
+	size, err := paneSizeArgument(command, op.Size, op.Percentage)
+	if err != nil {
+		return nil, err
+	}
+
+	q := p.tmux.query().
+		cmd(command).
+		fargs("-s", p.Id, "-t", target)
+	if op.SplitDirection != "" {
+		q.fargs(string(op.SplitDirection))
+	}
+	if op.Before {
+		q.fargs("-b")
+	}
+	if op.FullSize {
+		q.fargs("-f")
+	}
+	if op.DoNotSelect {
+		q.fargs("-d")
+	}
+	if size != "" {
+		q.fargs("-l", size)
+	}
+
+	if _, err := q.run(); err != nil {
+		return nil, fmt.Errorf("failed to %s: %w", command, err)
+	}
+	return p.refreshed()
+}
+
+// BreakPane moves this pane into a window of its own and returns the window.
+func (p *Pane) BreakPane(op *BreakPaneOptions) (*Window, error) {
+	if op == nil {
+		op = &BreakPaneOptions{}
+	}
+	if op.After && op.Before {
+		return nil, invalidOptions("break-pane", "After", "cannot be combined with Before")
+	}
+	if op.Index != nil && *op.Index < 0 {
+		return nil, invalidOptions("break-pane", "Index", "must not be negative")
+	}
+
+	q := p.tmux.query().
+		cmd("break-pane").
+		fargs("-P", "-s", p.Id)
+	if op.Session != nil || op.Index != nil {
+		// Without a session, tmux resolves ":N" against the control client's
+		// current session rather than the pane's.
+		session := op.Session
+		if session == nil {
+			session = &Session{Id: p.sessionId, Name: p.SessionName}
 		}
-		if op.StartDirectory != "" {
-			q.fargs("-c", op.StartDirectory)
+		target := session.target() + ":"
+		if op.Index != nil {
+			target += strconv.Itoa(*op.Index)
 		}
-		if op.ShellCommand != "" {
-			q.pargs(fmt.Sprintf("'%s'", op.ShellCommand))
+		q.fargs("-t", target)
+	}
+	if op.WindowName != "" {
+		q.fargs("-n", op.WindowName)
+	}
+	if op.DoNotSelect {
+		q.fargs("-d")
+	}
+	if op.After {
+		q.fargs("-a")
+	}
+	if op.Before {
+		q.fargs("-b")
+	}
+	q.windowVars()
+
+	output, err := q.run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to break pane: %w", lookupError("pane", p.Id, err))
+	}
+	window := output.one().toWindow(p.tmux)
+	if window.Id == "" {
+		return nil, errors.New("failed to break pane: tmux did not report the new window")
+	}
+	return window, nil
+}
+
+// SwapPane exchanges the positions of this pane and other. Both handles are
+// refreshed and the receiver is returned.
+func (p *Pane) SwapPane(other *Pane, op *SwapPaneOptions) (*Pane, error) {
+	q := p.tmux.query().
+		cmd("swap-pane").
+		fargs("-s", p.Id, "-t", other.Id)
+	if op != nil && op.DoNotSelect {
+		q.fargs("-d")
+	}
+
+	if _, err := q.run(); err != nil {
+		return nil, fmt.Errorf("failed to swap pane: %w", err)
+	}
+	if err := other.Refresh(); err != nil {
+		return nil, err
+	}
+	return p.refreshed()
+}
+
+// ResizePane resizes the pane or toggles its zoom.
+func (p *Pane) ResizePane(op *ResizePaneOptions) (*Pane, error) {
+	if op == nil {
+		return nil, invalidOptions("resize-pane", "ResizePaneOptions", "required")
+	}
+	width, err := paneSizeArgument("resize-pane", op.Width, op.WidthPercent)
+	if err != nil {
+		return nil, err
+	}
+	height, err := paneSizeArgument("resize-pane", op.Height, op.HeightPercent)
+	if err != nil {
+		return nil, err
+	}
+	if op.Adjustment < 0 {
+		return nil, invalidOptions("resize-pane", "Adjustment", "must not be negative")
+	}
+	if op.Adjustment > 0 && op.Direction == "" {
+		return nil, invalidOptions("resize-pane", "Adjustment", "requires Direction")
+	}
+	if op.Zoom && (width != "" || height != "" || op.Direction != "") {
+		return nil, invalidOptions("resize-pane", "Zoom", "cannot be combined with a size")
+	}
+	if !op.Zoom && width == "" && height == "" && op.Direction == "" {
+		return nil, invalidOptions("resize-pane", "ResizePaneOptions", "no size given")
+	}
+
+	q := p.tmux.query().
+		cmd("resize-pane").
+		fargs("-t", p.Id)
+	if op.Zoom {
+		q.fargs("-Z")
+	}
+	if width != "" {
+		q.fargs("-x", width)
+	}
+	if height != "" {
+		q.fargs("-y", height)
+	}
+	if op.Direction != "" {
+		q.fargs(string(op.Direction))
+		if op.Adjustment > 0 {
+			q.pargs(strconv.Itoa(op.Adjustment))
 		}
 	}
 
 	if _, err := q.run(); err != nil {
-		return fmt.Errorf("failed to split pane: %w", err)
+		return nil, fmt.Errorf("failed to resize pane: %w", lookupError("pane", p.Id, err))
 	}
-	return nil
+	return p.refreshed()
 }
 
-// Split splits with default options.
-func (p *Pane) Split() error {
-	return p.SplitWindow(nil)
+// RespawnPane restarts the pane with a new command.
+func (p *Pane) RespawnPane(op *RespawnPaneOptions) (*Pane, error) {
+	if op == nil {
+		op = &RespawnPaneOptions{}
+	}
+	if err := validateEnvironment("respawn-pane", op.Environment); err != nil {
+		return nil, err
+	}
+
+	q := p.tmux.query().
+		cmd("respawn-pane").
+		fargs("-t", p.Id)
+	if op.Kill {
+		q.fargs("-k")
+	}
+	if op.StartDirectory != "" {
+		q.fargs("-c", op.StartDirectory)
+	}
+	for _, key := range sortedKeys(op.Environment) {
+		q.fargs("-e", key+"="+op.Environment[key])
+	}
+	if op.ShellCommand != "" {
+		q.pargs(op.ShellCommand)
+	}
+
+	if _, err := q.run(); err != nil {
+		return nil, fmt.Errorf("failed to respawn pane: %w", lookupError("pane", p.Id, err))
+	}
+	return p.refreshed()
+}
+
+func (p *Pane) refreshed() (*Pane, error) {
+	if err := p.Refresh(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// paneSizeArgument formats a size given in cells or as a percentage.
+func paneSizeArgument(command string, cells, percent int) (string, error) {
+	switch {
+	case cells < 0 || percent < 0:
+		return "", invalidOptions(command, "Size", "must not be negative")
+	case cells > 0 && percent > 0:
+		return "", invalidOptions(command, "Size", "cannot combine cells and a percentage")
+	case percent > 100:
+		return "", invalidOptions(command, "Percentage", "must not exceed 100")
+	case percent > 0:
+		return strconv.Itoa(percent) + "%", nil
+	case cells > 0:
+		return strconv.Itoa(cells), nil
+	}
+	return "", nil
 }
 
 // ChooseTree enters choose-tree mode for this pane.
@@ -263,6 +721,119 @@ func (p *Pane) CapturePane(op *CaptureOptions) (string, error) {
 	return p.tmux.CapturePane(p.Id, op)
 }
 
+// captureChunkLines is how many lines StreamCapture requests per command.
+const captureChunkLines = 1000
+
+// CaptureLines captures pane content as numbered lines. op.Buffer and
+// op.PreserveAndJoin are not supported, as joined lines cannot be numbered.
+func (p *Pane) CaptureLines(op *CaptureOptions) ([]CaptureLine, error) {
+	var lines []CaptureLine
+	err := p.StreamCapture(op, func(line CaptureLine) error {
+		lines = append(lines, line)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// StreamCapture captures pane content in chunks of lines, calling fn for each
+// line in order, so large histories are never held in memory at once. It
+// stops at the first error fn returns. Each chunk is positioned against the
+// pane's current history size, so output arriving during the capture does
+// not shift the requested range unless the history is at its limit.
+func (p *Pane) StreamCapture(op *CaptureOptions, fn func(CaptureLine) error) error {
+	if op == nil {
+		op = &CaptureOptions{}
+	}
+	if err := op.validate(); err != nil {
+		return err
+	}
+	if op.Buffer != "" {
+		return invalidOptions("capture-pane", "Buffer", "lines cannot be returned from a buffer capture")
+	}
+	if op.PreserveAndJoin {
+		return invalidOptions("capture-pane", "PreserveAndJoin", "joined lines cannot be numbered")
+	}
+
+	if op.PendingInput {
+		output, err := p.tmux.capturePane(p.Id, op, "", "")
+		if err != nil {
+			return err
+		}
+		for idx, text := range output.result.Lines {
+			if err := fn(CaptureLine{Number: idx, Text: text}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	history, height, err := p.captureGeometry(op)
+	if err != nil {
+		return err
+	}
+	top, bottom := captureRange(op, history, height)
+	for first := top; first <= bottom; first += captureChunkLines {
+		if first != top {
+			if history, _, err = p.captureGeometry(op); err != nil {
+				return err
+			}
+		}
+		last := min(first+captureChunkLines-1, bottom)
+		output, err := p.tmux.capturePane(p.Id, op, strconv.Itoa(first-history), strconv.Itoa(last-history))
+		if err != nil {
+			return err
+		}
+		for idx, text := range output.result.Lines {
+			if err := fn(CaptureLine{Number: first + idx, Text: text}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// captureGeometry returns the history size and height of the grid op
+// captures. The alternate screen keeps no history.
+func (p *Pane) captureGeometry(op *CaptureOptions) (history, height int, err error) {
+	output, err := p.tmux.query().
+		cmd("display-message").
+		fargs("-t", p.Id).
+		vars(varHistorySize, varPaneHeight).
+		run()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query pane history: %w", err)
+	}
+	result := output.one()
+	if op.AlternateScreen {
+		return 0, atoi(result.get(varPaneHeight)), nil
+	}
+	return atoi(result.get(varHistorySize)), atoi(result.get(varPaneHeight)), nil
+}
+
+// captureRange resolves op's bounds to absolute lines, clamping them the way
+// capture-pane does.
+func captureRange(op *CaptureOptions, history, height int) (top, bottom int) {
+	last := history + height - 1
+	clamp := func(line *int, fallback int) int {
+		if line == nil {
+			return fallback
+		}
+		return max(0, min(history+*line, last))
+	}
+	top = clamp(op.Start, history)
+	if op.FromHistoryStart {
+		top = 0
+	}
+	bottom = clamp(op.End, last)
+	if bottom < top {
+		top, bottom = bottom, top
+	}
+	return top, bottom
+}
+
 // Capture captures pane output with default escapes.
 func (p *Pane) Capture() (string, error) {
 	return p.tmux.CapturePane(p.Id, &CaptureOptions{EscTxtNBgAttr: true})
@@ -0,0 +1,297 @@
+package gotmuxcc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pipeDrainTimeout bounds how long a cancelled PipeTo waits for the relay
+// command to exit after the pipe is closed.
+const pipeDrainTimeout = time.Second
+
+// PipeTo streams the pane's output to w with pipe-pane until ctx is cancelled
+// or the pipe is closed, for example by another pipe-pane or the pane exiting.
+// tmux runs a shell command that relays the data through a FIFO in a local
+// temporary directory, so the server must run on this machine, but no %output
+// notifications are needed: PipeTo works for a client that has turned them off.
+// Cancelling ctx closes the pipe, and PipeTo returns nil once the output
+// already written has been copied.
+//
+// w may be nil if op.Input is set. Input that blocks is read until it returns,
+// as exec.Cmd does with Stdin, even after PipeTo has returned.
+func (p *Pane) PipeTo(ctx context.Context, w io.Writer, op *PipeOptions) error {
+	if op == nil {
+		op = &PipeOptions{}
+	}
+	if w == nil && op.Input == nil {
+		return invalidOptions("pipe-pane", "Input", "required when there is no writer")
+	}
+
+	pipe, err := p.startPipe(w, op)
+	if err != nil || pipe == nil {
+		return err
+	}
+	defer pipe.cleanup()
+	return pipe.wait(ctx)
+}
+
+// startPipe opens the pane's pipe and starts copying. It returns nil if
+// op.Toggle closed an existing pipe instead.
+func (p *Pane) startPipe(w io.Writer, op *PipeOptions) (*panePipe, error) {
+	dir, err := os.MkdirTemp("", "gotmuxcc-pipe-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pipe directory: %w", err)
+	}
+	pipe := &panePipe{pane: p, dir: dir}
+	fail := func(err error) (*panePipe, error) {
+		pipe.cleanup()
+		return nil, err
+	}
+
+	var outPath, inPath string
+	if w != nil {
+		if outPath, err = pipe.fifo("out"); err != nil {
+			return fail(err)
+		}
+	}
+	if op.Input != nil {
+		if inPath, err = pipe.fifo("in"); err != nil {
+			return fail(err)
+		}
+	}
+
+	q := p.tmux.query().
+		cmd("pipe-pane").
+		fargs("-t", p.Id)
+	if op.Input != nil {
+		q.fargs("-I")
+	}
+	if w != nil {
+		q.fargs("-O")
+	}
+	if op.Toggle {
+		q.fargs("-o")
+	}
+	if _, err := q.pargs(pipeCommand(outPath, inPath)).run(); err != nil {
+		return fail(fmt.Errorf("failed to pipe pane: %w", err))
+	}
+	if op.Toggle {
+		piped, err := p.piped()
+		if err != nil || !piped {
+			return fail(err) // without an error, an existing pipe was closed
+		}
+	}
+
+	if w != nil {
+		pipe.outDone = make(chan error, 1)
+		pipe.copying.Add(1)
+		go func() {
+			defer pipe.copying.Done()
+			pipe.outDone <- pipe.copyOut(w, outPath)
+		}()
+	}
+	if op.Input != nil {
+		pipe.inDone = make(chan error, 1)
+		go func() { pipe.inDone <- pipe.copyIn(inPath, op.Input) }()
+	}
+	return pipe, nil
+}
+
+// wait copies until ctx is cancelled or the pipe closes, as PipeTo does.
+func (p *panePipe) wait(ctx context.Context) error {
+	outDone, inDone := p.outDone, p.inDone
+	for {
+		select {
+		case err := <-outDone:
+			if err != nil {
+				p.pane.stopPipe()
+			}
+			return err
+		case err := <-inDone:
+			// With -O as well, the pipe outlives the input.
+			inDone = nil
+			if err != nil {
+				p.pane.stopPipe()
+				return err
+			}
+			if outDone == nil {
+				return nil
+			}
+		case <-ctx.Done():
+			// tmux can't close a dead pane's pipe, but no more output will
+			// come through it.
+			if err := p.pane.stopPipe(); err != nil && !p.pane.exited() {
+				return err
+			}
+			if outDone == nil {
+				return nil
+			}
+			select {
+			case err := <-outDone:
+				return err
+			case <-time.After(pipeDrainTimeout):
+				return nil // cleanup abandons the copy
+			}
+		}
+	}
+}
+
+// piped reports whether the pane has a pipe open.
+func (p *Pane) piped() (bool, error) {
+	output, err := p.tmux.query().
+		cmd("display-message").
+		fargs("-t", p.Id).
+		vars(varPanePipe).
+		run()
+	if err != nil {
+		return false, fmt.Errorf("failed to query pane %s: %w", p.Id, lookupError("pane", p.Id, err))
+	}
+	return isOne(output.one().get(varPanePipe)), nil
+}
+
+// exited reports whether the pane's process has exited or the pane is gone.
+func (p *Pane) exited() bool {
+	output, err := p.tmux.query().
+		cmd("display-message").
+		fargs("-t", p.Id).
+		vars(varPaneId, varPaneDead).
+		run()
+	if err != nil {
+		return errors.Is(lookupError("pane", p.Id, err), ErrNotFound)
+	}
+	r := output.one()
+	return r.get(varPaneId) != p.Id || isOne(r.get(varPaneDead))
+}
+
+// stopPipe closes the pane's pipe, whichever command it runs.
+func (p *Pane) stopPipe() error {
+	_, err := p.tmux.query().
+		cmd("pipe-pane").
+		fargs("-t", p.Id).
+		run()
+	if err != nil {
+		return fmt.Errorf("failed to close pipe: %w", err)
+	}
+	return nil
+}
+
+// pipeCommand returns the shell command relaying a pipe through the FIFOs
+// at out and in, either of which may be empty. With both, the pane output
+// arrives on the same socket the command writes input to, so it is read
+// from a duplicate descriptor: a background job's stdin is /dev/null.
+func pipeCommand(out, in string) string {
+	switch {
+	case in == "":
+		return "exec cat > " + shellQuote(out)
+	case out == "":
+		return "exec cat " + shellQuote(in)
+	default:
+		return "exec 3<&0; cat <&3 > " + shellQuote(out) + " & exec cat " + shellQuote(in) + " 3<&-"
+	}
+}
+
+// shellQuote quotes s as a single POSIX shell word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// panePipe holds the local end of a pipe-pane relay.
+type panePipe struct {
+	pane    *Pane
+	dir     string
+	fifos   []string
+	copying sync.WaitGroup
+
+	outDone, inDone chan error
+
+	mu     sync.Mutex
+	files  []*os.File
+	closed bool
+}
+
+func (p *panePipe) fifo(name string) (string, error) {
+	path := filepath.Join(p.dir, name)
+	if err := mkfifo(path); err != nil {
+		return "", fmt.Errorf("failed to create pipe FIFO: %w", err)
+	}
+	p.fifos = append(p.fifos, path)
+	return path, nil
+}
+
+// open opens our end of a FIFO, waiting for the shell command to open the
+// other. The file is closed by cleanup.
+func (p *panePipe) open(path string, flag int) (*os.File, error) {
+	f, err := os.OpenFile(path, flag, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pipe FIFO: %w", err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		f.Close()
+		return nil, os.ErrClosed
+	}
+	p.files = append(p.files, f)
+	return f, nil
+}
+
+func (p *panePipe) copyOut(w io.Writer, path string) error {
+	f, err := p.open(path, os.O_RDONLY)
+	if err != nil {
+		return nil // the pipe was abandoned before it started
+	}
+	if _, err := io.Copy(w, f); err != nil && !p.isClosed() {
+		return fmt.Errorf("failed to copy pane output: %w", err)
+	}
+	return nil
+}
+
+func (p *panePipe) copyIn(path string, r io.Reader) error {
+	f, err := p.open(path, os.O_WRONLY)
+	if err != nil {
+		return nil
+	}
+	_, err = io.Copy(f, r)
+	f.Close()
+	if err != nil && !p.isClosed() {
+		return fmt.Errorf("failed to copy pane input: %w", err)
+	}
+	return nil
+}
+
+func (p *panePipe) isClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+// cleanup releases the FIFOs. Opening each read-write first gives any open
+// still waiting for the other end, ours or the shell command's, a partner,
+// and removing them before closing those descriptors means a command that
+// starts late finds nothing to wait on.
+func (p *panePipe) cleanup() {
+	guards := make([]*os.File, 0, len(p.fifos))
+	for _, path := range p.fifos {
+		if f, err := openFIFONonblock(path, os.O_RDWR); err == nil {
+			guards = append(guards, f)
+		}
+	}
+	p.mu.Lock()
+	p.closed = true
+	for _, f := range p.files {
+		f.Close()
+	}
+	p.mu.Unlock()
+	os.RemoveAll(p.dir)
+	for _, f := range guards {
+		f.Close()
+	}
+	p.copying.Wait()
+}
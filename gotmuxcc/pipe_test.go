@@ -0,0 +1,57 @@
+package gotmuxcc
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPipeCommand(t *testing.T) {
+	cases := []struct{ out, in, want string }{
+		{"/tmp/a b/out", "", `exec cat > '/tmp/a b/out'`},
+		{"", "/tmp/it's/in", `exec cat '/tmp/it'\''s/in'`},
+		{"/o", "/i", `exec 3<&0; cat <&3 > '/o' & exec cat '/i' 3<&-`},
+	}
+	for _, tc := range cases {
+		if got := pipeCommand(tc.out, tc.in); got != tc.want {
+			t.Errorf("expected %q, got %q", tc.want, got)
+		}
+	}
+}
+
+func TestPipeToggleClosesExistingPipe(t *testing.T) {
+	responses := []scriptedResponse{
+		{match: "pipe-pane -t %1 -O -o -- 'exec cat > ", lines: []string{"%begin 1 1 0", "%end 1 1 0"}},
+		{match: "display-message -t %1 -p '#{pane_pipe}'", lines: []string{"%begin 1 1 0", "0", "%end 1 1 0"}},
+	}
+	tr := newScriptedTransport(responses)
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	var out strings.Builder
+	if err := (&Pane{Id: "%1", tmux: tmux}).PipeTo(context.Background(), &out, &PipeOptions{Toggle: true}); err != nil {
+		t.Fatalf("Pipe returned error: %v", err)
+	}
+	tr.mu.Lock()
+	command := tr.sent[0]
+	tr.mu.Unlock()
+	start := strings.Index(command, os.TempDir())
+	end := strings.Index(command, "/out")
+	if start < 0 || end < start {
+		t.Fatalf("expected a FIFO under %s in %q", os.TempDir(), command)
+	}
+	dir := command[start:end]
+	if _, err := os.Stat(dir); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected the FIFO directory to be removed, got %v", err)
+	}
+}
+
+func TestPipeRequiresWriterOrInput(t *testing.T) {
+	var invalid *InvalidOptionsError
+	if err := (&Pane{Id: "%1", tmux: &Tmux{}}).PipeTo(context.Background(), nil, nil); !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidOptionsError, got %v", err)
+	}
+}
@@ -8,12 +8,16 @@ import (
 
 const querySeparator = "-:-"
 
+// printFormatKey collects the expansion of a caller-supplied -F format.
+const printFormatKey = "print_format"
+
 type query struct {
 	tmux      *Tmux
 	command   []string
 	flagArgs  []string
 	posArgs   []string
 	variables []string
+	formats   map[string]string
 }
 
 func newQuery(t *Tmux) *query {
@@ -45,6 +49,18 @@ func (q *query) vars(v ...string) *query {
 	return q
 }
 
+// format appends a caller-supplied format string to the query's variables,
+// collected under key. It should be added after the fixed variables: the last
+// field absorbs any separators the expansion contains.
+func (q *query) format(key, format string) *query {
+	if q.formats == nil {
+		q.formats = make(map[string]string)
+	}
+	q.formats[key] = format
+	q.variables = append(q.variables, key)
+	return q
+}
+
 func (q *query) build() (string, error) {
 	if q.tmux == nil {
 		return "", errors.New("gotmuxcc: query has no tmux instance")
@@ -54,16 +70,19 @@ func (q *query) build() (string, error) {
 	}
 
 	parts := make([]string, 0, len(q.command)+len(q.flagArgs)+len(q.posArgs)+4)
-	parts = append(parts, q.command...)
-	parts = append(parts, q.flagArgs...)
+	parts = append(parts, quoteArguments(q.command)...)
+	parts = append(parts, quoteArguments(q.flagArgs)...)
 
 	if len(q.variables) > 0 {
 		formats := make([]string, len(q.variables))
 		for idx, variable := range q.variables {
+			if raw, ok := q.formats[variable]; ok {
+				formats[idx] = raw
+				continue
+			}
 			formats[idx] = fmt.Sprintf("#{%s}", variable)
 		}
-		format := strings.Join(formats, querySeparator)
-		format = fmt.Sprintf("'%s'", format)
+		format := quoteArgument(strings.Join(formats, querySeparator))
 		if q.command[0] == "display-message" {
 			parts = append(parts, "-p", format)
 		} else {
@@ -71,7 +90,12 @@ func (q *query) build() (string, error) {
 		}
 	}
 
-	parts = append(parts, q.posArgs...)
+	// tmux parses flags after the lexer strips quotes, so only "--" stops a
+	// value that starts with '-' being read as one.
+	if len(q.posArgs) > 0 {
+		parts = append(parts, "--")
+		parts = append(parts, quoteArguments(q.posArgs)...)
+	}
 
 	return strings.Join(parts, " "), nil
 }
@@ -17,7 +17,7 @@ func TestQueryBuildWithVariables(t *testing.T) {
 		t.Fatalf("build returned error: %v", err)
 	}
 
-	expected := "list-panes -a -F '#{pane_id}-:-#{pane_index}' %0"
+	expected := "list-panes -a -F '#{pane_id}-:-#{pane_index}' -- %0"
 	if built != expected {
 		t.Fatalf("expected %q, got %q", expected, built)
 	}
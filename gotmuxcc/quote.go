@@ -0,0 +1,105 @@
+package gotmuxcc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// quoteArgument encodes arg as a single tmux command-parser word.
+//
+// Arguments made up solely of unambiguous characters are passed through
+// untouched so built commands stay readable in traces. Everything else is
+// wrapped in single quotes, which disables tmux's handling of whitespace, ';',
+// '#', '{', '}', '~' and '$'. Characters single quotes cannot carry are spliced
+// in between quoted runs: a quote becomes \' and control characters (which
+// would otherwise terminate the control-mode command line) become double-quoted
+// escapes such as "\n". tmux concatenates adjacent segments into one argument.
+func quoteArgument(arg string) string {
+	if arg == "" {
+		return "''"
+	}
+	if !needsQuoting(arg) {
+		return arg
+	}
+
+	var b strings.Builder
+	b.Grow(len(arg) + 2)
+	open := false
+	for i := 0; i < len(arg); i++ {
+		c := arg[i]
+		switch {
+		case c == '\'':
+			if open {
+				b.WriteByte('\'')
+				open = false
+			}
+			b.WriteString(`\'`)
+		case isControlByte(c):
+			if open {
+				b.WriteByte('\'')
+				open = false
+			}
+			b.WriteByte('"')
+			b.WriteString(escapeControlByte(c))
+			b.WriteByte('"')
+		default:
+			if !open {
+				b.WriteByte('\'')
+				open = true
+			}
+			b.WriteByte(c)
+		}
+	}
+	if open {
+		b.WriteByte('\'')
+	}
+	return b.String()
+}
+
+// quoteArguments applies quoteArgument to every element of args.
+func quoteArguments(args []string) []string {
+	quoted := make([]string, len(args))
+	for idx, arg := range args {
+		quoted[idx] = quoteArgument(arg)
+	}
+	return quoted
+}
+
+func needsQuoting(arg string) bool {
+	// A word starting with '%' is read as a directive such as %if, unless
+	// the rest is digits, as in a pane ID.
+	if arg[0] == '%' && strings.TrimLeft(arg[1:], "0123456789") != "" {
+		return true
+	}
+	for i := 0; i < len(arg); i++ {
+		if !isPlainByte(arg[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPlainByte reports whether c has no special meaning inside a tmux word.
+func isPlainByte(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	}
+	return strings.IndexByte("-_./:,%@=+", c) >= 0
+}
+
+func isControlByte(c byte) bool {
+	return c < 0x20 || c == 0x7f
+}
+
+func escapeControlByte(c byte) string {
+	switch c {
+	case '\n':
+		return `\n`
+	case '\r':
+		return `\r`
+	case '\t':
+		return `\t`
+	}
+	return fmt.Sprintf(`\%03o`, c)
+}
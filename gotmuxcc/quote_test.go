@@ -0,0 +1,224 @@
+package gotmuxcc
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestQuoteArgument(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"", "''"},
+		{"plain", "plain"},
+		{"%1", "%1"},
+		{"%", "%"},
+		{"a%b", "a%b"},
+		{"%foo", "'%foo'"},
+		{"%1a", "'%1a'"},
+		{"%if", "'%if'"},
+		{"%%", "'%%'"},
+		{"@2", "@2"},
+		{"-t", "-t"},
+		{"/tmp/dir.d", "/tmp/dir.d"},
+		{"$1", "'$1'"},
+		{"hello world", "'hello world'"},
+		{"it's", `'it'\''s'`},
+		{"'", `\'`},
+		{"a;b", "'a;b'"},
+		{"#{pane_id}", "'#{pane_id}'"},
+		{"~/src", "'~/src'"},
+		{"line1\nline2", `'line1'"\n"'line2'`},
+		{"\x1b[0m", `"\033"'[0m'`},
+		{"tab\t", `'tab'"\t"`},
+	}
+
+	for _, tc := range cases {
+		if got := quoteArgument(tc.in); got != tc.want {
+			t.Errorf("quoteArgument(%q) = %s, want %s", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestQueryBuildQuotesArguments(t *testing.T) {
+	q := newQuery(&Tmux{})
+	q.cmd("rename-session").
+		fargs("-t", "$1").
+		pargs("evil'; kill-server")
+
+	built, err := q.build()
+	if err != nil {
+		t.Fatalf("build returned error: %v", err)
+	}
+
+	expected := `rename-session -t '$1' -- 'evil'\''; kill-server'`
+	if built != expected {
+		t.Fatalf("expected %s, got %s", expected, built)
+	}
+
+	words, err := splitTmuxWords(built)
+	if err != nil {
+		t.Fatalf("failed to split built command: %v", err)
+	}
+	if !reflect.DeepEqual(words, []string{"rename-session", "-t", "$1", "--", "evil'; kill-server"}) {
+		t.Fatalf("unexpected words: %#v", words)
+	}
+}
+
+func TestQueryBuildEndsFlags(t *testing.T) {
+	cases := []struct {
+		build func(q *query) *query
+		want  string
+	}{
+		{func(q *query) *query { return q.cmd("rename-session").fargs("-t", "$1").pargs("-dev") }, `rename-session -t '$1' -- -dev`},
+		{func(q *query) *query { return q.cmd("set-buffer").fargs("-b", "x").pargs("--") }, "set-buffer -b x -- --"},
+		{func(q *query) *query { return q.cmd("send-keys").fargs("-t", "%1", "-l").pargs("-x", "--version") }, "send-keys -t %1 -l -- -x --version"},
+		// Without positional arguments there is nothing to protect.
+		{func(q *query) *query { return q.cmd("list-sessions").fargs("-f", "-x") }, "list-sessions -f -x"},
+	}
+	for _, tc := range cases {
+		built, err := tc.build(newQuery(&Tmux{})).build()
+		if err != nil {
+			t.Fatalf("build returned error: %v", err)
+		}
+		if built != tc.want {
+			t.Errorf("expected %s, got %s", tc.want, built)
+		}
+	}
+}
+
+func FuzzQuoteArgumentRoundTrip(f *testing.F) {
+	seeds := []string{
+		"", "plain", "two words", "it's", "a;b", ";", "#comment", "{}", "~user",
+		"$HOME", "\\", "\"", "line\nbreak", "\r\t\x00\x7f", "é日本", "'\"'\"",
+		"%1", "%foo", "%1a", "%if", "%", "a%b",
+	}
+	for _, seed := range seeds {
+		f.Add(seed, "second")
+	}
+
+	f.Fuzz(func(t *testing.T, a, b string) {
+		line, err := buildCommand([]string{"set-option", a, b})
+		if err != nil {
+			t.Fatalf("buildCommand returned error: %v", err)
+		}
+		if strings.ContainsAny(line, "\n\r") {
+			t.Fatalf("encoded command contains a line break: %q", line)
+		}
+
+		words, err := splitTmuxWords(line)
+		if err != nil {
+			t.Fatalf("failed to split %q: %v", line, err)
+		}
+		want := []string{"set-option", a, b}
+		if !reflect.DeepEqual(words, want) {
+			t.Fatalf("round trip mismatch for %q: got %#v want %#v", line, words, want)
+		}
+	})
+}
+
+// splitTmuxWords is a reduced model of the tmux command lexer. It understands
+// the constructs quoteArgument emits and rejects anything that tmux would treat
+// specially outside of quotes.
+func splitTmuxWords(line string) ([]string, error) {
+	words := make([]string, 0)
+	var (
+		current strings.Builder
+		inWord  bool
+	)
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == ' ':
+			if inWord {
+				words = append(words, current.String())
+				current.Reset()
+				inWord = false
+			}
+		case c == '\'':
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote at %d", i)
+			}
+			current.WriteString(line[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '"':
+			i++
+			for ; i < len(line) && line[i] != '"'; i++ {
+				if line[i] != '\\' {
+					if strings.IndexByte("$~", line[i]) >= 0 {
+						return nil, fmt.Errorf("unescaped %q in double quotes", line[i])
+					}
+					current.WriteByte(line[i])
+					continue
+				}
+				n, consumed, err := unescapeTmux(line[i+1:])
+				if err != nil {
+					return nil, err
+				}
+				current.WriteByte(n)
+				i += consumed
+			}
+			if i >= len(line) {
+				return nil, fmt.Errorf("unterminated double quote")
+			}
+			inWord = true
+		case c == '\\':
+			if i+1 >= len(line) {
+				return nil, fmt.Errorf("trailing backslash")
+			}
+			i++
+			current.WriteByte(line[i])
+			inWord = true
+		case strings.IndexByte(";#{}~$\t\n\r", c) >= 0:
+			return nil, fmt.Errorf("unquoted special character %q at %d", c, i)
+		case c == '%' && !inWord:
+			// A word may start with '%' only as a pane ID.
+			end := i + 1
+			for end < len(line) && line[end] >= '0' && line[end] <= '9' {
+				end++
+			}
+			if end < len(line) && line[end] != ' ' {
+				return nil, fmt.Errorf("unquoted directive at %d", i)
+			}
+			current.WriteString(line[i:end])
+			i = end - 1
+			inWord = true
+		default:
+			current.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, current.String())
+	}
+	return words, nil
+}
+
+func unescapeTmux(s string) (byte, int, error) {
+	if s == "" {
+		return 0, 0, fmt.Errorf("dangling escape")
+	}
+	switch s[0] {
+	case 'n':
+		return '\n', 1, nil
+	case 'r':
+		return '\r', 1, nil
+	case 't':
+		return '\t', 1, nil
+	case '\\', '"', '$', '~':
+		return s[0], 1, nil
+	}
+	if len(s) >= 3 {
+		if n, err := strconv.ParseUint(s[:3], 8, 8); err == nil {
+			return byte(n), 3, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("unsupported escape %q", s)
+}
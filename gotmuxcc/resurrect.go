@@ -0,0 +1,152 @@
+package gotmuxcc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tmux-resurrect save files are tab-separated, one record per line:
+//
+//	pane	<session>	<window index>	<window active>	:<window flags>	<pane index>	<pane title>	:<path>	<pane active>	<command>	:<full command>
+//	window	<session>	<window index>	:<window name>	<window active>	:<window flags>	<layout>	<automatic-rename>
+//	state	<client session>	<client last session>
+//
+// Spaces in paths are escaped with a backslash.
+
+// ExportResurrect writes the snapshot in tmux-resurrect's save file format.
+func (s *Snapshot) ExportResurrect(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, session := range s.Sessions {
+		for _, window := range session.Windows {
+			flags := ""
+			if window.Active {
+				flags = "*"
+			}
+			for _, pane := range window.Panes {
+				fmt.Fprintf(bw, "pane\t%s\t%d\t%s\t:%s\t%d\t%s\t:%s\t%s\t%s\t:%s\n",
+					session.Name, window.Index, resurrectBool(window.Active), flags,
+					pane.Index, pane.Title, strings.ReplaceAll(pane.Path, " ", `\ `),
+					resurrectBool(pane.Active), pane.CurrentCommand, pane.StartCommand)
+			}
+		}
+	}
+	for _, session := range s.Sessions {
+		for _, window := range session.Windows {
+			flags := ""
+			if window.Active {
+				flags = "*"
+			}
+			autoRename := window.Options["automatic-rename"]
+			if autoRename == "" {
+				autoRename = ":"
+			}
+			fmt.Fprintf(bw, "window\t%s\t%d\t:%s\t%s\t:%s\t%s\t%s\n",
+				session.Name, window.Index, window.Name, resurrectBool(window.Active),
+				flags, window.Layout, autoRename)
+		}
+	}
+	if len(s.Sessions) > 0 {
+		fmt.Fprintf(bw, "state\t%s\t\n", s.Sessions[0].Name)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to export resurrect file: %w", err)
+	}
+	return nil
+}
+
+// ImportResurrect reads a tmux-resurrect save file into a snapshot. The full
+// command recorded for each pane becomes its StartCommand.
+func ImportResurrect(r io.Reader) (*Snapshot, error) {
+	sessions := make(map[string]*SessionSnapshot)
+	windows := make(map[string]*WindowSnapshot)
+	sessionWindows := make(map[string][]*WindowSnapshot)
+	order := make([]string, 0)
+
+	session := func(name string) *SessionSnapshot {
+		ss, ok := sessions[name]
+		if !ok {
+			ss = &SessionSnapshot{Name: name}
+			sessions[name] = ss
+			order = append(order, name)
+		}
+		return ss
+	}
+	window := func(sessionName string, index int) *WindowSnapshot {
+		key := sessionName + ":" + strconv.Itoa(index)
+		ws, ok := windows[key]
+		if !ok {
+			ws = &WindowSnapshot{Index: index}
+			windows[key] = ws
+			session(sessionName)
+			sessionWindows[sessionName] = append(sessionWindows[sessionName], ws)
+		}
+		return ws
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		fields := strings.Split(scanner.Text(), "\t")
+		switch fields[0] {
+		case "pane":
+			if len(fields) < 11 {
+				return nil, fmt.Errorf("resurrect line %d: expected 11 pane fields, got %d", lineNo, len(fields))
+			}
+			ws := window(fields[1], atoi(fields[2]))
+			ws.Active = isOne(fields[3])
+			ws.Panes = append(ws.Panes, PaneSnapshot{
+				Index:          atoi(fields[5]),
+				Title:          fields[6],
+				Path:           strings.ReplaceAll(strings.TrimPrefix(fields[7], ":"), `\ `, " "),
+				Active:         isOne(fields[8]),
+				CurrentCommand: fields[9],
+				StartCommand:   strings.TrimPrefix(fields[10], ":"),
+			})
+		case "window":
+			if len(fields) < 7 {
+				return nil, fmt.Errorf("resurrect line %d: expected 7 window fields, got %d", lineNo, len(fields))
+			}
+			ws := window(fields[1], atoi(fields[2]))
+			ws.Name = strings.TrimPrefix(fields[3], ":")
+			ws.Active = isOne(fields[4])
+			ws.Layout = fields[6]
+			if len(fields) > 7 && (fields[7] == "on" || fields[7] == "off") {
+				ws.Options = map[string]string{"automatic-rename": fields[7]}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read resurrect file: %w", err)
+	}
+
+	snapshot := &Snapshot{
+		Version:     SnapshotVersion,
+		CreatedTime: time.Now().UTC().Truncate(time.Second),
+		Sessions:    make([]SessionSnapshot, 0, len(order)),
+	}
+	for _, name := range order {
+		ss := sessions[name]
+		list := sessionWindows[name]
+		sort.Slice(list, func(i, j int) bool { return list[i].Index < list[j].Index })
+		for _, ws := range list {
+			sort.Slice(ws.Panes, func(i, j int) bool { return ws.Panes[i].Index < ws.Panes[j].Index })
+			ss.Windows = append(ss.Windows, *ws)
+		}
+		snapshot.Sessions = append(snapshot.Sessions, *ss)
+	}
+	return snapshot, nil
+}
+
+func resurrectBool(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
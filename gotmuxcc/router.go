@@ -26,6 +26,8 @@ type Event struct {
 	Fields []string // whitespace-separated fields following the event name
 	Data   string   // raw tail of the line (fields joined with spaces)
 	Raw    string   // full raw line including leading '%'
+
+	seq uint64 // position in the router's event stream
 }
 
 type commandResponse struct {
@@ -72,6 +74,10 @@ type commandResult struct {
 	Number  string
 	Flags   string
 	Lines   []string
+
+	// eventSeq is the sequence number of the last event emitted before the
+	// command completed, ordering the result against notifications.
+	eventSeq uint64
 }
 
 type commandError struct {
@@ -97,6 +103,10 @@ type router struct {
 	eventsOnce   sync.Once
 	closed       chan struct{}
 	eventsClosed bool
+
+	eventSeq     uint64
+	listeners    map[int]func(Event)
+	nextListener int
 }
 
 func newRouter(t controlTransport) *router {
@@ -162,6 +172,13 @@ func (r *router) handleLine(line string) {
 		return
 	}
 
+	if current := r.currentBlock(); current != nil && !closesBlock(line, current) {
+		// tmux never interleaves notifications with command output, so every
+		// line inside a block belongs to the command, even one starting with %.
+		r.appendOutput(line)
+		return
+	}
+
 	switch {
 	case strings.HasPrefix(line, "%begin"):
 		r.handleBegin(line)
@@ -176,6 +193,31 @@ func (r *router) handleLine(line string) {
 	}
 }
 
+// currentBlock returns the command whose output is being read, or nil
+// outside a %begin/%end block.
+func (r *router) currentBlock() *commandState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.stack) == 0 {
+		return nil
+	}
+	return r.inflight[r.stack[len(r.stack)-1]]
+}
+
+// closesBlock reports whether line is the %end or %error guard for state.
+// tmux repeats the time, number and flags of %begin, which keeps captured
+// text that happens to look like a guard from ending the block early.
+func closesBlock(line string, state *commandState) bool {
+	for _, prefix := range []string{"%end", "%error"} {
+		if !strings.HasPrefix(line, prefix+" ") {
+			continue
+		}
+		timeStr, number, flags, _, err := parseFrame(line, prefix)
+		return err == nil && timeStr == state.time && number == state.number && flags == state.flags
+	}
+	return false
+}
+
 func (r *router) handleBegin(line string) {
 	timeStr, number, flags, _, err := parseFrame(line, "%begin")
 	if err != nil {
@@ -281,6 +323,7 @@ func (r *router) finishCommand(number, timeStr, flags string, cmdErr error, deta
 	}
 	pendingCount := len(r.pending)
 	inflightCount := len(r.inflight)
+	eventSeq := r.eventSeq
 	r.mu.Unlock()
 
 	if state == nil {
@@ -299,6 +342,8 @@ func (r *router) finishCommand(number, timeStr, flags string, cmdErr error, deta
 		Number:  number,
 		Flags:   flags,
 		Lines:   append([]string(nil), state.output...),
+
+		eventSeq: eventSeq,
 	}
 
 	commandDisplay := trace.FormatControlCommand(state.request.command)
@@ -343,12 +388,40 @@ func (r *router) removeFromStack(number string) {
 
 func (r *router) emitEvent(evt Event) {
 	r.mu.Lock()
+	r.eventSeq++
+	evt.seq = r.eventSeq
 	sent, ok := r.enqueueEvent(evt)
+	listeners := make([]func(Event), 0, len(r.listeners))
+	for _, fn := range r.listeners {
+		listeners = append(listeners, fn)
+	}
 	r.mu.Unlock()
 	if !ok {
 		return
 	}
 	r.logEvent(evt, sent)
+	for _, fn := range listeners {
+		fn(evt)
+	}
+}
+
+// listen calls fn for every notification, in order, on the goroutine reading
+// from tmux. fn must not block or run commands. The returned function
+// removes the listener.
+func (r *router) listen(fn func(Event)) func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.listeners == nil {
+		r.listeners = make(map[int]func(Event))
+	}
+	id := r.nextListener
+	r.nextListener++
+	r.listeners[id] = fn
+	return func() {
+		r.mu.Lock()
+		delete(r.listeners, id)
+		r.mu.Unlock()
+	}
 }
 
 func (r *router) emitEventLocked(evt Event) {
@@ -133,6 +133,33 @@ func TestRouterEmitsEvents(t *testing.T) {
 	}
 }
 
+func TestRouterKeepsPercentLinesInsideBlock(t *testing.T) {
+	ft := newFakeTransport()
+	r := newRouter(ft)
+	defer r.close()
+
+	go func() {
+		<-ft.sendC
+		ft.lines <- "%begin 1 4 1"
+		ft.lines <- "%output %1 not an event"
+		ft.lines <- "%end 2 4 1"
+		ft.lines <- "%end 1 4 1"
+	}()
+
+	result, err := r.runCommand("capture-pane -p")
+	if err != nil {
+		t.Fatalf("runCommand returned error: %v", err)
+	}
+	if len(result.Lines) != 2 || result.Lines[0] != "%output %1 not an event" || result.Lines[1] != "%end 2 4 1" {
+		t.Fatalf("unexpected lines: %#v", result.Lines)
+	}
+	select {
+	case evt := <-r.eventsChannel():
+		t.Fatalf("unexpected event: %#v", evt)
+	default:
+	}
+}
+
 type errorTransport struct {
 	err error
 }
@@ -0,0 +1,153 @@
+package gotmuxcc
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// untypedOptions lists options whose defaults look like a flag or a number
+// but which take other values too, such as "status 2" or
+// "main-pane-width 50%".
+var untypedOptions = map[string]bool{
+	"allow-passthrough":  true,
+	"destroy-unattached": true,
+	"detach-on-destroy":  true,
+	"extended-keys":      true,
+	"main-pane-height":   true,
+	"main-pane-width":    true,
+	"other-pane-height":  true,
+	"other-pane-width":   true,
+	"pane-border-status": true,
+	"remain-on-exit":     true,
+	"status":             true,
+	"visual-activity":    true,
+	"visual-bell":        true,
+	"visual-silence":     true,
+}
+
+// optionIndex matches an array option's index, as in "status-format[1]".
+var optionIndex = regexp.MustCompile(`^(.+)\[(\d+)\]$`)
+
+// OptionSchema builds a schema from the server, global session and global
+// window options the server lists. The names and levels are exact, and so
+// are array types, which show-options reveals with indices. tmux reports no
+// other types, so they are inferred: styles and colours from their names, and
+// flags and numbers from their current global values, less a list of known
+// exceptions. An option that takes other values too, such as one added by a
+// newer tmux, may be inferred wrongly, so Validate treats inferred types as
+// advisory and checks only the name and level.
+func (t *Tmux) OptionSchema() (*OptionSchema, error) {
+	schema := &OptionSchema{Options: map[string]OptionSpec{}}
+	levels := []struct {
+		level ScopeLevel
+		args  []string
+	}{
+		{ScopeServer, []string{"-s"}},
+		{ScopeSession, []string{"-g"}},
+		{ScopeWindow, []string{"-w", "-g"}},
+	}
+	for _, level := range levels {
+		output, err := t.query().
+			cmd("show-options").
+			fargs(level.args...).
+			run()
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve options: %w", err)
+		}
+		for _, line := range output.result.Lines {
+			key, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+			if key == "" || strings.HasPrefix(key, "@") {
+				continue
+			}
+			spec := OptionSpec{Name: key, Level: level.level, Type: inferOptionType(key, value), Inferred: true}
+			if m := optionIndex.FindStringSubmatch(key); m != nil {
+				spec.Name, spec.Type, spec.Inferred = m[1], OptionTypeArray, false
+			}
+			schema.Options[spec.Name] = spec
+		}
+	}
+	return schema, nil
+}
+
+// inferOptionType guesses an option's type from its name and value as
+// show-options prints them. An array option with no values is printed as
+// its bare name.
+func inferOptionType(key, value string) OptionType {
+	switch {
+	case value == "":
+		return OptionTypeArray
+	case strings.HasSuffix(key, "-style"):
+		return OptionTypeStyle
+	case strings.HasSuffix(key, "-colour"), strings.HasSuffix(key, "-bg"), strings.HasSuffix(key, "-fg"):
+		return OptionTypeColour
+	case untypedOptions[key]:
+		return OptionTypeString
+	case value == "on" || value == "off":
+		return OptionTypeFlag
+	}
+	if _, err := strconv.Atoi(value); err == nil {
+		return OptionTypeNumber
+	}
+	return OptionTypeString
+}
+
+// Validate checks that key is an option at scope's level and that value
+// suits its type, unless the type is inferred. User options, which start
+// with "@", are always valid.
+func (s *OptionSchema) Validate(scope Scope, key, value string) error {
+	if err := s.validateKey(scope, key); err != nil {
+		return err
+	}
+	spec, ok := s.Options[key]
+	if !ok || spec.Inferred {
+		return nil
+	}
+	switch spec.Type {
+	case OptionTypeFlag:
+		switch value {
+		case "on", "off", "yes", "no", "1", "0":
+		default:
+			return invalidOptions("set-option", key, fmt.Sprintf("%q is not a flag", value))
+		}
+	case OptionTypeNumber:
+		if _, err := strconv.Atoi(value); err != nil {
+			return invalidOptions("set-option", key, fmt.Sprintf("%q is not a number", value))
+		}
+	}
+	return nil
+}
+
+// validateKey checks that key is an option at scope's level.
+func (s *OptionSchema) validateKey(scope Scope, key string) error {
+	if strings.HasPrefix(key, "@") {
+		return nil
+	}
+	name := key
+	if m := optionIndex.FindStringSubmatch(key); m != nil {
+		name = m[1]
+	}
+	spec, ok := s.Options[name]
+	if !ok {
+		return invalidOptions("set-option", key, "unknown option")
+	}
+	if name != key && spec.Type != OptionTypeArray {
+		return invalidOptions("set-option", key, "not an array option")
+	}
+	if level := optionLevel(scope.Level); level != spec.Level {
+		return invalidOptions("set-option", key, fmt.Sprintf("is a %s option, not a %s one", spec.Level, level))
+	}
+	return nil
+}
+
+// optionLevel returns the level of the options a scope holds.
+func optionLevel(level ScopeLevel) ScopeLevel {
+	switch level {
+	case ScopeGlobalSession:
+		return ScopeSession
+	case ScopeGlobalWindow, ScopePane:
+		return ScopeWindow
+	}
+	return level
+}
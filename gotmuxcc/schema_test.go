@@ -0,0 +1,106 @@
+package gotmuxcc
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOptionSchema(t *testing.T) {
+	tr := newScriptedTransport([]scriptedResponse{
+		{match: "show-options -s", lines: []string{
+			"%begin 1 1 0", "escape-time 500", "terminal-overrides", "terminal-features[0] xterm*:clipboard", "%end 1 1 0",
+		}},
+		{match: "show-options -g", lines: []string{
+			"%begin 1 1 0", "mouse off", "status on", "status-style bg=green,fg=black", "status-bg default", "@user 1", "%end 1 1 0",
+		}},
+		{match: "show-options -w -g", lines: []string{
+			"%begin 1 1 0", "remain-on-exit off", "main-pane-width 80", "synchronize-panes off", "%end 1 1 0",
+		}},
+	})
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	schema, err := tmux.OptionSchema()
+	if err != nil {
+		t.Fatalf("OptionSchema returned error: %v", err)
+	}
+	want := map[string]OptionSpec{
+		"escape-time":        {Name: "escape-time", Level: ScopeServer, Type: OptionTypeNumber, Inferred: true},
+		"terminal-overrides": {Name: "terminal-overrides", Level: ScopeServer, Type: OptionTypeArray, Inferred: true},
+		"terminal-features":  {Name: "terminal-features", Level: ScopeServer, Type: OptionTypeArray},
+		"mouse":              {Name: "mouse", Level: ScopeSession, Type: OptionTypeFlag, Inferred: true},
+		"status":             {Name: "status", Level: ScopeSession, Type: OptionTypeString, Inferred: true},
+		"status-style":       {Name: "status-style", Level: ScopeSession, Type: OptionTypeStyle, Inferred: true},
+		"status-bg":          {Name: "status-bg", Level: ScopeSession, Type: OptionTypeColour, Inferred: true},
+		"remain-on-exit":     {Name: "remain-on-exit", Level: ScopeWindow, Type: OptionTypeString, Inferred: true},
+		"main-pane-width":    {Name: "main-pane-width", Level: ScopeWindow, Type: OptionTypeString, Inferred: true},
+		"synchronize-panes":  {Name: "synchronize-panes", Level: ScopeWindow, Type: OptionTypeFlag, Inferred: true},
+	}
+	if len(schema.Options) != len(want) {
+		t.Fatalf("expected %d options, got %v", len(want), schema.Options)
+	}
+	for name, spec := range want {
+		if schema.Options[name] != spec {
+			t.Errorf("expected %+v, got %+v", spec, schema.Options[name])
+		}
+	}
+}
+
+func TestOptionSchemaValidate(t *testing.T) {
+	schema := &OptionSchema{Options: map[string]OptionSpec{
+		"escape-time":       {Name: "escape-time", Level: ScopeServer, Type: OptionTypeNumber},
+		"terminal-features": {Name: "terminal-features", Level: ScopeServer, Type: OptionTypeArray},
+		"mouse":             {Name: "mouse", Level: ScopeSession, Type: OptionTypeFlag},
+		"synchronize-panes": {Name: "synchronize-panes", Level: ScopeWindow, Type: OptionTypeFlag},
+		"visual-bell":       {Name: "visual-bell", Level: ScopeSession, Type: OptionTypeFlag, Inferred: true},
+	}}
+	valid := []struct {
+		scope      Scope
+		key, value string
+	}{
+		{ServerScope(), "escape-time", "10"},
+		{ServerScope(), "terminal-features[2]", "foot*:RGB"},
+		{SessionScope("x"), "mouse", "on"},
+		{PaneScope("%1"), "synchronize-panes", "off"},
+		{GlobalWindowScope(), "@anything", "goes"},
+		// Inferred types are advisory: visual-bell also takes "both".
+		{GlobalSessionScope(), "visual-bell", "both"},
+	}
+	for _, tc := range valid {
+		if err := schema.Validate(tc.scope, tc.key, tc.value); err != nil {
+			t.Errorf("Validate(%+v, %s, %s) returned error: %v", tc.scope, tc.key, tc.value, err)
+		}
+	}
+	invalid := []struct {
+		scope      Scope
+		key, value string
+	}{
+		{ServerScope(), "escape-time", "soon"},
+		{GlobalSessionScope(), "mouse", "maybe"},
+		{GlobalSessionScope(), "escape-time", "10"},
+		{ServerScope(), "escape-time[0]", "10"},
+		{ServerScope(), "no-such-option", "1"},
+		{ServerScope(), "visual-bell", "both"},
+	}
+	var invalidErr *InvalidOptionsError
+	for _, tc := range invalid {
+		if err := schema.Validate(tc.scope, tc.key, tc.value); !errors.As(err, &invalidErr) {
+			t.Errorf("Validate(%+v, %s, %s): expected InvalidOptionsError, got %v", tc.scope, tc.key, tc.value, err)
+		}
+	}
+}
+
+func TestScopedOptionsSchema(t *testing.T) {
+	schema := &OptionSchema{Options: map[string]OptionSpec{
+		"mouse": {Name: "mouse", Level: ScopeSession, Type: OptionTypeFlag},
+	}}
+	options := (&Tmux{}).OptionsAt(WindowScope("@1"), schema)
+	var invalid *InvalidOptionsError
+	if err := options.Set("mouse", "on", nil); !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidOptionsError, got %v", err)
+	}
+	if err := options.Unset("mouse"); !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidOptionsError, got %v", err)
+	}
+}
@@ -0,0 +1,272 @@
+package gotmuxcc
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ServerScope returns the scope of server options.
+func ServerScope() Scope { return Scope{Level: ScopeServer} }
+
+// GlobalSessionScope returns the scope of global session options.
+func GlobalSessionScope() Scope { return Scope{Level: ScopeGlobalSession} }
+
+// SessionScope returns the scope of the target session's options.
+func SessionScope(target string) Scope { return Scope{Level: ScopeSession, Target: target} }
+
+// GlobalWindowScope returns the scope of global window options.
+func GlobalWindowScope() Scope { return Scope{Level: ScopeGlobalWindow} }
+
+// WindowScope returns the scope of the target window's options.
+func WindowScope(target string) Scope { return Scope{Level: ScopeWindow, Target: target} }
+
+// PaneScope returns the scope of the target pane's options.
+func PaneScope(target string) Scope { return Scope{Level: ScopePane, Target: target} }
+
+// scopeArgs returns the set-option and show-options flags for scope.
+func scopeArgs(command string, scope Scope) ([]string, error) {
+	var args []string
+	needsTarget := false
+	switch scope.Level {
+	case ScopeServer:
+		args = []string{"-s"}
+	case ScopeGlobalSession:
+		args = []string{"-g"}
+	case ScopeSession:
+		needsTarget = true
+	case ScopeGlobalWindow:
+		args = []string{"-w", "-g"}
+	case ScopeWindow:
+		args = []string{"-w"}
+		needsTarget = true
+	case ScopePane:
+		args = []string{"-p"}
+		needsTarget = true
+	default:
+		return nil, invalidOptions(command, "Scope", fmt.Sprintf("unknown level %q", scope.Level))
+	}
+	switch {
+	case needsTarget && scope.Target == "":
+		return nil, invalidOptions(command, "Scope", fmt.Sprintf("%s level requires a target", scope.Level))
+	case !needsTarget && scope.Target != "":
+		return nil, invalidOptions(command, "Scope", fmt.Sprintf("%s level takes no target", scope.Level))
+	case needsTarget:
+		args = append(args, "-t", scope.Target)
+	}
+	return args, nil
+}
+
+// ScopedOptions reads and writes the options in one scope.
+type ScopedOptions struct {
+	tmux   *Tmux
+	scope  Scope
+	schema *OptionSchema
+}
+
+// OptionsAt returns the options in scope. If schema is not nil, Set and
+// Unset check keys and values against it before sending them.
+func (t *Tmux) OptionsAt(scope Scope, schema *OptionSchema) *ScopedOptions {
+	return &ScopedOptions{tmux: t, scope: scope, schema: schema}
+}
+
+// Set sets an option.
+func (o *ScopedOptions) Set(key, value string, op *SetOptionOptions) error {
+	if op == nil {
+		op = &SetOptionOptions{}
+	}
+	args, err := scopeArgs("set-option", o.scope)
+	if err != nil {
+		return err
+	}
+	if o.schema != nil {
+		if err := o.schema.Validate(o.scope, key, value); err != nil {
+			return err
+		}
+	}
+
+	q := o.tmux.query().
+		cmd("set-option").
+		fargs(args...)
+	if op.Append {
+		q.fargs("-a")
+	}
+	if op.OnlyIfUnset {
+		q.fargs("-o")
+	}
+	if _, err := q.pargs(key, value).run(); err != nil {
+		return fmt.Errorf("failed to set option %s: %w", key, err)
+	}
+	return nil
+}
+
+// Unset unsets an option, so a scope below the global ones inherits it
+// again and a global option returns to its default.
+func (o *ScopedOptions) Unset(key string) error {
+	args, err := scopeArgs("set-option", o.scope)
+	if err != nil {
+		return err
+	}
+	if o.schema != nil {
+		if err := o.schema.validateKey(o.scope, key); err != nil {
+			return err
+		}
+	}
+	_, err = o.tmux.query().
+		cmd("set-option").
+		fargs(args...).
+		fargs("-u").
+		pargs(key).
+		run()
+	if err != nil {
+		return fmt.Errorf("failed to unset option %s: %w", key, err)
+	}
+	return nil
+}
+
+// Get returns an option's value. The values of an array option are joined
+// with newlines. It returns an error wrapping ErrNotFound if the option is
+// unknown or has no value in the scope: show-options prints nothing for a
+// built-in option that is unset there, or for an empty array, but an empty
+// line for an option set to "".
+func (o *ScopedOptions) Get(key string, op *ShowOptionsOptions) (*Option, error) {
+	lines, err := o.show(key, op)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("failed to retrieve option %s: %w", key, notFound("option", key))
+	}
+	return newOption(key, strings.Join(lines, "\n")), nil
+}
+
+// List returns the options set in the scope.
+func (o *ScopedOptions) List(op *ShowOptionsOptions) ([]*Option, error) {
+	if op == nil {
+		op = &ShowOptionsOptions{}
+	}
+	args, err := scopeArgs("show-options", o.scope)
+	if err != nil {
+		return nil, err
+	}
+	q := o.tmux.query().
+		cmd("show-options").
+		fargs(args...)
+	if op.Inherited {
+		q.fargs("-A")
+	}
+	output, err := q.run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve options: %w", err)
+	}
+	return output.toOptions(), nil
+}
+
+// show returns the lines show-options -v prints for key.
+func (o *ScopedOptions) show(key string, op *ShowOptionsOptions) ([]string, error) {
+	if op == nil {
+		op = &ShowOptionsOptions{}
+	}
+	args, err := scopeArgs("show-options", o.scope)
+	if err != nil {
+		return nil, err
+	}
+	q := o.tmux.query().
+		cmd("show-options").
+		fargs(args...)
+	if op.Inherited {
+		q.fargs("-A")
+	}
+	output, err := q.fargs("-v").pargs(key).run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve option %s: %w", key, optionError(key, err))
+	}
+	return output.result.Lines, nil
+}
+
+// effective returns an option's value as the scope sees it, including an
+// inherited one.
+func (o *ScopedOptions) effective(key string) (string, error) {
+	lines, err := o.show(key, &ShowOptionsOptions{Inherited: true})
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// Bool returns a flag option, as the scope sees it.
+func (o *ScopedOptions) Bool(key string) (bool, error) {
+	value, err := o.effective(key)
+	if err != nil {
+		return false, err
+	}
+	switch value {
+	case "on", "yes", "1":
+		return true, nil
+	case "off", "no", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to parse option %s: %q is not a flag", key, value)
+}
+
+// Int returns a number option, as the scope sees it.
+func (o *ScopedOptions) Int(key string) (int, error) {
+	value, err := o.effective(key)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse option %s: %w", key, err)
+	}
+	return n, nil
+}
+
+// Colour returns a colour option, such as "red", "colour12" or "#ff0000",
+// as the scope sees it.
+func (o *ScopedOptions) Colour(key string) (string, error) {
+	return o.effective(key)
+}
+
+// Style returns a style option, as the scope sees it.
+func (o *ScopedOptions) Style(key string) (*OptionStyle, error) {
+	value, err := o.effective(key)
+	if err != nil {
+		return nil, err
+	}
+	return parseStyle(value), nil
+}
+
+// Strings returns the values of an array option, as the scope sees it.
+func (o *ScopedOptions) Strings(key string) ([]string, error) {
+	return o.show(key, &ShowOptionsOptions{Inherited: true})
+}
+
+// parseStyle splits a style into its entries, which tmux separates with
+// commas or spaces.
+func parseStyle(value string) *OptionStyle {
+	style := &OptionStyle{Attributes: []string{}}
+	entries := strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' })
+	for _, entry := range entries {
+		switch {
+		case strings.HasPrefix(entry, "fg="):
+			style.Foreground = entry[len("fg="):]
+		case strings.HasPrefix(entry, "bg="):
+			style.Background = entry[len("bg="):]
+		default:
+			style.Attributes = append(style.Attributes, entry)
+		}
+	}
+	return style
+}
+
+// optionError converts tmux's error for an unknown or unset option into
+// ErrNotFound.
+func optionError(key string, err error) error {
+	var cmdErr *commandError
+	if errors.As(err, &cmdErr) && strings.Contains(strings.Join(cmdErr.Result.Lines, "\n"), "invalid option") {
+		return notFound("option", key)
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+package gotmuxcc
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScopeArgs(t *testing.T) {
+	cases := []struct {
+		scope Scope
+		want  string
+	}{
+		{ServerScope(), "-s"},
+		{GlobalSessionScope(), "-g"},
+		{SessionScope("$1"), "-t $1"},
+		{GlobalWindowScope(), "-w -g"},
+		{WindowScope("@2"), "-w -t @2"},
+		{PaneScope("%3"), "-p -t %3"},
+	}
+	for _, tc := range cases {
+		args, err := scopeArgs("set-option", tc.scope)
+		if err != nil {
+			t.Fatalf("scopeArgs(%+v) returned error: %v", tc.scope, err)
+		}
+		if got := strings.Join(args, " "); got != tc.want {
+			t.Errorf("scopeArgs(%+v): expected %q, got %q", tc.scope, tc.want, got)
+		}
+	}
+
+	var invalid *InvalidOptionsError
+	for _, scope := range []Scope{{}, {Level: ScopePane}, {Level: ScopeServer, Target: "x"}} {
+		if _, err := scopeArgs("set-option", scope); !errors.As(err, &invalid) {
+			t.Errorf("scopeArgs(%+v): expected InvalidOptionsError, got %v", scope, err)
+		}
+	}
+}
+
+func TestScopedOptions(t *testing.T) {
+	ok := []string{"%begin 1 1 0", "%end 1 1 0"}
+	tr := newScriptedTransport([]scriptedResponse{
+		{match: "set-option -w -t @2 -a -o -- main-pane-width 50%", lines: ok},
+		{match: "set-option -p -t %3 -u -- remain-on-exit", lines: ok},
+		{match: "show-options -t x -A -v -- status", lines: []string{"%begin 1 1 0", "on", "%end 1 1 0"}},
+		{match: "show-options -t x -A -v -- history-limit", lines: []string{"%begin 1 1 0", "2000", "%end 1 1 0"}},
+		{match: "show-options -t x -A -v -- status-style", lines: []string{"%begin 1 1 0", "bg=green fg=black,bold", "%end 1 1 0"}},
+		{match: "show-options -t x -A -v -- update-environment", lines: []string{"%begin 1 1 0", "DISPLAY", "SSH_AUTH_SOCK", "%end 1 1 0"}},
+		{match: "show-options -t x -v -- @missing", lines: []string{"%begin 1 1 0", "invalid option: @missing", "%error 1 1 0"}},
+		// A built-in option unset in the scope prints nothing; one set to ""
+		// prints an empty line.
+		{match: "show-options -p -t %3 -v -- remain-on-exit", lines: ok},
+		{match: "show-options -t x -v -- @empty", lines: []string{"%begin 1 1 0", "", "%end 1 1 0"}},
+	})
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	if err := tmux.OptionsAt(WindowScope("@2"), nil).Set("main-pane-width", "50%", &SetOptionOptions{Append: true, OnlyIfUnset: true}); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	if err := tmux.OptionsAt(PaneScope("%3"), nil).Unset("remain-on-exit"); err != nil {
+		t.Fatalf("Unset returned error: %v", err)
+	}
+	session := tmux.OptionsAt(SessionScope("x"), nil)
+	if on, err := session.Bool("status"); err != nil || !on {
+		t.Fatalf("expected status on, got %v %v", on, err)
+	}
+	if n, err := session.Int("history-limit"); err != nil || n != 2000 {
+		t.Fatalf("expected 2000, got %d %v", n, err)
+	}
+	style, err := session.Style("status-style")
+	if err != nil {
+		t.Fatalf("Style returned error: %v", err)
+	}
+	if want := (&OptionStyle{Foreground: "black", Background: "green", Attributes: []string{"bold"}}); !reflect.DeepEqual(style, want) {
+		t.Fatalf("expected %+v, got %+v", want, style)
+	}
+	values, err := session.Strings("update-environment")
+	if err != nil || !reflect.DeepEqual(values, []string{"DISPLAY", "SSH_AUTH_SOCK"}) {
+		t.Fatalf("unexpected values %v %v", values, err)
+	}
+	if _, err := session.Get("@missing", nil); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := tmux.OptionsAt(PaneScope("%3"), nil).Get("remain-on-exit", nil); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for an unset built-in option, got %v", err)
+	}
+	if empty, err := session.Get("@empty", nil); err != nil || empty.Value != "" {
+		t.Fatalf("expected an empty value, got %+v, %v", empty, err)
+	}
+}
@@ -0,0 +1,369 @@
+package gotmuxcc
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ColorType says how a Color is specified.
+type ColorType int
+
+const (
+	// ColorDefault is the terminal's default colour.
+	ColorDefault ColorType = iota
+	// ColorNamed is one of the 16 ANSI colours; 8-15 are the bright variants.
+	ColorNamed
+	// Color256 is an entry in the 256-colour palette.
+	Color256
+	// ColorRGB is a 24-bit colour.
+	ColorRGB
+)
+
+// Color is a cell foreground, background or underline colour.
+type Color struct {
+	Type    ColorType
+	Index   uint8 // palette index for ColorNamed and Color256
+	R, G, B uint8 // components for ColorRGB
+}
+
+// Attributes is a set of text attributes, combined with |.
+type Attributes uint16
+
+const (
+	AttrBold Attributes = 1 << iota
+	AttrDim
+	AttrItalic
+	AttrBlink
+	AttrReverse
+	AttrHidden
+	AttrStrikethrough
+	AttrOverline
+)
+
+// UnderlineStyle is the kind of underline drawn under a cell.
+type UnderlineStyle int
+
+const (
+	UnderlineNone UnderlineStyle = iota
+	UnderlineSingle
+	UnderlineDouble
+	UnderlineCurly
+	UnderlineDotted
+	UnderlineDashed
+)
+
+// Style is the rendition of a cell.
+type Style struct {
+	Fg             Color
+	Bg             Color
+	UnderlineColor Color
+	Attributes     Attributes
+	Underline      UnderlineStyle
+	// Hyperlink is the OSC 8 URI the cell links to.
+	Hyperlink string
+}
+
+// Cell is one character on a captured screen.
+type Cell struct {
+	Rune rune
+	// Combining holds zero-width characters, such as accents, drawn with Rune.
+	Combining []rune
+	// Width is the number of columns the cell occupies: 2 for wide characters.
+	Width int
+	Style Style
+}
+
+// String returns the cell's characters.
+func (c Cell) String() string {
+	return string(c.Rune) + string(c.Combining)
+}
+
+// Screen is a grid of styled cells parsed from capture-pane -e output.
+type Screen struct {
+	Rows [][]Cell
+}
+
+// ParseScreen parses captured pane text containing SGR and OSC 8 escape
+// sequences, one row per line as returned by Pane.Capture. Other escape
+// sequences and control characters are ignored. Style carries over from one
+// row to the next, as it would on a terminal.
+func ParseScreen(capture string) *Screen {
+	lines := strings.Split(capture, "\n")
+	screen := &Screen{Rows: make([][]Cell, 0, len(lines))}
+	var style Style
+	for _, line := range lines {
+		screen.Rows = append(screen.Rows, parseScreenRow(line, &style))
+	}
+	return screen
+}
+
+// Cell returns the cell covering column x of row y, or nil when there is
+// none.
+func (s *Screen) Cell(x, y int) *Cell {
+	if y < 0 || y >= len(s.Rows) || x < 0 {
+		return nil
+	}
+	column := 0
+	for idx := range s.Rows[y] {
+		cell := &s.Rows[y][idx]
+		if x < column+cell.Width {
+			return cell
+		}
+		column += cell.Width
+	}
+	return nil
+}
+
+// String returns the screen's text without styling.
+func (s *Screen) String() string {
+	var b strings.Builder
+	for idx, row := range s.Rows {
+		if idx > 0 {
+			b.WriteByte('\n')
+		}
+		for _, cell := range row {
+			b.WriteString(cell.String())
+		}
+	}
+	return b.String()
+}
+
+func parseScreenRow(line string, style *Style) []Cell {
+	row := make([]Cell, 0, len(line))
+	for pos := 0; pos < len(line); {
+		if line[pos] == '\x1b' {
+			pos += parseEscape(line[pos:], style)
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(line[pos:])
+		pos += size
+		switch width := runeWidth(r); {
+		case r < 0x20 || r == 0x7f:
+		case width == 0 && len(row) > 0:
+			last := &row[len(row)-1]
+			last.Combining = append(last.Combining, r)
+		default:
+			row = append(row, Cell{Rune: r, Width: max(width, 1), Style: *style})
+		}
+	}
+	return row
+}
+
+// parseEscape applies the escape sequence at the start of text to style and
+// returns its length.
+func parseEscape(text string, style *Style) int {
+	if len(text) < 2 {
+		return len(text)
+	}
+	switch text[1] {
+	case '[':
+		for end := 2; end < len(text); end++ {
+			if c := text[end]; c >= 0x40 && c <= 0x7e {
+				if c == 'm' {
+					applySGR(text[2:end], style)
+				}
+				return end + 1
+			}
+		}
+		return len(text)
+	case ']':
+		for end := 2; end < len(text); end++ {
+			switch {
+			case text[end] == '\a':
+				applyOSC(text[2:end], style)
+				return end + 1
+			case text[end] == '\x1b' && end+1 < len(text) && text[end+1] == '\\':
+				applyOSC(text[2:end], style)
+				return end + 2
+			}
+		}
+		return len(text)
+	default:
+		return 2
+	}
+}
+
+func applyOSC(payload string, style *Style) {
+	// OSC 8 ; params ; URI, with an empty URI closing the link.
+	fields := strings.SplitN(payload, ";", 3)
+	if len(fields) == 3 && fields[0] == "8" {
+		style.Hyperlink = fields[2]
+	}
+}
+
+func applySGR(params string, style *Style) {
+	if params == "" {
+		resetSGR(style)
+		return
+	}
+	codes := strings.Split(params, ";")
+	for idx := 0; idx < len(codes); idx++ {
+		sub := strings.Split(codes[idx], ":")
+		code, _ := strconv.Atoi(sub[0])
+		switch {
+		case code == 0:
+			resetSGR(style)
+		case code == 1:
+			style.Attributes |= AttrBold
+		case code == 2:
+			style.Attributes |= AttrDim
+		case code == 3:
+			style.Attributes |= AttrItalic
+		case code == 4:
+			style.Underline = UnderlineSingle
+			if len(sub) > 1 {
+				if n, err := strconv.Atoi(sub[1]); err == nil && n <= int(UnderlineDashed) {
+					style.Underline = UnderlineStyle(n)
+				}
+			}
+		case code == 5 || code == 6:
+			style.Attributes |= AttrBlink
+		case code == 7:
+			style.Attributes |= AttrReverse
+		case code == 8:
+			style.Attributes |= AttrHidden
+		case code == 9:
+			style.Attributes |= AttrStrikethrough
+		case code == 21:
+			style.Underline = UnderlineDouble
+		case code == 22:
+			style.Attributes &^= AttrBold | AttrDim
+		case code == 23:
+			style.Attributes &^= AttrItalic
+		case code == 24:
+			style.Underline = UnderlineNone
+		case code == 25:
+			style.Attributes &^= AttrBlink
+		case code == 27:
+			style.Attributes &^= AttrReverse
+		case code == 28:
+			style.Attributes &^= AttrHidden
+		case code == 29:
+			style.Attributes &^= AttrStrikethrough
+		case code >= 30 && code <= 37:
+			style.Fg = Color{Type: ColorNamed, Index: uint8(code - 30)}
+		case code == 38:
+			style.Fg, idx = extendedColor(sub, codes, idx)
+		case code == 39:
+			style.Fg = Color{}
+		case code >= 40 && code <= 47:
+			style.Bg = Color{Type: ColorNamed, Index: uint8(code - 40)}
+		case code == 48:
+			style.Bg, idx = extendedColor(sub, codes, idx)
+		case code == 49:
+			style.Bg = Color{}
+		case code == 53:
+			style.Attributes |= AttrOverline
+		case code == 55:
+			style.Attributes &^= AttrOverline
+		case code == 58:
+			style.UnderlineColor, idx = extendedColor(sub, codes, idx)
+		case code == 59:
+			style.UnderlineColor = Color{}
+		case code >= 90 && code <= 97:
+			style.Fg = Color{Type: ColorNamed, Index: uint8(code - 90 + 8)}
+		case code >= 100 && code <= 107:
+			style.Bg = Color{Type: ColorNamed, Index: uint8(code - 100 + 8)}
+		}
+	}
+}
+
+// extendedColor parses the colour following a 38, 48 or 58 code, given either
+// as colon sub-parameters (38:5:n, 38:2::r:g:b) or as the following
+// semicolon parameters (38;5;n, 38;2;r;g;b). It returns the index of the last
+// parameter consumed.
+func extendedColor(sub, codes []string, idx int) (Color, int) {
+	args := sub[1:]
+	consumed := idx
+	if len(sub) == 1 {
+		args = codes[idx+1:]
+	}
+	number := func(pos int) (uint8, bool) {
+		if pos >= len(args) {
+			return 0, false
+		}
+		n, err := strconv.Atoi(args[pos])
+		return uint8(n), err == nil && n >= 0 && n <= 255
+	}
+	if len(args) == 0 {
+		return Color{}, idx
+	}
+	switch args[0] {
+	case "5":
+		n, ok := number(1)
+		if len(sub) == 1 {
+			consumed = min(idx+2, len(codes)-1)
+		}
+		if !ok {
+			return Color{}, consumed
+		}
+		return Color{Type: Color256, Index: n}, consumed
+	case "2":
+		first := 1
+		if len(sub) > 1 && len(args) >= 5 {
+			// The colon form may carry a colour space identifier first.
+			first = 2
+		}
+		if len(sub) == 1 {
+			consumed = min(idx+4, len(codes)-1)
+		}
+		r, okR := number(first)
+		g, okG := number(first + 1)
+		b, okB := number(first + 2)
+		if !okR || !okG || !okB {
+			return Color{}, consumed
+		}
+		return Color{Type: ColorRGB, R: r, G: g, B: b}, consumed
+	}
+	return Color{}, idx
+}
+
+func resetSGR(style *Style) {
+	// Hyperlinks are not part of SGR and survive a reset.
+	*style = Style{Hyperlink: style.Hyperlink}
+}
+
+// runeWidth returns the number of columns r occupies: 0 for combining and
+// format characters, 2 for East Asian wide characters and emoji, otherwise 1.
+func runeWidth(r rune) int {
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	idx := sort.Search(len(wideRunes), func(i int) bool { return wideRunes[i][1] >= r })
+	if idx < len(wideRunes) && wideRunes[idx][0] <= r {
+		return 2
+	}
+	return 1
+}
+
+// wideRunes are the East Asian wide and fullwidth ranges, sorted.
+var wideRunes = [][2]rune{
+	{0x1100, 0x115f}, {0x231a, 0x231b}, {0x2329, 0x232a}, {0x23e9, 0x23ec},
+	{0x23f0, 0x23f0}, {0x23f3, 0x23f3}, {0x25fd, 0x25fe}, {0x2614, 0x2615},
+	{0x2648, 0x2653}, {0x267f, 0x267f}, {0x2693, 0x2693}, {0x26a1, 0x26a1},
+	{0x26aa, 0x26ab}, {0x26bd, 0x26be}, {0x26c4, 0x26c5}, {0x26ce, 0x26ce},
+	{0x26d4, 0x26d4}, {0x26ea, 0x26ea}, {0x26f2, 0x26f3}, {0x26f5, 0x26f5},
+	{0x26fa, 0x26fa}, {0x26fd, 0x26fd}, {0x2705, 0x2705}, {0x270a, 0x270b},
+	{0x2728, 0x2728}, {0x274c, 0x274c}, {0x274e, 0x274e}, {0x2753, 0x2755},
+	{0x2757, 0x2757}, {0x2795, 0x2797}, {0x27b0, 0x27b0}, {0x27bf, 0x27bf},
+	{0x2b1b, 0x2b1c}, {0x2b50, 0x2b50}, {0x2b55, 0x2b55}, {0x2e80, 0x303e},
+	{0x3041, 0x33ff}, {0x3400, 0x4dbf}, {0x4e00, 0x9fff}, {0xa000, 0xa4cf},
+	{0xa960, 0xa97f}, {0xac00, 0xd7a3}, {0xf900, 0xfaff}, {0xfe10, 0xfe19},
+	{0xfe30, 0xfe6f}, {0xff00, 0xff60}, {0xffe0, 0xffe6}, {0x16fe0, 0x16fe4},
+	{0x17000, 0x18cff}, {0x1b000, 0x1b2ff}, {0x1f004, 0x1f004}, {0x1f0cf, 0x1f0cf},
+	{0x1f18e, 0x1f18e}, {0x1f191, 0x1f19a}, {0x1f200, 0x1f251}, {0x1f300, 0x1f64f},
+	{0x1f680, 0x1f6ff}, {0x1f7e0, 0x1f7eb}, {0x1f90c, 0x1f9ff}, {0x1fa70, 0x1faff},
+	{0x20000, 0x2fffd}, {0x30000, 0x3fffd},
+}
+
+// CaptureScreen captures the visible pane with its styling and parses it.
+func (p *Pane) CaptureScreen() (*Screen, error) {
+	output, err := p.tmux.CapturePane(p.Id, &CaptureOptions{EscTxtNBgAttr: true})
+	if err != nil {
+		return nil, err
+	}
+	return ParseScreen(output), nil
+}
@@ -0,0 +1,70 @@
+package gotmuxcc
+
+import "testing"
+
+func TestParseScreenStyles(t *testing.T) {
+	// Captured from tmux 3.3 with capture-pane -p -e.
+	capture := "\x1b[1m\x1b[31mred\x1b[0m\x1b[39m\x1b[49m \x1b[38;5;200mx\x1b[48;2;1;2;3my\x1b[39m\x1b[49m " +
+		"\x1b[4:3mcurly\x1b[0m\x1b[39m\x1b[49m\x1b[58;5;9mU\x1b[0m\x1b[39m\x1b[49m 日本e\u0301 \x1b[7mrev\x1b[0m\n" +
+		"\x1b]8;;http://example.com\x1b\\link\x1b]8;;\x1b\\ \x1b[38:2::10:20:30;97;104mz"
+
+	screen := ParseScreen(capture)
+	if len(screen.Rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(screen.Rows))
+	}
+	if got := screen.String(); got != "red xy curlyU 日本e\u0301 rev\nlink z" {
+		t.Fatalf("unexpected text %q", got)
+	}
+
+	cases := []struct {
+		x, y  int
+		text  string
+		style Style
+		width int
+	}{
+		{0, 0, "r", Style{Fg: Color{Type: ColorNamed, Index: 1}, Attributes: AttrBold}, 1},
+		{3, 0, " ", Style{}, 1},
+		{4, 0, "x", Style{Fg: Color{Type: Color256, Index: 200}}, 1},
+		{5, 0, "y", Style{Fg: Color{Type: Color256, Index: 200}, Bg: Color{Type: ColorRGB, R: 1, G: 2, B: 3}}, 1},
+		{7, 0, "c", Style{Underline: UnderlineCurly}, 1},
+		{12, 0, "U", Style{UnderlineColor: Color{Type: Color256, Index: 9}}, 1},
+		{14, 0, "日", Style{}, 2},
+		{15, 0, "日", Style{}, 2},
+		{16, 0, "本", Style{}, 2},
+		{18, 0, "e\u0301", Style{}, 1},
+		{20, 0, "r", Style{Attributes: AttrReverse}, 1},
+		{0, 1, "l", Style{Hyperlink: "http://example.com"}, 1},
+		{4, 1, " ", Style{}, 1},
+		{5, 1, "z", Style{Fg: Color{Type: ColorNamed, Index: 15}, Bg: Color{Type: ColorNamed, Index: 12}}, 1},
+	}
+	for _, tc := range cases {
+		cell := screen.Cell(tc.x, tc.y)
+		if cell == nil {
+			t.Fatalf("no cell at %d,%d", tc.x, tc.y)
+		}
+		if cell.String() != tc.text || cell.Width != tc.width {
+			t.Errorf("cell %d,%d: expected %q width %d, got %q width %d", tc.x, tc.y, tc.text, tc.width, cell.String(), cell.Width)
+		}
+		if cell.Style != tc.style {
+			t.Errorf("cell %d,%d: expected style %+v, got %+v", tc.x, tc.y, tc.style, cell.Style)
+		}
+	}
+	if screen.Cell(40, 0) != nil || screen.Cell(0, 5) != nil {
+		t.Fatalf("expected no cell outside the screen")
+	}
+}
+
+func TestParseScreenAttributeResets(t *testing.T) {
+	screen := ParseScreen("\x1b[1;2;3;9;53ma\x1b[22;23;29;55mb\x1b[4;21mc\x1b[24md")
+	want := []Style{
+		{Attributes: AttrBold | AttrDim | AttrItalic | AttrStrikethrough | AttrOverline},
+		{},
+		{Underline: UnderlineDouble},
+		{},
+	}
+	for idx, style := range want {
+		if got := screen.Rows[0][idx].Style; got != style {
+			t.Errorf("cell %d: expected %+v, got %+v", idx, style, got)
+		}
+	}
+}
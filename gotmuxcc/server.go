@@ -2,6 +2,7 @@ package gotmuxcc
 
 import (
 	"strconv"
+	"time"
 )
 
 func (q *query) serverVars() *query {
@@ -31,10 +32,16 @@ func (q queryResult) toServer(t *Tmux) *Server {
 		Uid:       uid,
 		User:      user,
 		Version:   version,
+		startTime: parseUnix(startTime),
 		tmux:      t,
 	}
 }
 
+// StartedTime returns when the tmux server started.
+func (s *Server) StartedTime() time.Time {
+	return s.startTime
+}
+
 // GetServerInformation retrieves tmux server details.
 func (t *Tmux) GetServerInformation() (*Server, error) {
 	output, err := t.query().
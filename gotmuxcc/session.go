@@ -1,8 +1,8 @@
 package gotmuxcc
 
 import (
-	"errors"
 	"fmt"
+	"time"
 )
 
 func (q *query) sessionVars() *query {
@@ -54,11 +54,30 @@ func (r queryResult) toSession(t *Tmux) *Session {
 		Path:              r.get(varSessionPath),
 		Stack:             r.get(varSessionStack),
 		Windows:           atoi(r.get(varSessionWindows)),
+		activity:          parseUnix(r.get(varSessionActivity)),
+		created:           parseUnix(r.get(varSessionCreated)),
+		lastAttached:      parseUnix(r.get(varSessionLastAttached)),
 		tmux:              t,
 	}
 	return session
 }
 
+// CreatedTime returns when the session was created.
+func (s *Session) CreatedTime() time.Time {
+	return s.created
+}
+
+// ActivityTime returns the time of the last activity in the session.
+func (s *Session) ActivityTime() time.Time {
+	return s.activity
+}
+
+// LastAttachedTime returns when a client last attached to the session. It is
+// the zero time if the session has never been attached.
+func (s *Session) LastAttachedTime() time.Time {
+	return s.lastAttached
+}
+
 // ListSessions returns all tmux sessions.
 func (t *Tmux) ListSessions() ([]*Session, error) {
 	output, err := t.query().
@@ -103,6 +122,22 @@ func (t *Tmux) GetSessionByName(name string) (*Session, error) {
 	return nil, nil
 }
 
+// GetSessionById retrieves a session by its ID.
+func (t *Tmux) GetSessionById(id string) (*Session, error) {
+	sessions, err := t.ListSessions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session by id: %w", err)
+	}
+
+	for _, session := range sessions {
+		if session.Id == id {
+			return session, nil
+		}
+	}
+
+	return nil, nil
+}
+
 // Session is an alias for GetSessionByName.
 func (t *Tmux) Session(name string) (*Session, error) {
 	return t.GetSessionByName(name)
@@ -118,23 +153,41 @@ func (t *Tmux) NewSession(op *SessionOptions) (*Session, error) {
 		sessionVars()
 
 	if op != nil {
+		if err := op.validate(); err != nil {
+			return nil, err
+		}
 		if op.Name != "" {
-			if !checkSessionName(op.Name) {
-				return nil, errors.New("invalid tmux session name")
-			}
 			q.fargs("-s", op.Name)
 		}
+		if op.AttachIfExists {
+			q.fargs("-A")
+			if op.DetachOthers {
+				q.fargs("-D")
+			}
+		}
+		if op.GroupTarget != "" {
+			q.fargs("-t", op.GroupTarget)
+		}
+		if op.WindowName != "" {
+			q.fargs("-n", op.WindowName)
+		}
 		if op.StartDirectory != "" {
 			q.fargs("-c", op.StartDirectory)
 		}
 		if op.Width != 0 {
-			q.fargs("-x", fmt.Sprintf("%d", op.Width))
+			q.fargs("-x", sizeArgument(op.Width))
 		}
 		if op.Height != 0 {
-			q.fargs("-y", fmt.Sprintf("%d", op.Height))
+			q.fargs("-y", sizeArgument(op.Height))
+		}
+		for _, key := range sortedKeys(op.Environment) {
+			q.fargs("-e", key+"="+op.Environment[key])
+		}
+		if op.PrintFormat != "" {
+			q.format(printFormatKey, op.PrintFormat)
 		}
 		if op.ShellCommand != "" {
-			q.pargs(fmt.Sprintf("'%s'", op.ShellCommand))
+			q.pargs(op.ShellCommand)
 		}
 	}
 
@@ -143,10 +196,51 @@ func (t *Tmux) NewSession(op *SessionOptions) (*Session, error) {
 		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
 
-	session := output.one().toSession(t)
+	result := output.one()
+	if result.get(varSessionId) == "" && op != nil && op.AttachIfExists {
+		// new-session -A attaches to an existing session without printing it.
+		return t.GetSessionByName(op.Name)
+	}
+
+	session := result.toSession(t)
+	session.printed = result.get(printFormatKey)
 	return session, nil
 }
 
+func (op *SessionOptions) validate() error {
+	if op.Name != "" && !checkSessionName(op.Name) {
+		return invalidOptions("new-session", "Name", "invalid tmux session name")
+	}
+	if op.AttachIfExists && op.Name == "" {
+		return invalidOptions("new-session", "AttachIfExists", "requires Name")
+	}
+	if op.DetachOthers && !op.AttachIfExists {
+		return invalidOptions("new-session", "DetachOthers", "requires AttachIfExists")
+	}
+	if op.GroupTarget != "" && op.ShellCommand != "" {
+		return invalidOptions("new-session", "ShellCommand", "cannot be used with GroupTarget")
+	}
+	if op.GroupTarget != "" && op.WindowName != "" {
+		return invalidOptions("new-session", "WindowName", "cannot be used with GroupTarget")
+	}
+	if op.Width < 0 && op.Width != SizeFromClient {
+		return invalidOptions("new-session", "Width", "must be positive or SizeFromClient")
+	}
+	if op.Height < 0 && op.Height != SizeFromClient {
+		return invalidOptions("new-session", "Height", "must be positive or SizeFromClient")
+	}
+	if err := validateEnvironment("new-session", op.Environment); err != nil {
+		return err
+	}
+	return nil
+}
+
+// PrintedFormat returns the expansion of SessionOptions.PrintFormat captured
+// when the session was created.
+func (s *Session) PrintedFormat() string {
+	return s.printed
+}
+
 // New creates a session with default options.
 func (t *Tmux) New() (*Session, error) {
 	return t.NewSession(nil)
@@ -228,7 +322,7 @@ func (s *Session) ListClients() ([]*Client, error) {
 func (s *Session) AttachSession(op *AttachSessionOptions) error {
 	q := s.tmux.query().
 		cmd("attach-session").
-		fargs("-t", s.Name)
+		fargs("-t", s.target())
 
 	if op != nil {
 		if op.DetachClients {
@@ -254,7 +348,7 @@ func (s *Session) Attach() error {
 func (s *Session) Detach() error {
 	_, err := s.tmux.query().
 		cmd("detach-client").
-		fargs("-s", s.Name).
+		fargs("-s", s.target()).
 		run()
 	if err != nil {
 		return fmt.Errorf("failed to detach session: %w", err)
@@ -266,7 +360,7 @@ func (s *Session) Detach() error {
 func (s *Session) Kill() error {
 	_, err := s.tmux.query().
 		cmd("kill-session").
-		fargs("-t", s.Name).
+		fargs("-t", s.target()).
 		run()
 	if err != nil {
 		return fmt.Errorf("failed to kill session: %w", err)
@@ -278,31 +372,65 @@ func (s *Session) Kill() error {
 func (s *Session) Rename(name string) error {
 	_, err := s.tmux.query().
 		cmd("rename-session").
-		fargs("-t", s.Name).
+		fargs("-t", s.target()).
 		pargs(name).
 		run()
 	if err != nil {
 		return fmt.Errorf("failed to rename session: %w", err)
 	}
+	s.Name = name
 	return nil
 }
 
+// target returns the session's stable ID, falling back to its name for
+// handles built without one.
+func (s *Session) target() string {
+	if s.Id != "" {
+		return s.Id
+	}
+	return s.Name
+}
+
+// Refresh reloads the session's fields from tmux. It returns an error wrapping
+// ErrNotFound if the session no longer exists.
+func (s *Session) Refresh() error {
+	output, err := s.tmux.query().
+		cmd("display-message").
+		fargs("-t", s.target()).
+		sessionVars().
+		run()
+	if err != nil {
+		return fmt.Errorf("failed to refresh session: %w", lookupError("session", s.target(), err))
+	}
+	refreshed := output.one().toSession(s.tmux)
+	if refreshed.Id == "" || (s.Id != "" && refreshed.Id != s.Id) {
+		return fmt.Errorf("failed to refresh session: %w", notFound("session", s.target()))
+	}
+	*s = *refreshed
+	return nil
+}
+
+// Exists reports whether the session is still present on the server.
+func (s *Session) Exists() bool {
+	return s.tmux.HasSession(s.target())
+}
+
 // SetOption sets a session-scoped option.
 func (s *Session) SetOption(key, value string) error {
-	return s.tmux.SetOption(s.Name, key, value, "")
+	return s.tmux.SetOption(s.target(), key, value, "")
 }
 
 // Option retrieves a session option value.
 func (s *Session) Option(key string) (*Option, error) {
-	return s.tmux.Option(s.Name, key, "")
+	return s.tmux.Option(s.target(), key, "")
 }
 
 // Options lists all session options.
 func (s *Session) Options() ([]*Option, error) {
-	return s.tmux.Options(s.Name, "")
+	return s.tmux.Options(s.target(), "")
 }
 
 // DeleteOption removes a session option.
 func (s *Session) DeleteOption(key string) error {
-	return s.tmux.DeleteOption(s.Name, key, "")
+	return s.tmux.DeleteOption(s.target(), key, "")
 }
@@ -1,6 +1,7 @@
 package gotmuxcc
 
 import (
+	"errors"
 	"strings"
 	"sync"
 	"testing"
@@ -139,3 +140,168 @@ func TestSessionAttachDetachHelpers(t *testing.T) {
 		t.Fatalf("Rename returned error: %v", err)
 	}
 }
+
+func TestNewSessionOptionFlags(t *testing.T) {
+	sessionVars := func() []string {
+		q := newQuery(nil)
+		q.sessionVars()
+		return append([]string(nil), q.variables...)
+	}()
+	record := formatRecord(append(sessionVars, printFormatKey), map[string]string{
+		varSessionId:   "$4",
+		varSessionName: "work",
+		printFormatKey: "80x24-:-extra",
+	})
+
+	tr := newScriptedTransport([]scriptedResponse{
+		{match: "new-session", lines: []string{"%begin 1 1 0", record, "%end 1 1 0"}},
+	})
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	session, err := tmux.NewSession(&SessionOptions{
+		Name:           "work",
+		WindowName:     "editor",
+		Width:          SizeFromClient,
+		Height:         40,
+		Environment:    map[string]string{"B": "2", "A": "one two"},
+		AttachIfExists: true,
+		DetachOthers:   true,
+		PrintFormat:    "#{window_width}x#{window_height}",
+		ShellCommand:   "vim",
+	})
+	if err != nil {
+		t.Fatalf("NewSession returned error: %v", err)
+	}
+	if session.Id != "$4" || session.PrintedFormat() != "80x24-:-extra" {
+		t.Fatalf("unexpected session: %#v (printed %q)", session, session.PrintedFormat())
+	}
+
+	tr.mu.Lock()
+	sent := tr.sent[0]
+	tr.mu.Unlock()
+	for _, fragment := range []string{
+		"-s work -A -D -n editor -x - -y 40 -e 'A=one two' -e B=2",
+		"-:-#{window_width}x#{window_height}'",
+		"-- vim",
+	} {
+		if !strings.Contains(sent, fragment) {
+			t.Fatalf("expected %q in command %s", fragment, sent)
+		}
+	}
+}
+
+func TestNewSessionInvalidOptions(t *testing.T) {
+	cases := map[string]*SessionOptions{
+		"Name":           {Name: "bad:name"},
+		"AttachIfExists": {AttachIfExists: true},
+		"DetachOthers":   {Name: "x", DetachOthers: true},
+		"ShellCommand":   {GroupTarget: "shared", ShellCommand: "top"},
+		"WindowName":     {GroupTarget: "shared", WindowName: "w"},
+		"Width":          {Width: -5},
+		"Height":         {Height: -2},
+		"Environment":    {Environment: map[string]string{"A=B": "c"}},
+	}
+
+	tmux := &Tmux{}
+	for option, op := range cases {
+		_, err := tmux.NewSession(op)
+		var optErr *InvalidOptionsError
+		if !errors.As(err, &optErr) {
+			t.Fatalf("%s: expected InvalidOptionsError, got %v", option, err)
+		}
+		if optErr.Option != option || optErr.Command != "new-session" {
+			t.Fatalf("%s: unexpected error fields: %#v", option, optErr)
+		}
+	}
+}
+
+func TestNewSessionAttachIfExistsLooksUpSession(t *testing.T) {
+	sessionVars := func() []string {
+		q := newQuery(nil)
+		q.sessionVars()
+		return append([]string(nil), q.variables...)
+	}()
+
+	tr := newScriptedTransport([]scriptedResponse{
+		{match: "new-session", lines: []string{"%begin 1 1 0", "%end 1 1 0"}},
+		{match: "list-sessions", lines: []string{
+			"%begin 1 1 0",
+			formatRecord(sessionVars, map[string]string{varSessionId: "$0", varSessionName: "main"}),
+			"%end 1 1 0",
+		}},
+	})
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	session, err := tmux.NewSession(&SessionOptions{Name: "main", AttachIfExists: true})
+	if err != nil {
+		t.Fatalf("NewSession returned error: %v", err)
+	}
+	if session == nil || session.Id != "$0" {
+		t.Fatalf("expected existing session, got %#v", session)
+	}
+}
+
+func TestSessionHandleTargetsById(t *testing.T) {
+	tr := newAutoTransport()
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	session := &Session{Id: "$3", Name: "old", tmux: tmux}
+	if err := session.Rename("new"); err != nil {
+		t.Fatalf("Rename returned error: %v", err)
+	}
+	if session.Name != "new" {
+		t.Fatalf("expected handle name to follow rename, got %q", session.Name)
+	}
+	if err := session.Kill(); err != nil {
+		t.Fatalf("Kill returned error: %v", err)
+	}
+
+	tr.sendMu.Lock()
+	sent := append([]string(nil), tr.sent...)
+	tr.sendMu.Unlock()
+	want := []string{"rename-session -t '$3' -- new", "kill-session -t '$3'"}
+	for idx, cmd := range want {
+		if idx >= len(sent) || sent[idx] != cmd {
+			t.Fatalf("expected %q, saw %v", cmd, sent)
+		}
+	}
+}
+
+func TestRefreshReportsNotFound(t *testing.T) {
+	sessionVars := func() []string {
+		q := newQuery(nil)
+		q.sessionVars()
+		return append([]string(nil), q.variables...)
+	}()
+	responses := []scriptedResponse{
+		{match: "display-message -t '$1' -p", lines: []string{
+			"%begin 1 1 0",
+			formatRecord(sessionVars, map[string]string{varSessionId: "$1", varSessionName: "renamed", varSessionWindows: "4"}),
+			"%end 1 1 0",
+		}},
+		{match: "display-message -t @9 -p", lines: []string{"%begin 1 1 0", "can't find window: @9", "%error 1 1 0"}},
+	}
+	tr := newScriptedTransport(responses)
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	session := &Session{Id: "$1", Name: "old", tmux: tmux}
+	if err := session.Refresh(); err != nil {
+		t.Fatalf("Refresh returned error: %v", err)
+	}
+	if session.Name != "renamed" || session.Windows != 4 {
+		t.Fatalf("unexpected refreshed session: %#v", session)
+	}
+
+	window := &Window{Id: "@9", tmux: tmux}
+	if err := window.Refresh(); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
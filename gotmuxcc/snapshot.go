@@ -0,0 +1,372 @@
+package gotmuxcc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// SnapshotVersion is the document version written by Tmux.Snapshot.
+const SnapshotVersion = 1
+
+// Snapshot is a serialisable description of every session on a tmux server.
+type Snapshot struct {
+	Version     int               `json:"version"`
+	CreatedTime time.Time         `json:"created"`
+	Sessions    []SessionSnapshot `json:"sessions"`
+}
+
+// SessionSnapshot captures a session, its windows and its local options.
+type SessionSnapshot struct {
+	Name    string            `json:"name"`
+	Path    string            `json:"path,omitempty"`
+	Options map[string]string `json:"options,omitempty"`
+	Windows []WindowSnapshot  `json:"windows"`
+}
+
+// WindowSnapshot captures a window, its layout and its panes.
+type WindowSnapshot struct {
+	Index   int               `json:"index"`
+	Name    string            `json:"name"`
+	Active  bool              `json:"active,omitempty"`
+	Layout  string            `json:"layout"`
+	Width   int               `json:"width,omitempty"`
+	Height  int               `json:"height,omitempty"`
+	Options map[string]string `json:"options,omitempty"`
+	Panes   []PaneSnapshot    `json:"panes"`
+}
+
+// PaneSnapshot captures a pane's working directory and command.
+type PaneSnapshot struct {
+	Index          int               `json:"index"`
+	Active         bool              `json:"active,omitempty"`
+	Title          string            `json:"title,omitempty"`
+	Path           string            `json:"path,omitempty"`
+	StartCommand   string            `json:"start_command,omitempty"`
+	CurrentCommand string            `json:"current_command,omitempty"`
+	Options        map[string]string `json:"options,omitempty"`
+}
+
+// RestoreOptions customises Tmux.Restore.
+type RestoreOptions struct {
+	// ReplaceExisting kills sessions that already exist under a snapshot
+	// session's name. By default such sessions are left alone and skipped.
+	ReplaceExisting bool
+	// RunCommands starts each pane with its recorded start command instead
+	// of the default shell.
+	RunCommands bool
+}
+
+// Snapshot records every session, window and pane on the server along with
+// their layouts, working directories, commands and local option overrides.
+func (t *Tmux) Snapshot() (*Snapshot, error) {
+	sessions, err := t.ListSessions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot sessions: %w", err)
+	}
+
+	snapshot := &Snapshot{
+		Version:     SnapshotVersion,
+		CreatedTime: time.Now().UTC().Truncate(time.Second),
+		Sessions:    make([]SessionSnapshot, 0, len(sessions)),
+	}
+	for _, session := range sessions {
+		ss, err := snapshotSession(session)
+		if err != nil {
+			return nil, fmt.Errorf("failed to snapshot session %q: %w", session.Name, err)
+		}
+		snapshot.Sessions = append(snapshot.Sessions, ss)
+	}
+	return snapshot, nil
+}
+
+func snapshotSession(session *Session) (SessionSnapshot, error) {
+	options, err := session.Options()
+	if err != nil {
+		return SessionSnapshot{}, err
+	}
+	windows, err := session.ListWindows()
+	if err != nil {
+		return SessionSnapshot{}, err
+	}
+
+	ss := SessionSnapshot{
+		Name:    session.Name,
+		Path:    session.Path,
+		Options: optionMap(options),
+		Windows: make([]WindowSnapshot, 0, len(windows)),
+	}
+	for _, window := range windows {
+		ws, err := snapshotWindow(window)
+		if err != nil {
+			return SessionSnapshot{}, err
+		}
+		ss.Windows = append(ss.Windows, ws)
+	}
+	return ss, nil
+}
+
+func snapshotWindow(window *Window) (WindowSnapshot, error) {
+	options, err := window.Options()
+	if err != nil {
+		return WindowSnapshot{}, err
+	}
+	panes, err := window.ListPanes()
+	if err != nil {
+		return WindowSnapshot{}, err
+	}
+
+	ws := WindowSnapshot{
+		Index:   window.Index,
+		Name:    window.Name,
+		Active:  window.Active,
+		Layout:  window.Layout,
+		Width:   window.Width,
+		Height:  window.Height,
+		Options: optionMap(options),
+		Panes:   make([]PaneSnapshot, 0, len(panes)),
+	}
+	for _, pane := range panes {
+		paneOptions, err := pane.Options()
+		if err != nil {
+			return WindowSnapshot{}, err
+		}
+		ws.Panes = append(ws.Panes, PaneSnapshot{
+			Index:          pane.Index,
+			Active:         pane.Active,
+			Title:          pane.Title,
+			Path:           pane.CurrentPath,
+			StartCommand:   pane.StartCommand,
+			CurrentCommand: pane.CurrentCommand,
+			Options:        optionMap(paneOptions),
+		})
+	}
+	return ws, nil
+}
+
+func optionMap(options []*Option) map[string]string {
+	if len(options) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(options))
+	for _, option := range options {
+		if option.Values == nil {
+			m[option.Key] = option.Value
+			continue
+		}
+		// Restoring sets each value by index; unset indices are skipped.
+		for idx, value := range option.Values {
+			if value != "" {
+				m[fmt.Sprintf("%s[%d]", option.Key, idx)] = value
+			}
+		}
+	}
+	return m
+}
+
+// Encode writes the snapshot as indented JSON.
+func (s *Snapshot) Encode(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(s); err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	return nil
+}
+
+// DecodeSnapshot reads a JSON snapshot written by Snapshot.Encode.
+func DecodeSnapshot(r io.Reader) (*Snapshot, error) {
+	var snapshot Snapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	if snapshot.Version < 1 || snapshot.Version > SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", snapshot.Version)
+	}
+	return &snapshot, nil
+}
+
+// Restore recreates the sessions described by snapshot.
+func (t *Tmux) Restore(snapshot *Snapshot, op *RestoreOptions) error {
+	if snapshot == nil {
+		return nil
+	}
+	if op == nil {
+		op = &RestoreOptions{}
+	}
+
+	for _, ss := range snapshot.Sessions {
+		if len(ss.Windows) == 0 {
+			continue
+		}
+		if t.HasSession(ss.Name) {
+			if !op.ReplaceExisting {
+				continue
+			}
+			existing, err := t.GetSessionByName(ss.Name)
+			if err == nil && existing != nil {
+				err = existing.Kill()
+			}
+			if err != nil {
+				return fmt.Errorf("failed to restore session %q: %w", ss.Name, err)
+			}
+		}
+		if err := t.restoreSession(ss, op); err != nil {
+			return fmt.Errorf("failed to restore session %q: %w", ss.Name, err)
+		}
+	}
+	return nil
+}
+
+func (t *Tmux) restoreSession(ss SessionSnapshot, op *RestoreOptions) error {
+	first := ss.Windows[0]
+	sessionOptions := &SessionOptions{
+		Name:       ss.Name,
+		WindowName: first.Name,
+		Width:      first.Width,
+		Height:     first.Height,
+	}
+	if len(first.Panes) > 0 {
+		sessionOptions.StartDirectory = first.Panes[0].Path
+		if op.RunCommands {
+			sessionOptions.ShellCommand = first.Panes[0].StartCommand
+		}
+	}
+
+	session, err := t.NewSession(sessionOptions)
+	if err != nil {
+		return err
+	}
+	for key, value := range ss.Options {
+		if err := session.SetOption(key, value); err != nil {
+			return err
+		}
+	}
+
+	var active *Window
+	for idx, ws := range ss.Windows {
+		var window *Window
+		if idx == 0 {
+			windows, err := session.ListWindows()
+			if err != nil {
+				return err
+			}
+			if len(windows) == 0 {
+				return fmt.Errorf("session %q has no windows", ss.Name)
+			}
+			window = windows[0]
+		} else {
+			window, err = t.restoreWindowShell(session, ws, op)
+			if err != nil {
+				return err
+			}
+		}
+		if window.Index != ws.Index {
+			if err := window.Move(session.Name, ws.Index); err != nil {
+				return err
+			}
+		}
+		if err := t.restorePanes(window, ws, op); err != nil {
+			return err
+		}
+		if ws.Active {
+			active = window
+		}
+	}
+
+	if active != nil {
+		return active.Select()
+	}
+	return nil
+}
+
+func (t *Tmux) restoreWindowShell(session *Session, ws WindowSnapshot, op *RestoreOptions) (*Window, error) {
+	windowOptions := &NewWindowOptions{
+		WindowName:  ws.Name,
+		DoNotAttach: true,
+	}
+	if len(ws.Panes) > 0 {
+		windowOptions.StartDirectory = ws.Panes[0].Path
+		if op.RunCommands {
+			windowOptions.ShellCommand = ws.Panes[0].StartCommand
+		}
+	}
+	return session.NewWindow(windowOptions)
+}
+
+func (t *Tmux) restorePanes(window *Window, ws WindowSnapshot, op *RestoreOptions) error {
+	panes, err := window.ListPanes()
+	if err != nil {
+		return err
+	}
+	if len(panes) == 0 {
+		return fmt.Errorf("window %s has no panes", window.Id)
+	}
+
+	for idx := 1; idx < len(ws.Panes); idx++ {
+		ps := ws.Panes[idx]
+		split := &SplitWindowOptions{StartDirectory: ps.Path}
+		if op.RunCommands {
+			split.ShellCommand = ps.StartCommand
+		}
+		// Splitting the last pane appends the new one, keeping pane order
+		// aligned with the recorded layout.
+		pane, err := panes[len(panes)-1].SplitWindow(split)
+		if err != nil {
+			return err
+		}
+		panes = append(panes, pane)
+		// Keep every pane a usable size while splitting; the recorded layout
+		// is applied once all panes exist.
+		if err := window.SelectLayout(WindowLayoutTiled); err != nil {
+			return err
+		}
+	}
+
+	if ws.Layout != "" {
+		if err := window.SelectLayout(WindowLayout(ws.Layout)); err != nil {
+			return err
+		}
+	}
+	if _, ok := ws.Options["automatic-rename"]; !ok {
+		// Naming the window turned automatic-rename off locally.
+		if err := window.DeleteOption("automatic-rename"); err != nil {
+			return err
+		}
+	}
+	for key, value := range ws.Options {
+		if err := window.SetOption(key, value); err != nil {
+			return err
+		}
+	}
+
+	var active *Pane
+	for idx, ps := range ws.Panes {
+		if idx >= len(panes) {
+			break
+		}
+		pane := panes[idx]
+		for key, value := range ps.Options {
+			if err := pane.SetOption(key, value); err != nil {
+				return err
+			}
+		}
+		if ps.Title != "" {
+			_, err := t.query().
+				cmd("select-pane").
+				fargs("-t", pane.Id, "-T", ps.Title).
+				run()
+			if err != nil {
+				return fmt.Errorf("failed to set pane title: %w", err)
+			}
+		}
+		if ps.Active {
+			active = pane
+		}
+	}
+	if active != nil {
+		return active.Select()
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package gotmuxcc
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func sampleSnapshot() *Snapshot {
+	return &Snapshot{
+		Version: SnapshotVersion,
+		Sessions: []SessionSnapshot{{
+			Name: "work",
+			Windows: []WindowSnapshot{
+				{
+					Index:   1,
+					Name:    "editor",
+					Active:  true,
+					Layout:  "0b64,160x48,0,0{80x48,0,0,1,79x48,81,0,2}",
+					Options: map[string]string{"automatic-rename": "off"},
+					Panes: []PaneSnapshot{
+						{Index: 0, Active: true, Title: "vim", Path: "/home/me/my project", CurrentCommand: "vim", StartCommand: "vim main.go"},
+						{Index: 1, Title: "shell", Path: "/tmp", CurrentCommand: "bash"},
+					},
+				},
+				{
+					Index:  3,
+					Name:   "logs",
+					Layout: "c0d1,160x48,0,0,3",
+					Panes:  []PaneSnapshot{{Index: 0, Active: true, Path: "/var/log", CurrentCommand: "tail"}},
+				},
+			},
+		}},
+	}
+}
+
+func TestSnapshotJSONRoundTrip(t *testing.T) {
+	original := sampleSnapshot()
+
+	var buf bytes.Buffer
+	if err := original.Encode(&buf); err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+	decoded, err := DecodeSnapshot(&buf)
+	if err != nil {
+		t.Fatalf("DecodeSnapshot returned error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, original) {
+		t.Fatalf("snapshot mismatch after JSON round trip:\n%#v\n%#v", decoded, original)
+	}
+
+	if _, err := DecodeSnapshot(strings.NewReader(`{"version": 99}`)); err == nil {
+		t.Fatalf("expected unsupported version error")
+	}
+}
+
+func TestResurrectRoundTrip(t *testing.T) {
+	original := sampleSnapshot()
+
+	var buf bytes.Buffer
+	if err := original.ExportResurrect(&buf); err != nil {
+		t.Fatalf("ExportResurrect returned error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "pane\twork\t1\t1\t:*\t0\tvim\t:/home/me/my\\ project\t1\tvim\t:vim main.go\n") {
+		t.Fatalf("unexpected resurrect pane line:\n%s", buf.String())
+	}
+
+	imported, err := ImportResurrect(&buf)
+	if err != nil {
+		t.Fatalf("ImportResurrect returned error: %v", err)
+	}
+	imported.CreatedTime = original.CreatedTime
+	if !reflect.DeepEqual(imported, original) {
+		t.Fatalf("snapshot mismatch after resurrect round trip:\n%#v\n%#v", imported, original)
+	}
+}
+
+func TestImportResurrectRejectsShortLines(t *testing.T) {
+	if _, err := ImportResurrect(strings.NewReader("pane\twork\t0\n")); err == nil {
+		t.Fatalf("expected error for truncated pane line")
+	}
+}
+
+func TestOptionMapExpandsArrays(t *testing.T) {
+	got := optionMap([]*Option{
+		{Key: "@role", Value: "db"},
+		{Key: "update-environment", Values: []string{"DISPLAY", "", "TERM"}},
+		{Key: "terminal-overrides", Values: []string{}},
+	})
+	want := map[string]string{
+		"@role":                 "db",
+		"update-environment[0]": "DISPLAY",
+		"update-environment[2]": "TERM",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestRestoreCommandSequence(t *testing.T) {
+	sessionVars := func() []string {
+		q := newQuery(nil)
+		q.sessionVars()
+		return append([]string(nil), q.variables...)
+	}()
+	windowVars := func() []string {
+		q := newQuery(nil)
+		q.windowVars()
+		return append([]string(nil), q.variables...)
+	}()
+	paneVars := func() []string {
+		q := newQuery(nil)
+		q.paneVars()
+		return append([]string(nil), q.variables...)
+	}()
+	ok := []string{"%begin 1 1 0", "%end 1 1 0"}
+	pane := func(id, index string) string {
+		return formatRecord(paneVars, map[string]string{varPaneId: id, varPaneIndex: index})
+	}
+
+	responses := []scriptedResponse{
+		{match: "has-session -t work", lines: []string{"%begin 1 1 0", "%error 1 1 0 can't find session"}},
+		{match: "new-session -d -P -s work -n editor -c /src", lines: []string{
+			"%begin 1 1 0",
+			formatRecord(sessionVars, map[string]string{varSessionId: "$1", varSessionName: "work"}),
+			"%end 1 1 0",
+		}},
+		{match: "list-windows -t '$1'", lines: []string{
+			"%begin 1 1 0",
+			formatRecord(windowVars, map[string]string{varWindowId: "@1", varWindowIndex: "0"}),
+			"%end 1 1 0",
+		}},
+		{match: "list-panes -t @1", lines: []string{"%begin 1 1 0", pane("%1", "0"), "%end 1 1 0"}},
+		{match: "split-window -P -t %1 -c /tmp", lines: []string{"%begin 1 1 0", pane("%2", "1"), "%end 1 1 0"}},
+		{match: "select-layout -t @1 -- tiled", lines: ok},
+		{match: "select-layout -t @1 -- '0b64,160x48,0,0{80x48,0,0,1,79x48,81,0,2}'", lines: ok},
+		{match: "set-option -w -t @1 -u automatic-rename", lines: ok},
+		{match: "select-pane -t %2", lines: ok},
+		{match: "select-window -t @1", lines: ok},
+	}
+
+	tr := newScriptedTransport(responses)
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	snapshot := &Snapshot{
+		Version: SnapshotVersion,
+		Sessions: []SessionSnapshot{{
+			Name: "work",
+			Windows: []WindowSnapshot{{
+				Index:  0,
+				Name:   "editor",
+				Active: true,
+				Layout: "0b64,160x48,0,0{80x48,0,0,1,79x48,81,0,2}",
+				Panes: []PaneSnapshot{
+					{Index: 0, Path: "/src"},
+					{Index: 1, Path: "/tmp", Active: true},
+				},
+			}},
+		}},
+	}
+
+	if err := tmux.Restore(snapshot, nil); err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+
+	tr.mu.Lock()
+	sent := len(tr.sent)
+	tr.mu.Unlock()
+	if sent != len(responses) {
+		t.Fatalf("expected %d commands, saw %d", len(responses), sent)
+	}
+}
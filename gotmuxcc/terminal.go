@@ -0,0 +1,345 @@
+package gotmuxcc
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrPaneNotAttached is returned by Pane.Terminal for panes outside the
+// session the control client is attached to, which tmux sends no output for.
+var ErrPaneNotAttached = errors.New("gotmuxcc: pane is not in the attached session")
+
+// terminalSyncAttempts bounds how often Terminal retries a seed that pane
+// output raced with.
+const terminalSyncAttempts = 5
+
+// Terminal mirrors a pane's screen by feeding its %output notifications to a
+// headless terminal emulator, so reading the screen needs no round trip. It
+// is seeded from capture-pane and reseeded when the pane is resized. tmux
+// stops sending output if the control client switches to another session.
+type Terminal struct {
+	pane *Pane
+
+	syncMu sync.Mutex // serialises seeding
+
+	mu       sync.Mutex
+	vt       *vterm
+	syncing  bool
+	buffered []terminalOutput
+	stop     func()
+}
+
+type terminalOutput struct {
+	seq  uint64
+	data []byte
+}
+
+// Terminal starts mirroring the pane's screen. Close it when done.
+func (p *Pane) Terminal() (*Terminal, error) {
+	output, err := p.tmux.query().
+		cmd("list-panes").
+		fargs("-s").
+		vars(varPaneId).
+		run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attached panes: %w", err)
+	}
+	attached := false
+	for _, result := range output.collect() {
+		attached = attached || result.get(varPaneId) == p.Id
+	}
+	if !attached {
+		return nil, fmt.Errorf("pane %s: %w", p.Id, ErrPaneNotAttached)
+	}
+
+	term := &Terminal{pane: p, vt: newVterm(p.Width, p.Height)}
+	term.stop = p.tmux.listen(term.handle)
+	if err := term.Resync(); err != nil {
+		term.Close()
+		return nil, err
+	}
+	return term, nil
+}
+
+// Close stops mirroring the pane. The last screen remains readable.
+func (t *Terminal) Close() {
+	t.mu.Lock()
+	stop := t.stop
+	t.stop = nil
+	t.mu.Unlock()
+	if stop != nil {
+		stop()
+	}
+}
+
+// Screen returns a copy of the current screen. Unlike a capture, trailing
+// spaces that carry a style are kept.
+func (t *Terminal) Screen() *Screen {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.vt.screen()
+}
+
+// Cursor returns the cursor position, counted from 0 at the top left. As in
+// tmux's cursor_x, x equals the width after writing the last column, until
+// the next character wraps.
+func (t *Terminal) Cursor() (x, y int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.vt.cx, t.vt.cy
+}
+
+// CursorVisible reports whether the application has left the cursor shown.
+func (t *Terminal) CursorVisible() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.vt.cursorVisible
+}
+
+// AlternateScreen reports whether the alternate screen is active.
+func (t *Terminal) AlternateScreen() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.vt.altOn
+}
+
+// ScrollRegion returns the first and last rows of the scroll region.
+func (t *Terminal) ScrollRegion() (top, bottom int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.vt.top, t.vt.bottom
+}
+
+// Size returns the pane size the terminal models.
+func (t *Terminal) Size() (width, height int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.vt.width, t.vt.height
+}
+
+// Resync reseeds the terminal from tmux. Output arriving while the seed is
+// taken is held back and replayed; if it keeps racing the seed, Resync gives
+// up and the previous screen is kept.
+func (t *Terminal) Resync() error {
+	t.syncMu.Lock()
+	defer t.syncMu.Unlock()
+
+	t.mu.Lock()
+	t.syncing = true
+	t.buffered = nil
+	t.mu.Unlock()
+
+	for attempt := 0; attempt < terminalSyncAttempts; attempt++ {
+		vt, before, after, err := t.seed()
+		if err != nil {
+			t.finishSync(nil, 0)
+			return err
+		}
+		if vt != nil && t.settled(before, after) {
+			t.finishSync(vt, after)
+			return nil
+		}
+	}
+	t.finishSync(nil, 0)
+	return fmt.Errorf("failed to sync terminal for pane %s: output did not settle", t.pane.Id)
+}
+
+// settled reports whether no output arrived between the two state queries
+// bracketing a seed, and drops output the next seed will include otherwise.
+func (t *Terminal) settled(before, after uint64) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, output := range t.buffered {
+		if output.seq > before && output.seq <= after {
+			t.buffered = t.buffered[:0]
+			return false
+		}
+	}
+	return true
+}
+
+// finishSync installs vt, replays output that arrived after seq and resumes
+// live updates. A nil vt keeps the current screen and replays everything.
+func (t *Terminal) finishSync(vt *vterm, seq uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if vt != nil {
+		t.vt = vt
+	}
+	for _, output := range t.buffered {
+		if output.seq > seq {
+			t.vt.Write(output.data)
+		}
+	}
+	t.buffered = nil
+	t.syncing = false
+}
+
+// seed builds an emulator from the pane's state and screens. It returns nil
+// if the state changed while the screens were captured, along with the
+// event sequence numbers of the state queries before and after.
+func (t *Terminal) seed() (*vterm, uint64, uint64, error) {
+	state, before, err := t.pane.terminalState()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	altOn := isOne(state.get(varAlternateOn))
+
+	current, err := t.pane.tmux.CapturePane(t.pane.Id, &CaptureOptions{EscTxtNBgAttr: true})
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	var saved string
+	if altOn {
+		saved, err = t.pane.tmux.CapturePane(t.pane.Id, &CaptureOptions{EscTxtNBgAttr: true, AlternateScreen: true, Quiet: true})
+		if err != nil {
+			return nil, 0, 0, err
+		}
+	}
+
+	check, after, err := t.pane.terminalState()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !maps.Equal(state, check) {
+		return nil, before, after, nil
+	}
+
+	vt := newVterm(atoi(state.get(varPaneWidth)), atoi(state.get(varPaneHeight)))
+	if altOn {
+		vt.load(vt.primary, ParseScreen(saved))
+		vt.load(vt.alternate, ParseScreen(current))
+		vt.grid = vt.alternate
+		vt.altOn = true
+		vt.altSaved = vtermCursor{x: atoi(state.get(varAlternateSavedX)), y: atoi(state.get(varAlternateSavedY))}
+	} else {
+		vt.load(vt.primary, ParseScreen(current))
+	}
+	vt.top = atoi(state.get(varScrollRegionUpper))
+	vt.bottom = atoi(state.get(varScrollRegionLower))
+	if vt.bottom <= vt.top || vt.bottom >= vt.height {
+		vt.top, vt.bottom = 0, vt.height-1
+	}
+	vt.cursorVisible = isOne(state.get(varCursorFlag))
+	vt.insert = isOne(state.get(varInsertFlag))
+	vt.origin = isOne(state.get(varOriginFlag))
+	vt.moveCursor(0, atoi(state.get(varCursorY)))
+	vt.cx = max(0, min(atoi(state.get(varCursorX)), vt.width))
+	return vt, before, after, nil
+}
+
+// terminalStateVars is the pane state a Terminal is seeded from.
+var terminalStateVars = []string{
+	varAlternateOn,
+	varAlternateSavedX,
+	varAlternateSavedY,
+	varCursorFlag,
+	varCursorX,
+	varCursorY,
+	varInsertFlag,
+	varOriginFlag,
+	varPaneHeight,
+	varPaneWidth,
+	varScrollRegionLower,
+	varScrollRegionUpper,
+}
+
+// terminalState queries the pane's terminal state, returning the event
+// sequence number it is current as of.
+func (p *Pane) terminalState() (queryResult, uint64, error) {
+	output, err := p.tmux.query().
+		cmd("display-message").
+		fargs("-t", p.Id).
+		vars(terminalStateVars...).
+		run()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query pane %s: %w", p.Id, lookupError("pane", p.Id, err))
+	}
+	state := output.one()
+	if len(state) == 0 || state.get(varPaneWidth) == "" {
+		return nil, 0, notFound("pane", p.Id)
+	}
+	return state, output.result.eventSeq, nil
+}
+
+func (t *Terminal) handle(evt Event) {
+	switch evt.Name {
+	case "output":
+		pane, data, ok := parseOutputEvent(evt.Raw)
+		if !ok || pane != t.pane.Id {
+			return
+		}
+		t.mu.Lock()
+		if t.syncing {
+			t.buffered = append(t.buffered, terminalOutput{seq: evt.seq, data: data})
+		} else {
+			t.vt.Write(data)
+		}
+		t.mu.Unlock()
+	case "layout-change":
+		if t.resized(evt) {
+			// Commands cannot run on the goroutine delivering events.
+			go t.Resync()
+		}
+	}
+}
+
+// resized reports whether a layout-change event gives the pane a new size.
+func (t *Terminal) resized(evt Event) bool {
+	if len(evt.Fields) < 2 {
+		return false
+	}
+	layout := evt.Fields[1]
+	if len(evt.Fields) > 2 {
+		layout = evt.Fields[2] // the visible layout accounts for zoom
+	}
+	tree, err := ParseLayout(layout)
+	if err != nil {
+		return false
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(t.pane.Id, "%"))
+	if err != nil {
+		return false
+	}
+	width, height := t.Size()
+	for _, cell := range tree.Panes() {
+		if cell.PaneId == id {
+			return cell.Width != width || cell.Height != height
+		}
+	}
+	return false
+}
+
+// parseOutputEvent decodes a %output line, whose data has bytes below space
+// and backslashes escaped as \ooo.
+func parseOutputEvent(raw string) (pane string, data []byte, ok bool) {
+	rest, ok := strings.CutPrefix(raw, "%output ")
+	if !ok {
+		return "", nil, false
+	}
+	pane, value, _ := strings.Cut(rest, " ")
+	data = make([]byte, 0, len(value))
+	for idx := 0; idx < len(value); idx++ {
+		if value[idx] == '\\' && idx+3 < len(value) && isOctal(value[idx+1:idx+4]) {
+			n, _ := strconv.ParseUint(value[idx+1:idx+4], 8, 8)
+			data = append(data, byte(n))
+			idx += 3
+			continue
+		}
+		data = append(data, value[idx])
+	}
+	return pane, data, true
+}
+
+func isOctal(digits string) bool {
+	for idx := 0; idx < len(digits); idx++ {
+		if digits[idx] < '0' || digits[idx] > '7' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+package gotmuxcc
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVtermScrollRegionAndLineOperations(t *testing.T) {
+	vt := newVterm(10, 5)
+	vt.Write([]byte("1\r\n2\r\n3\r\n4\r\n5"))
+	// Scroll rows 2-4 (1-based) up by one, then insert a line at the top,
+	// outside the region, which tmux applies to the whole screen.
+	vt.Write([]byte("\x1b[2;4r\x1b[4;1H\nx\x1b[1;1H\x1b[Lt"))
+	want := "t\n1\n3\n4\nx"
+	if got := vt.screen().String(); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if vt.top != 1 || vt.bottom != 3 {
+		t.Fatalf("unexpected scroll region %d-%d", vt.top, vt.bottom)
+	}
+}
+
+func TestVtermWrapAndWideCharacters(t *testing.T) {
+	vt := newVterm(5, 3)
+	vt.Write([]byte("abcde"))
+	if vt.cx != 5 || vt.cy != 0 {
+		t.Fatalf("expected cursor past the last column, got %d,%d", vt.cx, vt.cy)
+	}
+	vt.Write([]byte("f日本\xe6\x1b[1mg"))
+	want := "abcde\nf日本\ng"
+	if got := vt.screen().String(); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if cell := vt.screen().Rows[2][0]; cell.Style.Attributes != AttrBold {
+		t.Fatalf("expected a bold cell after invalid UTF-8, got %+v", cell)
+	}
+
+	// Without autowrap, characters past the last column are dropped.
+	vt.Write([]byte("\x1b[2J\x1b[H\x1b[?7labcdefg"))
+	if got := vt.screen().String(); got != "abcde\n\n" {
+		t.Fatalf("unexpected screen without autowrap: %q", got)
+	}
+}
+
+func TestVtermAlternateScreenAndErase(t *testing.T) {
+	vt := newVterm(8, 3)
+	vt.Write([]byte("shell\r\n$ "))
+	vt.Write([]byte("\x1b[?1049h\x1b[H\x1b[41mfull\x1b[K"))
+	screen := vt.screen()
+	if !vt.altOn || screen.String() != "full    \n\n" {
+		t.Fatalf("unexpected alternate screen %q", screen.String())
+	}
+	if cell := screen.Cell(7, 0); cell == nil || cell.Style.Bg != (Color{Type: ColorNamed, Index: 1}) {
+		t.Fatalf("expected erased cells to keep the background, got %+v", cell)
+	}
+	vt.Write([]byte("\x1b[0m\x1b[?1049l"))
+	if vt.altOn || vt.screen().String() != "shell\n$\n" || vt.cx != 2 || vt.cy != 1 {
+		t.Fatalf("expected the primary screen and cursor back, got %q at %d,%d", vt.screen().String(), vt.cx, vt.cy)
+	}
+}
+
+func TestParseOutputEvent(t *testing.T) {
+	pane, data, ok := parseOutputEvent(`%output %3 a b\015\012\134x\1`)
+	if !ok || pane != "%3" || string(data) != "a b\r\n\\x\\1" {
+		t.Fatalf("unexpected decode: %q %q %v", pane, data, ok)
+	}
+	if _, _, ok := parseOutputEvent("%window-add @1"); ok {
+		t.Fatalf("expected non-output events to be rejected")
+	}
+}
+
+func TestTerminalSeedsAndFollowsOutput(t *testing.T) {
+	state := []string{
+		"%begin 1 1 0",
+		formatRecord(terminalStateVars, map[string]string{
+			varPaneWidth:         "10",
+			varPaneHeight:        "3",
+			varCursorX:           "2",
+			varCursorY:           "0",
+			varCursorFlag:        "1",
+			varScrollRegionLower: "2",
+		}),
+		"%end 1 1 0",
+	}
+	capture := []string{"%begin 1 1 0", "ab", "", "", "%end 1 1 0"}
+	responses := []scriptedResponse{
+		{match: "list-panes -s", lines: []string{"%begin 1 1 0", "%1", "%end 1 1 0"}},
+		{match: "display-message -t %1", lines: state},
+		// Output racing the first seed forces a second attempt.
+		{match: "capture-pane -t %1 -p -e", lines: append([]string{`%output %1 lost`}, capture...)},
+		{match: "display-message -t %1", lines: state},
+		{match: "display-message -t %1", lines: state},
+		{match: "capture-pane -t %1 -p -e", lines: capture},
+		{match: "display-message -t %1", lines: append(append([]string(nil), state...), `%output %2 other`, `%output %1 c\015\012d`)},
+	}
+	tr := newScriptedTransport(responses)
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	term, err := (&Pane{Id: "%1", tmux: tmux}).Terminal()
+	if err != nil {
+		t.Fatalf("Terminal returned error: %v", err)
+	}
+	defer term.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for term.Screen().String() != "abc\nd\n" {
+		if time.Now().After(deadline) {
+			t.Fatalf("screen never caught up: %q", term.Screen().String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if x, y := term.Cursor(); x != 1 || y != 1 {
+		t.Fatalf("unexpected cursor %d,%d", x, y)
+	}
+	if width, height := term.Size(); width != 10 || height != 3 {
+		t.Fatalf("unexpected size %dx%d", width, height)
+	}
+}
+
+func TestTerminalRequiresAttachedPane(t *testing.T) {
+	responses := []scriptedResponse{
+		{match: "list-panes -s", lines: []string{"%begin 1 1 0", "%2", "%end 1 1 0"}},
+	}
+	tr := newScriptedTransport(responses)
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	if _, err := (&Pane{Id: "%1", tmux: tmux}).Terminal(); !errors.Is(err, ErrPaneNotAttached) {
+		t.Fatalf("expected ErrPaneNotAttached, got %v", err)
+	}
+}
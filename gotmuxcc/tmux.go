@@ -133,6 +133,14 @@ func (t *Tmux) events() <-chan Event {
 	return t.router.eventsChannel()
 }
 
+// listen registers fn for the router's notifications; see router.listen.
+func (t *Tmux) listen(fn func(Event)) func() {
+	if t == nil || t.router == nil {
+		return func() {}
+	}
+	return t.router.listen(fn)
+}
+
 func (t *Tmux) runCommand(command string) (commandResult, error) {
 	if t == nil || t.router == nil {
 		return commandResult{}, errRouterClosed
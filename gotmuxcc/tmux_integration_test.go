@@ -4,12 +4,15 @@
 package gotmuxcc
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -84,6 +87,16 @@ func skipIfUnsupported(t *testing.T, err error) {
 	}
 }
 
+// skipIfUncollected skips the test when the pane is dead but tmux never
+// collected its exit status, which happens when the server misses SIGCHLD in
+// some sandboxes; Wait then rightly waits until its context is done.
+func skipIfUncollected(t *testing.T, pane *Pane) {
+	t.Helper()
+	if _, dead, err := pane.exitStatus(); err == nil && dead {
+		t.Skipf("tmux did not collect the exit status of pane %s", pane.Id)
+	}
+}
+
 func waitForCondition(t *testing.T, desc string, fn func() (bool, error)) {
 	t.Helper()
 	deadline := time.Now().Add(5 * time.Second)
@@ -380,7 +393,7 @@ func TestPaneOperations(t *testing.T) {
 	}
 	first := panes[0]
 
-	if err := first.SplitWindow(&SplitWindowOptions{SplitDirection: PaneSplitDirectionVertical}); err != nil {
+	if _, err := first.SplitWindow(&SplitWindowOptions{SplitDirection: PaneSplitDirectionVertical}); err != nil {
 		skipIfUnsupported(t, err)
 		if err != nil {
 			t.Fatalf("SplitWindow returned error: %v", err)
@@ -558,7 +571,7 @@ func TestSessionWindowPaneState(t *testing.T) {
 		t.Fatalf("expected code window to contain at least one pane")
 	}
 
-	if err := panes[0].SplitWindow(&SplitWindowOptions{SplitDirection: PaneSplitDirectionVertical}); err != nil {
+	if _, err := panes[0].SplitWindow(&SplitWindowOptions{SplitDirection: PaneSplitDirectionVertical}); err != nil {
 		skipIfUnsupported(t, err)
 		t.Fatalf("SplitWindow returned error: %v", err)
 	}
@@ -648,3 +661,1606 @@ func TestSessionWindowPaneState(t *testing.T) {
 		return false, nil
 	})
 }
+
+func TestPaneWaitIntegration(t *testing.T) {
+	tmux := newTestTmux(t)
+
+	name := fmt.Sprintf("wait-%d", time.Now().UnixNano())
+	session, err := tmux.NewSession(&SessionOptions{Name: name})
+	skipIfUnsupported(t, err)
+	if err != nil {
+		t.Fatalf("NewSession returned error: %v", err)
+	}
+	defer func() { _ = session.Kill() }()
+
+	panes, err := session.ListPanes()
+	skipIfUnsupported(t, err)
+	if err != nil || len(panes) == 0 {
+		t.Fatalf("ListPanes returned %v, %v", panes, err)
+	}
+	first := panes[0]
+
+	if _, err := tmux.Command("set-hook", "-g", "pane-died", "set-option -g @user fired"); err != nil {
+		t.Fatalf("set-hook returned error: %v", err)
+	}
+	defer func() { _, _ = tmux.Command("set-hook", "-gu", "pane-died") }()
+
+	pane, err := first.SplitWindow(&SplitWindowOptions{ShellCommand: "sleep 1; exit 7"})
+	if err != nil {
+		t.Fatalf("SplitWindow returned error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	status, err := pane.Wait(ctx, nil)
+	if err != nil {
+		skipIfUncollected(t, pane)
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	if status.Code != 7 || status.Time.IsZero() {
+		t.Fatalf("unexpected status %+v", status)
+	}
+	if out, _ := tmux.Command("show-hooks", "-g", "pane-died"); strings.Count(out, "\n") != 0 || !strings.Contains(out, "@user") {
+		t.Fatalf("expected only the user hook to remain, got %q", out)
+	}
+	waitForCondition(t, "user hook to run", func() (bool, error) {
+		out, err := tmux.Command("show-options", "-gv", "@user")
+		return out == "fired", err
+	})
+
+	// The pane is already dead; respawn it once its status is read.
+	status, err = pane.Wait(ctx, &WaitOptions{Respawn: &RespawnPaneOptions{ShellCommand: "kill -TERM $$"}})
+	if err != nil || status.Code != 7 {
+		t.Fatalf("Wait on a dead pane returned %+v, %v", status, err)
+	}
+	status, err = pane.Wait(ctx, &WaitOptions{Kill: true})
+	if err != nil || status.Signal != 15 {
+		skipIfUncollected(t, pane)
+		t.Fatalf("Wait after respawn returned %+v, %v", status, err)
+	}
+	if err := pane.Refresh(); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected killed pane to be gone, got %v", err)
+	}
+
+	// Killed while waiting.
+	pane, err = first.SplitWindow(&SplitWindowOptions{ShellCommand: "sleep 30"})
+	if err != nil {
+		t.Fatalf("SplitWindow returned error: %v", err)
+	}
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		_ = pane.Kill()
+	}()
+	if _, err := pane.Wait(ctx, nil); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for a killed pane, got %v", err)
+	}
+
+	// Cancelled while waiting.
+	pane, err = first.SplitWindow(&SplitWindowOptions{ShellCommand: "sleep 30"})
+	if err != nil {
+		t.Fatalf("SplitWindow returned error: %v", err)
+	}
+	short, stop := context.WithTimeout(ctx, 300*time.Millisecond)
+	defer stop()
+	if _, err := pane.Wait(short, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestPaneWaitExitedEarlyIntegration(t *testing.T) {
+	tmux := newTestTmux(t)
+
+	name := fmt.Sprintf("wait-early-%d", time.Now().UnixNano())
+	session, err := tmux.NewSession(&SessionOptions{Name: name})
+	skipIfUnsupported(t, err)
+	if err != nil {
+		t.Fatalf("NewSession returned error: %v", err)
+	}
+	defer func() { _ = session.Kill() }()
+
+	panes, err := session.ListPanes()
+	skipIfUnsupported(t, err)
+	if err != nil || len(panes) == 0 {
+		t.Fatalf("ListPanes returned %v, %v", panes, err)
+	}
+
+	// Keep the panes around so the process is gone before Wait starts.
+	if _, err := tmux.Command("set-option", "-w", "-t", panes[0].Id, "remain-on-exit", "on"); err != nil {
+		t.Fatalf("set-option returned error: %v", err)
+	}
+
+	for _, command := range []string{"true", "gotmuxcc-missing-binary"} {
+		pane, err := panes[0].SplitWindow(&SplitWindowOptions{ShellCommand: command})
+		if err != nil {
+			t.Fatalf("SplitWindow(%q) returned error: %v", command, err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		status, err := pane.Wait(ctx, &WaitOptions{Kill: true})
+		cancel()
+		if err != nil {
+			skipIfUncollected(t, pane)
+			t.Fatalf("Wait(%q) returned error: %v", command, err)
+		}
+		if command == "true" && status.Code != 0 || command != "true" && status.Code == 0 {
+			t.Fatalf("unexpected status for %q: %+v", command, status)
+		}
+	}
+}
+
+// leftoverDirs returns the temporary directories gotmuxcc has left behind.
+func leftoverDirs(t *testing.T) []string {
+	t.Helper()
+	dirs, err := filepath.Glob(filepath.Join(os.TempDir(), "gotmuxcc-*"))
+	if err != nil {
+		t.Fatalf("Glob returned error: %v", err)
+	}
+	return dirs
+}
+
+func TestCmdIntegration(t *testing.T) {
+	tmux := newTestTmux(t)
+
+	name := fmt.Sprintf("cmd-%d", time.Now().UnixNano())
+	session, err := tmux.NewSession(&SessionOptions{Name: name})
+	skipIfUnsupported(t, err)
+	if err != nil {
+		t.Fatalf("NewSession returned error: %v", err)
+	}
+	defer func() { _ = session.Kill() }()
+	before := len(leftoverDirs(t))
+
+	var stdout, stderr bytes.Buffer
+	cmd := session.Command("sh", "-c", `echo "out $FOO $(pwd)"; echo err >&2; cat; exit 3`)
+	cmd.Dir = "/tmp"
+	cmd.Env = []string{"FOO=bar baz"}
+	cmd.Stdin = strings.NewReader("from stdin\n")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 3 {
+		skipIfUncollected(t, cmd.Pane)
+		t.Fatalf("expected exit status 3, got %v", err)
+	}
+	out := stdout.String()
+	if !strings.Contains(out, "out bar baz /tmp") || !strings.Contains(out, "from stdin") || strings.Contains(out, "err") {
+		t.Fatalf("unexpected stdout %q", out)
+	}
+	if stderr.String() != "err\n" {
+		t.Fatalf("unexpected stderr %q", stderr.String())
+	}
+	if cmd.Pane.Exists() {
+		t.Fatalf("expected pane %s to be killed", cmd.Pane.Id)
+	}
+
+	panes, err := session.ListPanes()
+	if err != nil || len(panes) == 0 {
+		t.Fatalf("ListPanes returned %v, %v", panes, err)
+	}
+	first := panes[0]
+
+	stdout.Reset()
+	cmd = first.Command("printf", `%s\n`, "a b", "it's")
+	cmd.Stdout = &stdout
+	cmd.KeepPane = true
+	if err := cmd.Run(); err != nil {
+		skipIfUncollected(t, cmd.Pane)
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if stdout.String() != "a b\r\nit's\r\n" || !cmd.Pane.Exists() {
+		t.Fatalf("unexpected output %q or missing pane %s", stdout.String(), cmd.Pane.Id)
+	}
+	if err := first.Refresh(); err != nil || !first.Active {
+		t.Fatalf("expected the split pane to keep focus: %v", err)
+	}
+
+	cmd = first.Command("gotmuxcc-missing-binary")
+	if err := cmd.Run(); !errors.As(err, &exitErr) || exitErr.Code != 127 {
+		skipIfUncollected(t, cmd.Pane)
+		t.Fatalf("expected exit status 127, got %v", err)
+	}
+
+	// Killed while running.
+	cmd = first.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		_ = cmd.Pane.Kill()
+	}()
+	if err := cmd.Wait(); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	// Cancelled while running, with output still being copied.
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	cmd = first.CommandContext(ctx, "sleep", "30")
+	cmd.Stdout = &stdout
+	cmd.KeepPane = true
+	if err := cmd.Run(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if cmd.Pane.Exists() {
+		t.Fatalf("expected pane %s to be killed on cancel", cmd.Pane.Id)
+	}
+	if err := cmd.Start(); err == nil {
+		t.Fatal("expected a second Start to fail")
+	}
+
+	if after := leftoverDirs(t); len(after) != before {
+		t.Fatalf("temporary directories left behind: %v", after)
+	}
+}
+
+// testSession returns the session the test server starts with, which the
+// control client is attached to.
+func testSession(t *testing.T, tmux *Tmux) *Session {
+	t.Helper()
+	session, err := tmux.GetSessionByName("gotmuxcctest")
+	skipIfUnsupported(t, err)
+	if err != nil || session == nil {
+		t.Fatalf("GetSessionByName returned %v, %v", session, err)
+	}
+	return session
+}
+
+func TestSetOptionQuotingIntegration(t *testing.T) {
+	tmux := newTestTmux(t)
+	testSession(t, tmux)
+
+	values := []string{
+		"plain", "two words", "it's", "a;b", ";", "#comment", "{}", "~user", "$HOME",
+		`\`, `"`, "line\nbreak", "\r\t\x01\x7f", "é日本", `'"'"`, "x\\\"y'z\n",
+		"%foo", "%1a", "%12", "%", "-", "--", "-foo", "--bar",
+	}
+	for _, value := range values {
+		if err := tmux.SetOption("gotmuxcctest", "@v", value, ""); err != nil {
+			t.Fatalf("SetOption(%q) returned error: %v", value, err)
+		}
+		got, err := tmux.Command("show-options", "-v", "-t", "gotmuxcctest", "@v")
+		if err != nil {
+			t.Fatalf("show-options returned error: %v", err)
+		}
+		if got != value {
+			t.Errorf("expected %q, got %q", value, got)
+		}
+	}
+
+	// Positional values that look like flags.
+	if err := tmux.SetBuffer("dash", "-foo", nil); err != nil {
+		t.Fatalf("SetBuffer returned error: %v", err)
+	}
+	if got, err := tmux.ShowBuffer("dash"); err != nil || got != "-foo" {
+		t.Fatalf("ShowBuffer returned %q, %v", got, err)
+	}
+	session := testSession(t, tmux)
+	if err := session.Rename("-dev"); err != nil {
+		t.Fatalf("Rename returned error: %v", err)
+	}
+	if got, err := tmux.Command("display-message", "-p", "-t", session.Id, "#{session_name}"); err != nil || got != "-dev" {
+		t.Fatalf("expected the session to be renamed to -dev, got %q, %v", got, err)
+	}
+}
+
+func TestSessionGroupsIntegration(t *testing.T) {
+	tmux := newTestTmux(t)
+	testSession(t, tmux)
+
+	if _, err := tmux.NewSession(&SessionOptions{Name: "grouped", GroupTarget: "gotmuxcctest"}); err != nil {
+		t.Fatalf("NewSession returned error: %v", err)
+	}
+	groups, err := tmux.ListSessionGroups()
+	if err != nil || len(groups) != 1 || len(groups[0].Sessions) != 2 {
+		t.Fatalf("ListSessionGroups returned %v, %v", groups, err)
+	}
+	session := testSession(t, tmux)
+	group, err := session.SessionGroup()
+	if err != nil || group == nil {
+		t.Fatalf("SessionGroup returned %v, %v", group, err)
+	}
+	added, err := group.NewSession(&SessionOptions{Name: "grouped-too"})
+	if err != nil || added.Name != "grouped-too" || !added.Grouped {
+		t.Fatalf("group NewSession returned %+v, %v", added, err)
+	}
+}
+
+func TestNewSessionOptionsIntegration(t *testing.T) {
+	tmux := newTestTmux(t)
+	testSession(t, tmux)
+
+	session, err := tmux.NewSession(&SessionOptions{
+		Name:        "created",
+		WindowName:  "ed",
+		Width:       100,
+		Height:      SizeFromClient,
+		Environment: map[string]string{"FOO": "a b"},
+		PrintFormat: "#{window_name}|#{window_width}",
+	})
+	if err != nil {
+		t.Fatalf("NewSession returned error: %v", err)
+	}
+	if got := session.PrintedFormat(); got != "ed|100" {
+		t.Fatalf("unexpected printed format %q", got)
+	}
+	env, err := session.Environment()
+	if value, ok := env.Lookup("FOO"); err != nil || !ok || value != "a b" {
+		t.Fatalf("expected FOO in the session environment, got %q, %v", value, err)
+	}
+	again, err := tmux.NewSession(&SessionOptions{Name: "created", AttachIfExists: true})
+	if err != nil || again == nil || again.Id != session.Id {
+		t.Fatalf("AttachIfExists returned %+v, %v", again, err)
+	}
+}
+
+func TestEnvironmentIntegration(t *testing.T) {
+	tmux := newTestTmux(t)
+	session := testSession(t, tmux)
+
+	if err := session.SetEnv("A", "1 2"); err != nil {
+		t.Fatalf("SetEnv returned error: %v", err)
+	}
+	if err := session.SetHiddenEnv("H", "h"); err != nil {
+		t.Fatalf("SetHiddenEnv returned error: %v", err)
+	}
+	if err := session.RemoveEnv("R"); err != nil {
+		t.Fatalf("RemoveEnv returned error: %v", err)
+	}
+	env, err := session.Environment()
+	if err != nil {
+		t.Fatalf("Environment returned error: %v", err)
+	}
+	if value, ok := env.Lookup("A"); !ok || value != "1 2" || !env["H"].Hidden || !env["R"].Removed {
+		t.Fatalf("unexpected environment %#v", env)
+	}
+
+	if err := tmux.SetUpdateEnvironment("X", "Y"); err != nil {
+		t.Fatalf("SetUpdateEnvironment returned error: %v", err)
+	}
+	names, err := tmux.UpdateEnvironment()
+	if err != nil || len(names) != 2 || names[0] != "X" || names[1] != "Y" {
+		t.Fatalf("UpdateEnvironment returned %v, %v", names, err)
+	}
+	global, err := tmux.Environment()
+	if err != nil || len(global) == 0 {
+		t.Fatalf("Environment returned %d variables, %v", len(global), err)
+	}
+}
+
+func TestSnapshotRestoreIntegration(t *testing.T) {
+	tmux := newTestTmux(t)
+	session := testSession(t, tmux)
+
+	window, err := session.NewWindow(&NewWindowOptions{WindowName: "two", StartDirectory: "/tmp"})
+	if err != nil {
+		t.Fatalf("NewWindow returned error: %v", err)
+	}
+	panes, err := window.ListPanes()
+	if err != nil || len(panes) == 0 {
+		t.Fatalf("ListPanes returned %v, %v", panes, err)
+	}
+	if _, err := panes[0].SplitWindow(&SplitWindowOptions{SplitDirection: PaneSplitDirectionHorizontal, StartDirectory: "/usr"}); err != nil {
+		t.Fatalf("SplitWindow returned error: %v", err)
+	}
+	if err := window.SetOption("@tag", "hello world"); err != nil {
+		t.Fatalf("SetOption returned error: %v", err)
+	}
+
+	snap, err := tmux.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %v", err)
+	}
+	var encoded bytes.Buffer
+	if err := snap.Encode(&encoded); err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+	decoded, err := DecodeSnapshot(&encoded)
+	if err != nil {
+		t.Fatalf("DecodeSnapshot returned error: %v", err)
+	}
+	// Restoring over the live session changes nothing.
+	if err := tmux.Restore(decoded, nil); err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	decoded.Sessions[0].Name = "restored"
+	if err := tmux.Restore(decoded, nil); err != nil {
+		t.Fatalf("Restore under a new name returned error: %v", err)
+	}
+
+	restored, err := tmux.GetSessionByName("restored")
+	if err != nil || restored == nil {
+		t.Fatalf("GetSessionByName returned %v, %v", restored, err)
+	}
+	windows, err := restored.ListWindows()
+	if err != nil || len(windows) != 2 || windows[1].Name != "two" || windows[1].Panes != 2 {
+		t.Fatalf("unexpected restored windows %+v, %v", windows, err)
+	}
+	tag, err := windows[1].Option("@tag")
+	if err != nil || tag.Value != "hello world" {
+		t.Fatalf("expected the window option to be restored, got %+v, %v", tag, err)
+	}
+}
+
+func TestWorkspaceIntegration(t *testing.T) {
+	tmux := newTestTmux(t)
+	testSession(t, tmux)
+
+	workspace := &Workspace{Sessions: []SessionSpec{{
+		Name:    "dev",
+		Options: map[string]string{"status": "off"},
+		Windows: []WindowSpec{
+			{
+				Name:    "editor",
+				Layout:  WindowLayoutEvenHorizontal,
+				Options: map[string]string{"mode-keys": "vi"},
+				Panes:   []PaneSpec{{StartDirectory: "/tmp"}, {Command: "sleep 1000"}, {}},
+			},
+			{Name: "shell", Panes: []PaneSpec{{Command: "sleep 1000"}}},
+		},
+	}}}
+	if plan, err := tmux.Apply(workspace); err != nil {
+		t.Fatalf("Apply returned error: %v\n%s", err, plan)
+	}
+	if plan, err := tmux.Plan(workspace); err != nil || !plan.Empty() {
+		t.Fatalf("expected nothing left to do, got %v\n%s", err, plan)
+	}
+
+	// Rearranging the panes by hand is drift too.
+	if _, err := tmux.Command("select-layout", "-t", "dev:editor", string(WindowLayoutEvenVertical)); err != nil {
+		t.Fatalf("select-layout returned error: %v", err)
+	}
+	if plan, err := tmux.Apply(workspace); err != nil || len(plan.Steps) != 1 || plan.Steps[0].Action != PlanSelectLayout {
+		t.Fatalf("expected only the layout to be reselected, got %v\n%s", err, plan)
+	}
+	if plan, err := tmux.Plan(workspace); err != nil || !plan.Empty() {
+		t.Fatalf("expected nothing left to do after reselecting, got %v\n%s", err, plan)
+	}
+
+	spec := &workspace.Sessions[0]
+	spec.KillUnlisted = true
+	spec.Windows = spec.Windows[:1]
+	spec.Windows[0].Panes = spec.Windows[0].Panes[:2]
+	if plan, err := tmux.Apply(workspace); err != nil {
+		t.Fatalf("Apply returned error: %v\n%s", err, plan)
+	}
+	if plan, err := tmux.Plan(workspace); err != nil || !plan.Empty() {
+		t.Fatalf("expected nothing left to do after trimming, got %v\n%s", err, plan)
+	}
+	session, err := tmux.GetSessionByName("dev")
+	if err != nil || session == nil {
+		t.Fatalf("GetSessionByName returned %v, %v", session, err)
+	}
+	windows, err := session.ListWindows()
+	if err != nil || len(windows) != 1 || windows[0].Panes != 2 {
+		t.Fatalf("unexpected windows %+v, %v", windows, err)
+	}
+}
+
+// firstPane returns the first pane of the session's first window.
+func firstPane(t *testing.T, session *Session) *Pane {
+	t.Helper()
+	panes, err := session.ListPanes()
+	if err != nil || len(panes) == 0 {
+		t.Fatalf("ListPanes returned %v, %v", panes, err)
+	}
+	return panes[0]
+}
+
+func TestHandlesIntegration(t *testing.T) {
+	tmux := newTestTmux(t)
+	session := testSession(t, tmux)
+
+	if _, err := tmux.Command("rename-session", "-t", "gotmuxcctest", "renamed"); err != nil {
+		t.Fatalf("rename-session returned error: %v", err)
+	}
+	// The handle follows the session by ID.
+	if err := session.SetOption("@k", "v"); err != nil {
+		t.Fatalf("SetOption returned error: %v", err)
+	}
+	if err := session.Refresh(); err != nil || session.Name != "renamed" {
+		t.Fatalf("Refresh returned %q, %v", session.Name, err)
+	}
+	windows, err := session.ListWindows()
+	if err != nil || len(windows) == 0 {
+		t.Fatalf("ListWindows returned %v, %v", windows, err)
+	}
+	window := windows[0]
+	pane := firstPane(t, session)
+	if !session.Exists() || !window.Exists() || !pane.Exists() {
+		t.Fatal("expected session, window and pane to exist")
+	}
+
+	added, err := session.NewWindow(&NewWindowOptions{DoNotAttach: true})
+	if err != nil {
+		t.Fatalf("NewWindow returned error: %v", err)
+	}
+	if err := added.Kill(); err != nil {
+		t.Fatalf("Kill returned error: %v", err)
+	}
+	if added.Exists() {
+		t.Fatal("expected killed window not to exist")
+	}
+	if err := added.Refresh(); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	gone := &Pane{Id: "%99", tmux: tmux}
+	if gone.Exists() || !errors.Is(gone.Refresh(), ErrNotFound) {
+		t.Fatal("expected missing pane not to be found")
+	}
+	if got, err := tmux.GetSessionById(session.Id); err != nil || got == nil || got.Name != "renamed" {
+		t.Fatalf("GetSessionById returned %+v, %v", got, err)
+	}
+	missing := &Session{Id: "$99", tmux: tmux}
+	if missing.Exists() || !errors.Is(missing.Refresh(), ErrNotFound) {
+		t.Fatal("expected missing session not to be found")
+	}
+}
+
+func TestWindowOpsIntegration(t *testing.T) {
+	tmux := newTestTmux(t)
+	session := testSession(t, tmux)
+
+	alpha, err := session.NewWindow(&NewWindowOptions{WindowName: "alpha,}#", DoNotAttach: true})
+	if err != nil {
+		t.Fatalf("NewWindow returned error: %v", err)
+	}
+	beta, err := session.NewWindow(&NewWindowOptions{WindowName: "beta", DoNotAttach: true})
+	if err != nil {
+		t.Fatalf("NewWindow returned error: %v", err)
+	}
+	alphaIndex, betaIndex := alpha.Index, beta.Index
+	if _, err := alpha.Swap(beta, nil); err != nil || alpha.Index != betaIndex || beta.Index != alphaIndex {
+		t.Fatalf("Swap left indexes %d and %d: %v", alpha.Index, beta.Index, err)
+	}
+
+	other, err := tmux.NewSession(&SessionOptions{Name: "other"})
+	if err != nil {
+		t.Fatalf("NewSession returned error: %v", err)
+	}
+	index := 7
+	if linked, err := alpha.Link(other, &LinkWindowOptions{Index: &index, DoNotSelect: true}); err != nil || !linked.Linked {
+		t.Fatalf("Link returned %+v, %v", linked, err)
+	}
+	if _, err := alpha.Unlink(other); err != nil {
+		t.Fatalf("Unlink returned error: %v", err)
+	}
+	if _, err := beta.Unlink(session); err == nil {
+		t.Fatal("expected unlinking a window from its only session to fail")
+	}
+
+	panes, err := alpha.ListPanes()
+	if err != nil || len(panes) == 0 {
+		t.Fatalf("ListPanes returned %v, %v", panes, err)
+	}
+	if _, err := panes[0].Split(); err != nil {
+		t.Fatalf("Split returned error: %v", err)
+	}
+	if _, err := alpha.Rotate(RotateDirectionUp); err != nil {
+		t.Fatalf("Rotate returned error: %v", err)
+	}
+	layout := alpha.Layout
+	if _, err := alpha.NextLayout(); err != nil || alpha.Layout == layout {
+		t.Fatalf("NextLayout left layout %q: %v", alpha.Layout, err)
+	}
+	if _, err := alpha.PreviousLayout(); err != nil {
+		t.Fatalf("PreviousLayout returned error: %v", err)
+	}
+	if resized, err := alpha.Resize(&ResizeWindowOptions{Width: 100, Height: 30}); err != nil || resized.Width != 100 || resized.Height != 30 {
+		t.Fatalf("Resize returned %+v, %v", resized, err)
+	}
+	if _, err := alpha.Resize(&ResizeWindowOptions{Largest: true}); err != nil {
+		t.Fatalf("Resize returned error: %v", err)
+	}
+	respawn := &RespawnWindowOptions{Kill: true, ShellCommand: "sleep 100", Environment: map[string]string{"A": "1"}}
+	if _, err := alpha.Respawn(respawn); err != nil || alpha.Panes != 1 {
+		t.Fatalf("Respawn left %d panes: %v", alpha.Panes, err)
+	}
+
+	found, err := tmux.FindWindows("alpha,}#", &FindWindowOptions{Name: true})
+	if err != nil || len(found) != 1 || found[0].Id != alpha.Id {
+		t.Fatalf("FindWindows by name returned %v, %v", found, err)
+	}
+	found, err = tmux.FindWindows("^BET", &FindWindowOptions{Regex: true, IgnoreCase: true})
+	if err != nil || len(found) != 1 || found[0].Id != beta.Id {
+		t.Fatalf("FindWindows by regex returned %v, %v", found, err)
+	}
+	betaPanes, err := beta.ListPanes()
+	if err != nil || len(betaPanes) == 0 {
+		t.Fatalf("ListPanes returned %v, %v", betaPanes, err)
+	}
+	if err := betaPanes[0].SendKeys("echo needle-xyz"); err != nil {
+		t.Fatalf("SendKeys returned error: %v", err)
+	}
+	waitForCondition(t, "content match", func() (bool, error) {
+		found, err := tmux.FindWindows("needle-xyz", &FindWindowOptions{Content: true})
+		return len(found) == 1 && found[0].Id == beta.Id, err
+	})
+
+	windows, err := session.ListWindows()
+	if err != nil || len(windows) == 0 {
+		t.Fatalf("ListWindows returned %v, %v", windows, err)
+	}
+	if err := windows[0].Kill(); err != nil {
+		t.Fatalf("Kill returned error: %v", err)
+	}
+	windows, err = session.RenumberWindows()
+	if err != nil || windows[0].Index != 0 {
+		t.Fatalf("RenumberWindows returned %+v, %v", windows, err)
+	}
+}
+
+func TestLayoutIntegration(t *testing.T) {
+	tmux := newTestTmux(t)
+	session := testSession(t, tmux)
+
+	windows, err := session.ListWindows()
+	if err != nil || len(windows) == 0 {
+		t.Fatalf("ListWindows returned %v, %v", windows, err)
+	}
+	window := windows[0]
+	for i := 0; i < 5; i++ {
+		panes, err := window.ListPanes()
+		if err != nil {
+			t.Fatalf("ListPanes returned error: %v", err)
+		}
+		if _, err := panes[len(panes)-1].Split(); err != nil {
+			t.Fatalf("Split returned error: %v", err)
+		}
+		if err := window.SelectLayout(WindowLayoutTiled); err != nil {
+			t.Fatalf("SelectLayout returned error: %v", err)
+		}
+	}
+	if err := window.Refresh(); err != nil {
+		t.Fatalf("Refresh returned error: %v", err)
+	}
+	tree, err := window.LayoutTree()
+	if err != nil || len(tree.Panes()) != 6 {
+		t.Fatalf("LayoutTree returned %v, %v", tree, err)
+	}
+
+	builders := map[string]func() (*Layout, error){
+		"grid":          func() (*Layout, error) { return GridLayout(window.Width, window.Height, 3, 2) },
+		"golden":        func() (*Layout, error) { return GoldenLayout(window.Width, window.Height, 6) },
+		"sidebar":       func() (*Layout, error) { return SidebarLayout(window.Width, window.Height, 30, 6, false) },
+		"sidebar-right": func() (*Layout, error) { return SidebarLayout(window.Width, window.Height, 30, 6, true) },
+	}
+	for name, build := range builders {
+		want, err := build()
+		if err != nil {
+			t.Fatalf("%s: building layout returned error: %v", name, err)
+		}
+		if _, err := window.ApplyLayout(want); err != nil {
+			t.Fatalf("%s: ApplyLayout returned error: %v", name, err)
+		}
+		got, err := window.LayoutTree()
+		if err != nil {
+			t.Fatalf("%s: LayoutTree returned error: %v", name, err)
+		}
+		// Pane IDs differ, so compare the geometry.
+		gotPanes, wantPanes := got.Panes(), want.Panes()
+		if len(gotPanes) != len(wantPanes) {
+			t.Fatalf("%s: expected %d panes, got %d", name, len(wantPanes), len(gotPanes))
+		}
+		for i := range gotPanes {
+			a, b := gotPanes[i], wantPanes[i]
+			if a.Width != b.Width || a.Height != b.Height || a.X != b.X || a.Y != b.Y {
+				t.Fatalf("%s: layout mismatch\n got %s\nwant %s", name, got, want)
+			}
+		}
+	}
+	for _, preset := range []WindowLayout{WindowLayoutMainVertical, WindowLayoutMainHorizontal} {
+		if err := window.SelectLayout(preset); err != nil {
+			t.Fatalf("SelectLayout(%v) returned error: %v", preset, err)
+		}
+	}
+}
+
+func TestNewWindowOptionsIntegration(t *testing.T) {
+	tmux := newTestTmux(t)
+	session := testSession(t, tmux)
+
+	index := 5
+	window, err := session.NewWindow(&NewWindowOptions{
+		WindowName:   "a b",
+		Index:        &index,
+		DoNotAttach:  true,
+		Environment:  map[string]string{"V": "x y"},
+		ShellCommand: "sh",
+		Arguments:    []string{"-c", `echo "$V"; sleep 100`},
+	})
+	if err != nil || window.Index != 5 || window.Name != "a b" {
+		t.Fatalf("NewWindow returned %+v, %v", window, err)
+	}
+	panes, err := window.ListPanes()
+	if err != nil || len(panes) == 0 {
+		t.Fatalf("ListPanes returned %v, %v", panes, err)
+	}
+	waitForCondition(t, "environment echo", func() (bool, error) {
+		out, err := panes[0].Capture()
+		return strings.Contains(out, "x y"), err
+	})
+
+	existing, err := session.NewWindow(&NewWindowOptions{WindowName: "a b", SelectExisting: true})
+	if err != nil || existing.Id != window.Id {
+		t.Fatalf("SelectExisting returned %+v, %v", existing, err)
+	}
+	replaced, err := session.NewWindow(&NewWindowOptions{Index: &index, KillExisting: true, DoNotAttach: true})
+	if err != nil || replaced.Index != 5 || replaced.Id == window.Id {
+		t.Fatalf("KillExisting returned %+v, %v", replaced, err)
+	}
+}
+
+func TestPaneStructureIntegration(t *testing.T) {
+	tmux := newTestTmux(t)
+	session := testSession(t, tmux)
+
+	windows, err := session.ListWindows()
+	if err != nil || len(windows) == 0 {
+		t.Fatalf("ListWindows returned %v, %v", windows, err)
+	}
+	pane := firstPane(t, session)
+	split, err := pane.SplitWindow(&SplitWindowOptions{SplitDirection: PaneSplitDirectionHorizontal, Percentage: 30, Before: true})
+	if err != nil || split.Id == pane.Id {
+		t.Fatalf("SplitWindow returned %+v, %v", split, err)
+	}
+	broken, err := split.BreakPane(&BreakPaneOptions{WindowName: "broken", DoNotSelect: true})
+	if err != nil || broken.Name != "broken" {
+		t.Fatalf("BreakPane returned %+v, %v", broken, err)
+	}
+	split, err = split.JoinPane(windows[0], &JoinPaneOptions{SplitDirection: PaneSplitDirectionVertical, Size: 10})
+	if err != nil || split.Height != 10 {
+		t.Fatalf("JoinPane returned %+v, %v", split, err)
+	}
+	if _, err := pane.SwapPane(split, nil); err != nil {
+		t.Fatalf("SwapPane returned error: %v", err)
+	}
+	if resized, err := split.ResizePane(&ResizePaneOptions{Height: 15}); err != nil || resized.Height != 15 {
+		t.Fatalf("ResizePane returned %+v, %v", resized, err)
+	}
+	if _, err := split.ResizePane(&ResizePaneOptions{Zoom: true}); err != nil {
+		t.Fatalf("ResizePane zoom returned error: %v", err)
+	}
+	if _, err := split.RespawnPane(&RespawnPaneOptions{Kill: true, ShellCommand: "sleep 100"}); err != nil {
+		t.Fatalf("RespawnPane returned error: %v", err)
+	}
+}
+
+func TestCaptureIntegration(t *testing.T) {
+	tmux := newTestTmux(t)
+	session := testSession(t, tmux)
+
+	if _, err := tmux.Command("set-option", "-g", "history-limit", "100000"); err != nil {
+		t.Fatalf("set-option returned error: %v", err)
+	}
+	// Lines that look like control-mode replies must not confuse the parser.
+	window, err := session.NewWindow(&NewWindowOptions{ShellCommand: "seq -f '%%end 1 %g 1' 1 60000; sleep 100"})
+	if err != nil {
+		t.Fatalf("NewWindow returned error: %v", err)
+	}
+	panes, err := window.ListPanes()
+	if err != nil || len(panes) == 0 {
+		t.Fatalf("ListPanes returned %v, %v", panes, err)
+	}
+	pane := panes[0]
+	waitForCondition(t, "output to finish", func() (bool, error) {
+		out, err := pane.Capture()
+		return strings.Contains(out, "%end 1 60000 1"), err
+	})
+
+	start := -50000
+	var streamed []CaptureLine
+	err = pane.StreamCapture(&CaptureOptions{Start: &start}, func(line CaptureLine) error {
+		streamed = append(streamed, line)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamCapture returned error: %v", err)
+	}
+	all, err := pane.CaptureLines(&CaptureOptions{FromHistoryStart: true, End: &start})
+	if err != nil {
+		t.Fatalf("CaptureLines returned error: %v", err)
+	}
+	for _, lines := range [][]CaptureLine{streamed, all} {
+		if len(lines) == 0 {
+			t.Fatal("expected captured lines")
+		}
+		for i, line := range lines {
+			if i > 0 && line.Number != lines[i-1].Number+1 {
+				t.Fatalf("line numbers jump from %d to %d", lines[i-1].Number, line.Number)
+			}
+			if want := fmt.Sprintf("%%end 1 %d 1", line.Number+1); line.Number < 60000 && line.Text != want {
+				t.Fatalf("line %d: expected %q, got %q", line.Number, want, line.Text)
+			}
+		}
+	}
+	if got := len(streamed); got < 50000 {
+		t.Fatalf("expected at least 50000 streamed lines, got %d", got)
+	}
+	// Start and End are inclusive, so both captures hold line -50000.
+	if last := all[len(all)-1]; streamed[0] != last {
+		t.Fatalf("expected the captures to meet, got %+v and %+v", last, streamed[0])
+	}
+
+	if _, err := pane.CapturePane(&CaptureOptions{AlternateScreen: true}); err == nil {
+		t.Fatal("expected capturing a missing alternate screen to fail")
+	}
+	if out, err := pane.CapturePane(&CaptureOptions{AlternateScreen: true, Quiet: true}); err != nil || out != "" {
+		t.Fatalf("quiet alternate capture returned %q, %v", out, err)
+	}
+	if _, err := pane.CapturePane(&CaptureOptions{Buffer: "cap", Start: &start}); err != nil {
+		t.Fatalf("CapturePane into a buffer returned error: %v", err)
+	}
+	if text, err := tmux.ShowBuffer("cap"); err != nil || !strings.Contains(text, "%end 1 60000 1") {
+		t.Fatalf("ShowBuffer returned %d bytes, %v", len(text), err)
+	}
+}
+
+func TestCaptureScreenIntegration(t *testing.T) {
+	tmux := newTestTmux(t)
+	session := testSession(t, tmux)
+
+	window, err := session.NewWindow(&NewWindowOptions{ShellCommand: `printf '\033[1;38;2;9;8;7m日x\033[0m\n'; sleep 100`})
+	if err != nil {
+		t.Fatalf("NewWindow returned error: %v", err)
+	}
+	panes, err := window.ListPanes()
+	if err != nil || len(panes) == 0 {
+		t.Fatalf("ListPanes returned %v, %v", panes, err)
+	}
+	var screen *Screen
+	waitForCondition(t, "styled output", func() (bool, error) {
+		var err error
+		screen, err = panes[0].CaptureScreen()
+		return err == nil && screen.Cell(0, 0).Rune == '日', err
+	})
+	wide, next := screen.Cell(0, 0), screen.Cell(2, 0)
+	if wide.Width != 2 || next.Rune != 'x' {
+		t.Fatalf("unexpected cells %+v %+v", *wide, *next)
+	}
+	want := Style{Fg: Color{Type: ColorRGB, R: 9, G: 8, B: 7}, Attributes: AttrBold}
+	if wide.Style != want || next.Style != want {
+		t.Fatalf("unexpected styles %+v %+v", wide.Style, next.Style)
+	}
+}
+
+// terminalDiff compares a Terminal with a fresh capture of its pane and
+// returns the first difference, or "" if they match.
+func terminalDiff(term *Terminal, pane *Pane) (string, error) {
+	capture, err := pane.CapturePane(&CaptureOptions{EscTxtNBgAttr: true})
+	if err != nil {
+		return "", err
+	}
+	state, _, err := pane.terminalState()
+	if err != nil {
+		return "", err
+	}
+	want, got := ParseScreen(capture), term.Screen()
+	gotLines, wantLines := strings.Split(got.String(), "\n"), strings.Split(want.String(), "\n")
+	for i := 0; i < len(gotLines) || i < len(wantLines); i++ {
+		var a, b string
+		if i < len(gotLines) {
+			// A capture drops trailing spaces, even styled ones.
+			a = strings.TrimRight(gotLines[i], " ")
+		}
+		if i < len(wantLines) {
+			b = wantLines[i]
+		}
+		if a != b {
+			return fmt.Sprintf("row %d:\n got %q\nwant %q", i, a, b), nil
+		}
+	}
+	for y := range want.Rows {
+		for x := range want.Rows[y] {
+			if y < len(got.Rows) && x < len(got.Rows[y]) && got.Rows[y][x].Style != want.Rows[y][x].Style {
+				return fmt.Sprintf("style at %d,%d: got %+v, want %+v", x, y, got.Rows[y][x].Style, want.Rows[y][x].Style), nil
+			}
+		}
+	}
+	x, y := term.Cursor()
+	if fmt.Sprint(x) != state.get(varCursorX) || fmt.Sprint(y) != state.get(varCursorY) {
+		return fmt.Sprintf("cursor at %d,%d, want %s,%s", x, y, state.get(varCursorX), state.get(varCursorY)), nil
+	}
+	if term.AlternateScreen() != isOne(state.get(varAlternateOn)) {
+		return fmt.Sprintf("alternate screen %v, want %s", term.AlternateScreen(), state.get(varAlternateOn)), nil
+	}
+	return "", nil
+}
+
+func TestTerminalIntegration(t *testing.T) {
+	tmux := newTestTmux(t)
+	session := testSession(t, tmux)
+
+	// Output is only reported for the attached session's panes.
+	window, err := session.NewWindow(&NewWindowOptions{ShellCommand: "bash --norc --noprofile"})
+	if err != nil {
+		t.Fatalf("NewWindow returned error: %v", err)
+	}
+	panes, err := window.ListPanes()
+	if err != nil || len(panes) == 0 {
+		t.Fatalf("ListPanes returned %v, %v", panes, err)
+	}
+	pane := panes[0]
+	send := func(keys ...string) {
+		t.Helper()
+		if _, err := tmux.Command(append([]string{"send-keys", "-t", pane.Id}, keys...)...); err != nil {
+			t.Fatalf("send-keys returned error: %v", err)
+		}
+	}
+	send(`seq 1 100; printf '\033[31mred\033[0m 日本 e\xcc\x81 tab\tx\n'`, "Enter")
+	time.Sleep(300 * time.Millisecond)
+
+	term, err := pane.Terminal()
+	if err != nil {
+		t.Fatalf("Terminal returned error: %v", err)
+	}
+	defer term.Close()
+	compare := func(label string) {
+		t.Helper()
+		var diff string
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+			time.Sleep(200 * time.Millisecond)
+			if diff, err = terminalDiff(term, pane); err != nil {
+				t.Fatalf("%s: %v", label, err)
+			}
+			if diff == "" {
+				return
+			}
+		}
+		t.Errorf("%s: %s", label, diff)
+	}
+
+	compare("seed")
+	send(`for i in $(seq 1 30); do printf '\033[1;4%dmline %d\033[0m wide 日本語テキスト\n' $((i%8)) $i; done`, "Enter")
+	compare("scroll")
+	send(`printf '\033[?1049h\033[2J\033[5;10r\033[5;1Hregion\n1\n2\n3\n4\n5\n6\n7\033[1;1H\033[2Ltop\033[3;3H\033[4@ins\033[2P'`, "Enter")
+	compare("alternate screen")
+	send(`printf '\033[r\033[?1049l'`, "Enter")
+	compare("alternate screen off")
+	send(`clear; printf 'abc\033[1;2H\033[Kdef\033[3;5Hghi\033[1J\033[s\033[10;10Hx\033[uy\033[3X'`, "Enter")
+	compare("erase")
+	if _, err := window.Resize(&ResizeWindowOptions{Width: 100, Height: 30}); err != nil {
+		t.Fatalf("Resize returned error: %v", err)
+	}
+	compare("resize")
+	send(`printf '%0200d\n' 0`, "Enter")
+	compare("wrap")
+	send(`for i in $(seq 1 400); do printf '\033[3%dm%s\033[0m\n' $((i%8)) $i; sleep 0.005; done`, "Enter")
+	time.Sleep(100 * time.Millisecond)
+	// Resyncing while output races the seed either succeeds or keeps the
+	// previous screen; either way the mirror catches up.
+	_ = term.Resync()
+	compare("busy")
+
+	if _, err := exec.LookPath("vim"); err == nil {
+		send("TERM=xterm-256color vim -u NONE -c 'syntax on' terminal.go", "Enter")
+		compare("vim")
+		send("50%")
+		compare("vim jump")
+		send("o", "ab日本cd", "Escape", "0", "i", "xy", "Escape")
+		compare("vim edit")
+		send(":q!", "Enter")
+		compare("vim quit")
+	}
+	if _, err := exec.LookPath("less"); err == nil {
+		send("TERM=xterm-256color less terminal.go", "Enter")
+		compare("less")
+		send("G")
+		compare("less end")
+		send("q")
+		compare("less quit")
+	}
+}
+
+func TestSendKeysIntegration(t *testing.T) {
+	tmux := newTestTmux(t)
+	session := testSession(t, tmux)
+
+	pane := firstPane(t, session)
+	if _, err := pane.RespawnPane(&RespawnPaneOptions{Kill: true, ShellCommand: "cat -v"}); err != nil {
+		t.Fatalf("RespawnPane returned error: %v", err)
+	}
+	keys := NewKeys().
+		Text("Enter; 'x'").Key(KeyEnter).
+		Hex(0x41, 0x42).Key("C-a", "M-b", KeyEnter).
+		Text("z").Repeat(3).Key(KeyEnter)
+	if err := pane.Send(keys); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	waitForCondition(t, "keys to arrive", func() (bool, error) {
+		out, err := pane.Capture()
+		return strings.Contains(out, "Enter; 'x'\n") && strings.Contains(out, "AB^A^[b\n") && strings.Contains(out, "zzz\n"), err
+	})
+
+	// Command-line flags are the usual literal text.
+	if err := pane.Send(NewKeys().Text("--version").Key(KeyEnter).Text("-x").Key("-", KeyEnter)); err != nil {
+		t.Fatalf("Send of dash-leading text returned error: %v", err)
+	}
+	waitForCondition(t, "dash-leading text to arrive", func() (bool, error) {
+		out, err := pane.Capture()
+		return strings.Contains(out, "--version\n") && strings.Contains(out, "-x-\n"), err
+	})
+
+	if err := pane.CopyMode().Enter(); err != nil {
+		t.Fatalf("Enter returned error: %v", err)
+	}
+	if err := pane.Send(NewKeys().CopyMode("cursor-up").Repeat(2)); err != nil {
+		t.Fatalf("Send of a copy-mode command returned error: %v", err)
+	}
+	if err := pane.Send(NewKeys().Reset()); err != nil {
+		t.Fatalf("Send of a reset returned error: %v", err)
+	}
+}
+
+func TestCopyModeIntegration(t *testing.T) {
+	tmux := newTestTmux(t)
+	session := testSession(t, tmux)
+
+	pane := firstPane(t, session)
+	command := `seq 1 100; printf 'error: \tboom \\x\n'; seq 101 110; cat`
+	if _, err := pane.RespawnPane(&RespawnPaneOptions{Kill: true, ShellCommand: command}); err != nil {
+		t.Fatalf("RespawnPane returned error: %v", err)
+	}
+	waitForCondition(t, "output", func() (bool, error) {
+		out, err := pane.Capture()
+		return strings.Contains(out, "110"), err
+	})
+
+	copyMode := pane.CopyMode()
+	if err := copyMode.Enter(); err != nil {
+		t.Fatalf("Enter returned error: %v", err)
+	}
+	found, err := copyMode.SearchBackward("^error: .*")
+	if err != nil || !found {
+		t.Fatalf("SearchBackward returned %v, %v", found, err)
+	}
+	state, err := copyMode.State()
+	if err != nil || state.Line() != 100 {
+		t.Fatalf("expected the cursor on line 100, got %+v, %v", state, err)
+	}
+	if found, err := copyMode.SearchBackward("zzz"); err != nil || found {
+		t.Fatalf("SearchBackward for a missing match returned %v, %v", found, err)
+	}
+
+	if err := copyMode.BeginSelection(); err != nil {
+		t.Fatalf("BeginSelection returned error: %v", err)
+	}
+	if err := copyMode.ExtendSelection("end-of-line", 1); err != nil {
+		t.Fatalf("ExtendSelection returned error: %v", err)
+	}
+	if text, err := copyMode.SelectedText(); err != nil || text != `error:  boom \x` {
+		t.Fatalf("SelectedText returned %q, %v", text, err)
+	}
+	if err := copyMode.CopyToBuffer("errs"); err != nil {
+		t.Fatalf("CopyToBuffer returned error: %v", err)
+	}
+	if text, err := tmux.ShowBuffer("errs"); err != nil || text != `error:  boom \x` {
+		t.Fatalf("ShowBuffer returned %q, %v", text, err)
+	}
+	if err := copyMode.ClearSelection(); err != nil {
+		t.Fatalf("ClearSelection returned error: %v", err)
+	}
+	if text, err := copyMode.SelectedText(); text != "" || err != nil {
+		t.Fatalf("expected no selection, got %q, %v", text, err)
+	}
+
+	if err := copyMode.GotoLine(5); err != nil {
+		t.Fatalf("GotoLine returned error: %v", err)
+	}
+	state, err = copyMode.State()
+	if err != nil || state.Line() != 5 || state.CursorY != 0 || !strings.HasPrefix(state.CursorLine, "6 ") {
+		t.Fatalf("unexpected state after GotoLine(5): %+v, %v", state, err)
+	}
+	if err := copyMode.GotoLine(state.HistorySize + 3); err != nil {
+		t.Fatalf("GotoLine returned error: %v", err)
+	}
+	state, err = copyMode.State()
+	if err != nil || state.Line() != state.HistorySize+3 || state.CursorY != 3 {
+		t.Fatalf("unexpected state after GotoLine on screen: %+v, %v", state, err)
+	}
+
+	if err := copyMode.Exit(); err != nil {
+		t.Fatalf("Exit returned error: %v", err)
+	}
+	if state, err := copyMode.State(); err != nil || state.Active {
+		t.Fatalf("expected copy mode to be off, got %+v, %v", state, err)
+	}
+	if err := copyMode.Exit(); err != nil {
+		t.Fatalf("a second Exit returned error: %v", err)
+	}
+	if err := copyMode.BeginSelection(); err == nil {
+		t.Fatal("expected BeginSelection outside copy mode to fail")
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe to write from a pipe's goroutine.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestPipeIntegration(t *testing.T) {
+	tmux := newTestTmux(t)
+	session := testSession(t, tmux)
+	before := len(leftoverDirs(t))
+
+	pane := firstPane(t, session)
+	if _, err := pane.RespawnPane(&RespawnPaneOptions{Kill: true, ShellCommand: "cat"}); err != nil {
+		t.Fatalf("RespawnPane returned error: %v", err)
+	}
+	piped := func() bool {
+		t.Helper()
+		on, err := pane.piped()
+		if err != nil {
+			t.Fatalf("piped returned error: %v", err)
+		}
+		return on
+	}
+
+	// Output only, until cancelled.
+	ctx, cancel := context.WithCancel(context.Background())
+	var out syncBuffer
+	done := make(chan error, 1)
+	go func() { done <- pane.PipeTo(ctx, &out, nil) }()
+	waitForCondition(t, "pipe to open", func() (bool, error) { return pane.piped() })
+	if err := pane.SendKeys("hello-pipe\n"); err != nil {
+		t.Fatalf("SendKeys returned error: %v", err)
+	}
+	waitForCondition(t, "piped output", func() (bool, error) {
+		return strings.Contains(out.String(), "hello-pipe"), nil
+	})
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Pipe returned error: %v", err)
+	}
+	if piped() {
+		t.Fatal("expected the pipe to be closed")
+	}
+
+	// Input only: typed into the pane, ending by itself.
+	if err := pane.PipeTo(context.Background(), nil, &PipeOptions{Input: strings.NewReader("typed-in\n")}); err != nil {
+		t.Fatalf("Pipe with input returned error: %v", err)
+	}
+	waitForCondition(t, "typed input", func() (bool, error) {
+		text, err := pane.Capture()
+		return strings.Contains(text, "typed-in"), err
+	})
+
+	// Both directions.
+	ctx, cancel = context.WithCancel(context.Background())
+	var both syncBuffer
+	go func() { done <- pane.PipeTo(ctx, &both, &PipeOptions{Input: strings.NewReader("both-ways\n")}) }()
+	waitForCondition(t, "echoed input", func() (bool, error) {
+		return strings.Contains(both.String(), "both-ways"), nil
+	})
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Pipe in both directions returned error: %v", err)
+	}
+
+	// The pane exiting ends the pipe. A second window keeps the session.
+	if _, err := session.NewWindow(&NewWindowOptions{DoNotAttach: true}); err != nil {
+		t.Fatalf("NewWindow returned error: %v", err)
+	}
+	var ended syncBuffer
+	go func() { done <- pane.PipeTo(context.Background(), &ended, nil) }()
+	waitForCondition(t, "pipe to open", func() (bool, error) { return pane.piped() })
+	if err := pane.Send(NewKeys().Key("C-d")); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("pipe did not end with the pane")
+	}
+
+	// Toggling closes a pipe someone else opened.
+	pane = firstPane(t, session)
+	if _, err := tmux.Command("pipe-pane", "-t", pane.Id, "cat > /dev/null"); err != nil {
+		t.Fatalf("pipe-pane returned error: %v", err)
+	}
+	if err := pane.PipeTo(context.Background(), &ended, &PipeOptions{Toggle: true}); err != nil {
+		t.Fatalf("Pipe toggle returned error: %v", err)
+	}
+	if on, err := pane.piped(); err != nil || on {
+		t.Fatalf("expected toggle to close the pipe, got %v, %v", on, err)
+	}
+
+	// An already cancelled context returns at once.
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if err := pane.PipeTo(ctx, &ended, nil); err != nil {
+		t.Fatalf("Pipe with a cancelled context returned error: %v", err)
+	}
+	if after := leftoverDirs(t); len(after) != before {
+		t.Fatalf("temporary directories left behind: %v", after)
+	}
+}
+
+func TestPaneMarksIntegration(t *testing.T) {
+	tmux := newTestTmux(t)
+	session := testSession(t, tmux)
+
+	pane := firstPane(t, session)
+	other, err := pane.Split()
+	if err != nil {
+		t.Fatalf("Split returned error: %v", err)
+	}
+	if marked, err := tmux.MarkedPane(); marked != nil || err != nil {
+		t.Fatalf("expected no marked pane, got %+v, %v", marked, err)
+	}
+	title := "build #1 {x}; 'y'"
+	if _, err := pane.SetTitle(title); err != nil || pane.Title != title {
+		t.Fatalf("SetTitle left %q: %v", pane.Title, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		// Marking a marked pane keeps the mark.
+		if _, err := pane.Mark(); err != nil || !pane.Marked {
+			t.Fatalf("Mark returned error: %v", err)
+		}
+	}
+	if marked, err := tmux.MarkedPane(); err != nil || marked == nil || marked.Id != pane.Id {
+		t.Fatalf("MarkedPane returned %+v, %v", marked, err)
+	}
+	if _, err := other.Mark(); err != nil || !other.Marked {
+		t.Fatalf("Mark returned error: %v", err)
+	}
+	if err := pane.Refresh(); err != nil || pane.Marked {
+		t.Fatalf("expected the mark to move, got %v, %v", pane.Marked, err)
+	}
+	if _, err := pane.Unmark(); err != nil {
+		t.Fatalf("Unmark returned error: %v", err)
+	}
+	if marked, err := tmux.MarkedPane(); err != nil || marked == nil || marked.Id != other.Id {
+		t.Fatalf("unmarking an unmarked pane cleared the mark: %+v, %v", marked, err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := tmux.ClearMark(); err != nil {
+			t.Fatalf("ClearMark returned error: %v", err)
+		}
+	}
+	if marked, err := tmux.MarkedPane(); marked != nil || err != nil {
+		t.Fatalf("expected the mark to be cleared, got %+v, %v", marked, err)
+	}
+
+	if _, err := pane.DisableInput(); err != nil || !pane.InputOff {
+		t.Fatalf("DisableInput returned error: %v", err)
+	}
+	if _, err := pane.EnableInput(); err != nil || pane.InputOff {
+		t.Fatalf("EnableInput returned error: %v", err)
+	}
+
+	windows, err := session.ListWindows()
+	if err != nil || len(windows) == 0 {
+		t.Fatalf("ListWindows returned %v, %v", windows, err)
+	}
+	if _, err := windows[0].SetSynchronized(true); err != nil {
+		t.Fatalf("SetSynchronized returned error: %v", err)
+	}
+	if pane.Refresh() != nil || other.Refresh() != nil || !pane.Synchronized || !other.Synchronized {
+		t.Fatal("expected both panes to be synchronized")
+	}
+	if _, err := windows[0].SetSynchronized(false); err != nil {
+		t.Fatalf("SetSynchronized returned error: %v", err)
+	}
+	if err := pane.Refresh(); err != nil || pane.Synchronized {
+		t.Fatalf("expected synchronization off, got %v, %v", pane.Synchronized, err)
+	}
+}
+
+func TestBuffersIntegration(t *testing.T) {
+	tmux := newTestTmux(t)
+	session := testSession(t, tmux)
+
+	if err := tmux.SetBuffer("b1", "one\n", nil); err != nil {
+		t.Fatalf("SetBuffer returned error: %v", err)
+	}
+	if err := tmux.SetBuffer("b1", "two\x01", &SetBufferOptions{Append: true}); err != nil {
+		t.Fatalf("SetBuffer append returned error: %v", err)
+	}
+	if err := tmux.SetBuffer("b1", "", &SetBufferOptions{NewName: "b2"}); err != nil {
+		t.Fatalf("SetBuffer rename returned error: %v", err)
+	}
+	if err := tmux.LoadBuffer("b3", strings.NewReader("loaded\n\n")); err != nil {
+		t.Fatalf("LoadBuffer returned error: %v", err)
+	}
+	var saved bytes.Buffer
+	if err := tmux.SaveBuffer("b2", &saved); err != nil || saved.String() != "one\ntwo\x01" {
+		t.Fatalf("SaveBuffer returned %q, %v", saved.String(), err)
+	}
+	if text, err := tmux.ShowBuffer("b3"); err != nil || text != "loaded\n\n" {
+		t.Fatalf("ShowBuffer returned %q, %v", text, err)
+	}
+	buffers, err := tmux.ListBuffers()
+	if err != nil || len(buffers) != 2 {
+		t.Fatalf("ListBuffers returned %v, %v", buffers, err)
+	}
+	if buffers[0].Name != "b3" || buffers[1].Name != "b2" || buffers[1].Size != 8 || buffers[1].CreatedTime.IsZero() {
+		t.Fatalf("unexpected buffers %+v %+v", *buffers[0], *buffers[1])
+	}
+
+	window, err := session.NewWindow(&NewWindowOptions{ShellCommand: "cat -v"})
+	if err != nil {
+		t.Fatalf("NewWindow returned error: %v", err)
+	}
+	panes, err := window.ListPanes()
+	if err != nil || len(panes) == 0 {
+		t.Fatalf("ListPanes returned %v, %v", panes, err)
+	}
+	pane := panes[0]
+	if err := tmux.SetBuffer("p", "a\nb", nil); err != nil {
+		t.Fatalf("SetBuffer returned error: %v", err)
+	}
+	if err := pane.PasteBuffer(&PasteBufferOptions{Buffer: "p", Separator: "|", Delete: true}); err != nil {
+		t.Fatalf("PasteBuffer returned error: %v", err)
+	}
+	waitForCondition(t, "pasted text", func() (bool, error) {
+		out, err := pane.Capture()
+		return strings.Contains(out, "a|b"), err
+	})
+	if _, err := tmux.ShowBuffer("p"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected the pasted buffer to be deleted, got %v", err)
+	}
+	if err := pane.PasteBuffer(&PasteBufferOptions{Buffer: "p"}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound pasting a missing buffer, got %v", err)
+	}
+
+	if err := tmux.DeleteBuffer("b2"); err != nil {
+		t.Fatalf("DeleteBuffer returned error: %v", err)
+	}
+	if err := tmux.DeleteBuffer("b2"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound deleting twice, got %v", err)
+	}
+	if err := tmux.SaveBuffer("b2", &saved); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound saving a missing buffer, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := tmux.WatchBuffers(ctx, func(BufferEvent) {}); err != nil {
+		t.Fatalf("WatchBuffers returned error: %v", err)
+	}
+}
+
+func TestScopedOptionsIntegration(t *testing.T) {
+	tmux := newTestTmux(t)
+	pane := firstPane(t, testSession(t, tmux))
+
+	schema, err := tmux.OptionSchema()
+	if err != nil {
+		t.Fatalf("OptionSchema returned error: %v", err)
+	}
+	for key, want := range map[string]OptionType{
+		"status-style":         OptionTypeStyle,
+		"history-limit":        OptionTypeNumber,
+		"mouse":                OptionTypeFlag,
+		"update-environment":   OptionTypeArray,
+		"display-panes-colour": OptionTypeColour,
+		"default-shell":        OptionTypeString,
+	} {
+		if spec, ok := schema.Options[key]; !ok || spec.Type != want {
+			t.Errorf("%s: expected type %v, got %+v", key, want, spec)
+		}
+	}
+
+	global := tmux.OptionsAt(GlobalSessionScope(), schema)
+	if err := global.Set("history-limit", "5000", nil); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	if n, err := tmux.OptionsAt(SessionScope("gotmuxcctest"), nil).Int("history-limit"); err != nil || n != 5000 {
+		t.Fatalf("Int returned %d, %v", n, err)
+	}
+	for _, bad := range [][2]string{{"nosuch", "1"}, {"status-style[1]", "x"}} {
+		var invalid *InvalidOptionsError
+		if err := global.Set(bad[0], bad[1], nil); !errors.As(err, &invalid) {
+			t.Errorf("Set(%q, %q): expected InvalidOptionsError, got %v", bad[0], bad[1], err)
+		}
+	}
+	// Inferred types are advisory, so tmux is left to refuse these.
+	for _, bad := range [][2]string{{"history-limit", "abc"}, {"mouse", "maybe"}} {
+		var invalid *InvalidOptionsError
+		if err := global.Set(bad[0], bad[1], nil); err == nil || errors.As(err, &invalid) {
+			t.Errorf("Set(%q, %q): expected tmux to refuse it, got %v", bad[0], bad[1], err)
+		}
+	}
+	if err := tmux.OptionsAt(ServerScope(), schema).Set("status", "on", nil); err == nil {
+		t.Fatal("expected a session option to be rejected at server scope")
+	}
+	if err := global.Set("status", "2", nil); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	if err := global.Set("status-style", ",bold", &SetOptionOptions{Append: true}); err != nil {
+		t.Fatalf("Set append returned error: %v", err)
+	}
+	if style, err := global.Style("status-style"); err != nil || len(style.Attributes) == 0 || style.Attributes[len(style.Attributes)-1] != "bold" {
+		t.Fatalf("Style returned %+v, %v", style, err)
+	}
+	if err := global.Set("@o", "1", &SetOptionOptions{OnlyIfUnset: true}); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	if err := global.Set("@o", "2", &SetOptionOptions{OnlyIfUnset: true}); err == nil {
+		t.Fatal("expected OnlyIfUnset to refuse a set option")
+	}
+	if env, err := global.Strings("update-environment"); err != nil || len(env) == 0 {
+		t.Fatalf("Strings returned %v, %v", env, err)
+	}
+
+	scoped := tmux.OptionsAt(PaneScope(pane.Id), schema)
+	if err := scoped.Set("remain-on-exit", "failed", nil); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	if option, err := scoped.Get("remain-on-exit", nil); err != nil || option.Value != "failed" {
+		t.Fatalf("Get returned %+v, %v", option, err)
+	}
+	if on, err := scoped.Bool("synchronize-panes"); err != nil || on {
+		t.Fatalf("Bool returned %v, %v", on, err)
+	}
+	if _, err := tmux.OptionsAt(GlobalWindowScope(), nil).Colour("display-panes-colour"); err != nil {
+		t.Fatalf("Colour returned error: %v", err)
+	}
+	if _, err := scoped.Get("@missing", nil); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := scoped.Get("window-style", nil); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for a built-in option unset in the pane, got %v", err)
+	}
+	if err := scoped.Set("@empty", "", nil); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	if option, err := scoped.Get("@empty", nil); err != nil || option.Value != "" {
+		t.Fatalf("Get of an empty value returned %+v, %v", option, err)
+	}
+	if list, err := scoped.List(&ShowOptionsOptions{Inherited: true}); err != nil || len(list) == 0 {
+		t.Fatalf("List returned %d options, %v", len(list), err)
+	}
+	if err := scoped.Unset("remain-on-exit"); err != nil {
+		t.Fatalf("Unset returned error: %v", err)
+	}
+	if err := tmux.OptionsAt(Scope{Level: ScopeWindow}, nil).Set("x", "y", nil); err == nil {
+		t.Fatal("expected a window scope without a target to fail")
+	}
+}
+
+func TestOptionParsingIntegration(t *testing.T) {
+	tmux := newTestTmux(t)
+	testSession(t, tmux)
+
+	values := []string{
+		"plain", "two words", "it's", `a"b`, "x$y", "#{foo}", "~home", "~", ";", `a\b`,
+		"tab\there", "nl\nx", "é", "", " lead", `say "hi" $x`, `q'"`, "`cmd`", "a%b", "{}",
+		`\`, `"`, "'", "'x'", `"x"`, "\x01\x7f",
+	}
+	global := tmux.OptionsAt(GlobalSessionScope(), nil)
+	for i, value := range values {
+		if err := global.Set(fmt.Sprintf("@v%02d", i), value, nil); err != nil {
+			t.Fatalf("Set(%q) returned error: %v", value, err)
+		}
+	}
+	if err := global.Set("update-environment[12]", "GAP", nil); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+
+	options, err := tmux.Options("gotmuxcctest", "-g")
+	if err != nil {
+		t.Fatalf("Options returned error: %v", err)
+	}
+	byKey := map[string]*Option{}
+	for _, option := range options {
+		byKey[option.Key] = option
+	}
+	for i, value := range values {
+		if option := byKey[fmt.Sprintf("@v%02d", i)]; option == nil || option.Value != value {
+			t.Errorf("%q: got %+v", value, option)
+		}
+	}
+	env := byKey["update-environment"]
+	if env == nil || len(env.Values) != 13 || env.Values[12] != "GAP" || env.Values[11] != "" {
+		t.Fatalf("expected update-environment as an array with a gap, got %+v", env)
+	}
+
+	session := tmux.OptionsAt(SessionScope("gotmuxcctest"), nil)
+	if err := session.Set("@mine", "1", nil); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	list, err := session.List(&ShowOptionsOptions{Inherited: true})
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	inherited := map[string]bool{}
+	for _, option := range list {
+		inherited[option.Key] = option.Inherited
+	}
+	if inherited["@mine"] || !inherited["status"] || !inherited["update-environment"] {
+		t.Fatalf("unexpected inheritance %v", inherited)
+	}
+}
+
+func TestMetadataIntegration(t *testing.T) {
+	tmux := newTestTmux(t)
+	session := testSession(t, tmux)
+
+	first := firstPane(t, session)
+	second, err := first.SplitWindow(nil)
+	if err != nil {
+		t.Fatalf("SplitWindow returned error: %v", err)
+	}
+	type owner struct {
+		Name string `json:"name"`
+		Tags []string
+	}
+	// Values that need escaping in formats and tmux commands.
+	boss := owner{"a,b}#{x}", []string{`q'"`, "$y"}}
+	if err := first.Meta().Set("role", "db"); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	if err := first.Meta().Set("owner", boss); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	if err := second.Meta().Set("role", "web"); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	if err := second.Meta().Namespace("other").Set("role", "db"); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+
+	var got owner
+	if err := first.Meta().Get("owner", &got); err != nil || got.Name != boss.Name || got.Tags[0] != boss.Tags[0] {
+		t.Fatalf("Get returned %+v, %v", got, err)
+	}
+	if all, err := first.Meta().GetAll(); err != nil || len(all) != 2 || string(all["role"]) != `"db"` {
+		t.Fatalf("GetAll returned %v, %v", all, err)
+	}
+	if found, err := tmux.FindPanesByMeta("role", "db"); err != nil || len(found) != 1 || found[0].Id != first.Id {
+		t.Fatalf("FindPanesByMeta returned %v, %v", found, err)
+	}
+	if found, err := tmux.FindPanesByMeta("owner", boss); err != nil || len(found) != 1 {
+		t.Fatalf("FindPanesByMeta with a struct returned %v, %v", found, err)
+	}
+	if found, err := tmux.FindPanesByMetaNamespace("other", "role", "db"); err != nil || len(found) != 1 || found[0].Id != second.Id {
+		t.Fatalf("FindPanesByMetaNamespace returned %v, %v", found, err)
+	}
+	if err := second.Meta().Get("missing", &got); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := first.Meta().Delete("role"); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if err := first.Meta().Get("role", new(string)); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound after Delete, got %v", err)
+	}
+	if err := first.Meta().Set("bad.key", 1); err == nil {
+		t.Fatal("expected an invalid name to be rejected")
+	}
+
+	if err := session.Meta().Set("n", 42); err != nil {
+		t.Fatalf("Set on a session returned error: %v", err)
+	}
+	var n int
+	if err := session.Meta().Get("n", &n); err != nil || n != 42 {
+		t.Fatalf("Get on a session returned %d, %v", n, err)
+	}
+	windows, err := session.ListWindows()
+	if err != nil || len(windows) == 0 {
+		t.Fatalf("ListWindows returned %v, %v", windows, err)
+	}
+	if err := windows[0].Meta().Set("team", "core"); err != nil {
+		t.Fatalf("Set on a window returned error: %v", err)
+	}
+	// Pane options inherit window options, so both panes match.
+	if found, err := tmux.FindPanesByMeta("team", "core"); err != nil || len(found) != 2 {
+		t.Fatalf("FindPanesByMeta on a window value returned %v, %v", found, err)
+	}
+}
@@ -2,7 +2,10 @@
 // github.com/GianlucaP106/gotmux module while adopting a control-mode backend.
 package gotmuxcc
 
-import "io"
+import (
+	"io"
+	"time"
+)
 
 // Socket references a tmux socket path.
 type Socket struct {
@@ -18,6 +21,8 @@ type Server struct {
 	User      string
 	Version   string
 
+	startTime time.Time
+
 	tmux *Tmux
 }
 
@@ -48,6 +53,11 @@ type Client struct {
 	Width        int
 	Written      string
 
+	activity  time.Time
+	created   time.Time
+	discarded int64
+	written   int64
+
 	tmux *Tmux
 }
 
@@ -75,6 +85,11 @@ type Session struct {
 	Stack             string
 	Windows           int
 
+	activity     time.Time
+	created      time.Time
+	lastAttached time.Time
+	printed      string
+
 	tmux *Tmux
 }
 
@@ -116,6 +131,8 @@ type Window struct {
 	Width              int
 	ZoomedFlag         bool
 
+	activity time.Time
+
 	tmux *Tmux
 }
 
@@ -163,22 +180,47 @@ type Pane struct {
 	Width          int
 	WindowIndex    int
 
+	sessionId string
+	geometry  PaneGeometry
+	deadTime  time.Time
+
 	tmux *Tmux
 }
 
+// PaneGeometry holds a pane's position within its window in cells. Right and
+// Bottom are inclusive, matching tmux's pane_right and pane_bottom formats.
+type PaneGeometry struct {
+	Left   int
+	Top    int
+	Right  int
+	Bottom int
+	Width  int
+	Height int
+}
+
 // Option models a tmux option key/value pair.
 type Option struct {
 	Key   string
 	Value string
+	// Values holds an array option's values by index, with "" at indices
+	// that are not set, and is nil for other options. Value then holds the
+	// values joined with newlines.
+	Values []string
+	// Inherited reports that the value comes from a parent scope rather
+	// than being set in the one shown. Only listings that include
+	// inherited values have such options.
+	Inherited bool
 }
 
-// WindowLayout enumerates tmux window layouts.
+// WindowLayout enumerates tmux's preset window layouts. A layout string, such
+// as one produced by Layout.String, may also be used.
 type WindowLayout string
 
 const (
 	WindowLayoutEvenHorizontal WindowLayout = "even-horizontal"
 	WindowLayoutEvenVertical   WindowLayout = "even-vertical"
-	WindowLayoutMainVertical   WindowLayout = "main-horizontal"
+	WindowLayoutMainHorizontal WindowLayout = "main-horizontal"
+	WindowLayoutMainVertical   WindowLayout = "main-vertical"
 	WindowLayoutTiled          WindowLayout = "tiled"
 )
 
@@ -200,6 +242,10 @@ const (
 	PaneSplitDirectionVertical   PaneSplitDirection = "-v"
 )
 
+// SizeFromClient may be used as SessionOptions.Width or Height to size the
+// session from the attached client (new-session -x - / -y -).
+const SizeFromClient = -1
+
 // SessionOptions configures new session creation.
 type SessionOptions struct {
 	Name           string
@@ -207,6 +253,21 @@ type SessionOptions struct {
 	StartDirectory string
 	Width          int
 	Height         int
+	// GroupTarget names an existing session whose group the new session joins
+	// (new-session -t). It cannot be combined with ShellCommand or WindowName.
+	GroupTarget string
+	// WindowName names the session's initial window (-n).
+	WindowName string
+	// Environment sets variables in the session environment (-e).
+	Environment map[string]string
+	// AttachIfExists attaches to the session called Name instead of failing
+	// when it already exists (-A).
+	AttachIfExists bool
+	// DetachOthers detaches other clients when AttachIfExists attaches (-D).
+	DetachOthers bool
+	// PrintFormat is an additional format expanded for the new session; the
+	// result is available from Session.PrintedFormat.
+	PrintFormat string
 }
 
 // DetachClientOptions customises detach-client behavior.
@@ -235,6 +296,86 @@ type NewWindowOptions struct {
 	StartDirectory string
 	WindowName     string
 	DoNotAttach    bool
+	// Index creates the window at this index of the session. By default the
+	// next free index is used.
+	Index *int
+	// After and Before insert the window after or before Index (or the
+	// session's current window), shifting later windows up (-a / -b).
+	After  bool
+	Before bool
+	// KillExisting replaces a window already at Index (-k).
+	KillExisting bool
+	// SelectExisting selects the window called WindowName, if there is one,
+	// instead of creating another (-S).
+	SelectExisting bool
+	// ShellCommand runs in the window instead of the default shell. When
+	// Arguments are given, tmux runs the command directly with them rather
+	// than through the shell.
+	ShellCommand string
+	Arguments    []string
+	// Environment sets variables for the window's process (-e).
+	Environment map[string]string
+}
+
+// SwapWindowOptions customises swap-window behavior.
+type SwapWindowOptions struct {
+	// DoNotSelect keeps the current window selected (-d).
+	DoNotSelect bool
+}
+
+// LinkWindowOptions customises link-window behavior.
+type LinkWindowOptions struct {
+	// Index places the window at this index of the target session. By default
+	// the next free index is used.
+	Index *int
+	// After and Before insert the window after or before Index (or the
+	// session's current window), shifting later windows up (-a / -b).
+	After  bool
+	Before bool
+	// KillExisting replaces a window already at Index (-k).
+	KillExisting bool
+	// DoNotSelect keeps the target session's current window selected (-d).
+	DoNotSelect bool
+}
+
+// RotateDirection enumerates rotate-window directions.
+type RotateDirection string
+
+const (
+	RotateDirectionUp   RotateDirection = "-U"
+	RotateDirectionDown RotateDirection = "-D"
+)
+
+// RespawnWindowOptions customises respawn-window behavior.
+type RespawnWindowOptions struct {
+	ShellCommand   string
+	StartDirectory string
+	// Environment sets variables for the new process (-e).
+	Environment map[string]string
+	// Kill respawns even if the window's process is still running (-k).
+	Kill bool
+}
+
+// ResizeWindowOptions customises resize-window behavior. Setting Width or
+// Height switches the window-size option to manual.
+type ResizeWindowOptions struct {
+	Width  int
+	Height int
+	// Largest and Smallest size the window to the largest or smallest
+	// session containing it (-A / -a).
+	Largest  bool
+	Smallest bool
+}
+
+// FindWindowOptions selects what find-window matches against. If none of
+// Name, Content and Title is set, all three are searched.
+type FindWindowOptions struct {
+	Name    bool
+	Content bool
+	Title   bool
+	// Regex treats the match as a regular expression instead of a glob.
+	Regex      bool
+	IgnoreCase bool
 }
 
 // SelectPaneOptions customises select-pane behavior.
@@ -247,6 +388,77 @@ type SplitWindowOptions struct {
 	SplitDirection PaneSplitDirection
 	StartDirectory string
 	ShellCommand   string
+	// Size and Percentage set the new pane's size in cells or as a percentage
+	// of the available space (-l). At most one may be set.
+	Size       int
+	Percentage int
+	// Before places the new pane left of or above the target (-b).
+	Before bool
+	// FullSize spans the full window width or height instead of splitting
+	// only the target pane (-f).
+	FullSize bool
+	// DoNotSelect leaves the target pane active (-d).
+	DoNotSelect bool
+	// Environment sets variables for the new pane's process (-e).
+	Environment map[string]string
+}
+
+// JoinPaneOptions customises join-pane and move-pane behavior.
+type JoinPaneOptions struct {
+	SplitDirection PaneSplitDirection
+	Size           int
+	Percentage     int
+	Before         bool
+	FullSize       bool
+	// DoNotSelect leaves the destination's active pane selected (-d).
+	DoNotSelect bool
+}
+
+// BreakPaneOptions customises break-pane behavior.
+type BreakPaneOptions struct {
+	WindowName string
+	// DoNotSelect keeps the current window selected (-d).
+	DoNotSelect bool
+	// Session receives the new window; by default it is the pane's session.
+	Session *Session
+	// Index places the new window at this index. By default the next free
+	// index is used.
+	Index *int
+	// After and Before insert the window after or before Index (or the
+	// session's current window), shifting later windows up (-a / -b).
+	After  bool
+	Before bool
+}
+
+// SwapPaneOptions customises swap-pane behavior.
+type SwapPaneOptions struct {
+	// DoNotSelect keeps the active pane from changing (-d).
+	DoNotSelect bool
+}
+
+// ResizePaneOptions customises resize-pane behavior. Width and Height (or
+// their percentage forms) set an absolute size; Direction and Adjustment
+// resize relatively; Zoom toggles the pane's zoomed state.
+type ResizePaneOptions struct {
+	Width         int
+	Height        int
+	WidthPercent  int
+	HeightPercent int
+	// Direction moves the pane border up, down, left or right by Adjustment
+	// cells (default 1).
+	Direction  PanePosition
+	Adjustment int
+	Zoom       bool
+}
+
+// RespawnPaneOptions customises respawn-pane behavior.
+type RespawnPaneOptions struct {
+	ShellCommand   string
+	StartDirectory string
+	// Environment sets variables for the new process (-e).
+	Environment map[string]string
+	// Kill respawns even if the pane's process is still running (-k).
+	Kill bool
 }
 
 // ChooseTreeOptions customises choose-tree behavior.
@@ -262,4 +474,192 @@ type CaptureOptions struct {
 	IgnoreTrailing   bool
 	PreserveTrailing bool
 	PreserveAndJoin  bool
+	// Start and End bound the capture (-S and -E). Line 0 is the first
+	// visible line and negative lines reach back into history; nil captures
+	// from the top or to the bottom of the visible screen.
+	Start *int
+	End   *int
+	// FromHistoryStart captures from the oldest history line (-S -).
+	FromHistoryStart bool
+	// ToVisibleEnd captures to the last visible line (-E -).
+	ToVisibleEnd bool
+	// AlternateScreen captures the alternate screen (-a).
+	AlternateScreen bool
+	// PendingInput captures input tmux has not yet processed (-P).
+	PendingInput bool
+	// Quiet suppresses errors, such as a missing alternate screen (-q).
+	Quiet bool
+	// Buffer stores the capture in the named paste buffer instead of
+	// returning it (-b).
+	Buffer string
+}
+
+// CaptureLine is one line of captured pane content.
+type CaptureLine struct {
+	// Number is the line's position in the pane's history at capture time:
+	// 0 is the oldest history line and the first visible line equals the
+	// history size. Alternate screen and pending input lines count from 0.
+	Number int
+	Text   string
+}
+
+// CopyModeState is a pane's copy-mode state. Selection coordinates are
+// numbered like CaptureLine.Number; the cursor's are relative to the screen.
+// CursorLine is the line as displayed, so on the top line it ends with the
+// position indicator.
+type CopyModeState struct {
+	Active           bool // the pane is in copy mode
+	CursorX          int
+	CursorY          int
+	CursorLine       string
+	CursorWord       string
+	ScrollPosition   int // lines scrolled back from the bottom
+	HistorySize      int
+	SearchString     string
+	SearchPresent    bool
+	SearchMatch      string
+	SelectionPresent bool
+	SelectionActive  bool
+	SelectionStartX  int
+	SelectionStartY  int
+	SelectionEndX    int
+	SelectionEndY    int
+}
+
+// PipeOptions customises Pane.PipeTo.
+type PipeOptions struct {
+	// Input is typed into the pane as it is read (-I).
+	Input io.Reader
+	// Toggle closes the pane's existing pipe, if it has one, instead of
+	// opening a new one (-o).
+	Toggle bool
+}
+
+// WaitOptions customises Pane.Wait.
+type WaitOptions struct {
+	// Kill kills the pane once it has died.
+	Kill bool
+	// Respawn restarts the pane once it has died.
+	Respawn *RespawnPaneOptions
+}
+
+// ExitStatus describes how a pane's process exited.
+type ExitStatus struct {
+	// Code is the exit status, 0 if the process was killed by a signal.
+	Code int
+	// Signal is the signal that killed the process, or 0.
+	Signal int
+	// Time is when the process exited.
+	Time time.Time
+}
+
+// Buffer describes a paste buffer.
+type Buffer struct {
+	Name string
+	Size int
+	// Sample is the start of the buffer as tmux displays it, with
+	// non-printable characters escaped.
+	Sample      string
+	CreatedTime time.Time
+}
+
+// SetBufferOptions customises Tmux.SetBuffer.
+type SetBufferOptions struct {
+	// Append adds the data to the end of the buffer (-a).
+	Append bool
+	// NewName renames the buffer instead of setting it (-n).
+	NewName string
+}
+
+// PasteBufferOptions customises Pane.PasteBuffer.
+type PasteBufferOptions struct {
+	// Buffer names the buffer to paste; by default the most recent one.
+	Buffer string
+	// Bracketed uses bracketed paste if the application asked for it (-p).
+	Bracketed bool
+	// Separator replaces each newline in the buffer (-s). By default tmux
+	// uses a carriage return.
+	Separator string
+	// NoReplace pastes newlines unchanged (-r).
+	NoReplace bool
+	// Delete deletes the buffer after pasting it (-d).
+	Delete bool
+}
+
+// BufferEvent reports a paste buffer that was set or deleted.
+type BufferEvent struct {
+	Name    string
+	Deleted bool
+}
+
+// ScopeLevel enumerates the levels tmux keeps options at.
+type ScopeLevel string
+
+const (
+	ScopeServer        ScopeLevel = "server"
+	ScopeGlobalSession ScopeLevel = "global-session"
+	ScopeSession       ScopeLevel = "session"
+	ScopeGlobalWindow  ScopeLevel = "global-window"
+	ScopeWindow        ScopeLevel = "window"
+	ScopePane          ScopeLevel = "pane"
+)
+
+// Scope identifies a set of options. Target names the session, window or
+// pane for the levels that need one.
+type Scope struct {
+	Level  ScopeLevel
+	Target string
+}
+
+// SetOptionOptions customises ScopedOptions.Set.
+type SetOptionOptions struct {
+	// Append adds the value to the option's current one (-a).
+	Append bool
+	// OnlyIfUnset leaves an option that is already set unchanged; tmux
+	// then reports an error (-o).
+	OnlyIfUnset bool
+}
+
+// ShowOptionsOptions customises ScopedOptions.Get and List.
+type ShowOptionsOptions struct {
+	// Inherited includes values inherited from the parent scope (-A).
+	Inherited bool
+}
+
+// OptionStyle is a parsed style option, such as "fg=red,bg=black,bold".
+type OptionStyle struct {
+	Foreground string
+	Background string
+	// Attributes holds the other entries, such as "bold" or "align=left",
+	// in order.
+	Attributes []string
+}
+
+// OptionType enumerates the kinds of value an option holds.
+type OptionType string
+
+const (
+	OptionTypeString OptionType = "string"
+	OptionTypeFlag   OptionType = "flag"
+	OptionTypeNumber OptionType = "number"
+	OptionTypeColour OptionType = "colour"
+	OptionTypeStyle  OptionType = "style"
+	OptionTypeArray  OptionType = "array"
+)
+
+// OptionSpec describes an option the server knows. Level is ScopeServer,
+// ScopeSession or ScopeWindow; window options include pane options.
+type OptionSpec struct {
+	Name  string
+	Level ScopeLevel
+	Type  OptionType
+	// Inferred marks a Type guessed from the option's name and value rather
+	// than reported by tmux. Validate does not reject values on the strength
+	// of an inferred type.
+	Inferred bool
+}
+
+// OptionSchema lists the options a server knows, by name.
+type OptionSchema struct {
+	Options map[string]OptionSpec
 }
@@ -0,0 +1,716 @@
+package gotmuxcc
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// vterm is a minimal VT100/xterm emulator modelling what tmux draws for a
+// pane. It handles cursor movement, erasing, scroll regions, insert and
+// delete, the alternate screen, tab stops and SGR attributes; everything else
+// is parsed and ignored.
+type vterm struct {
+	width, height int
+
+	primary   [][]Cell
+	alternate [][]Cell
+	grid      [][]Cell // primary or alternate
+	altOn     bool
+
+	// cx runs from 0 to width: as in tmux, writing the last column leaves
+	// the cursor past it until the next character wraps.
+	cx, cy      int
+	style       Style
+	top, bottom int // scroll region, inclusive
+
+	origin        bool
+	autowrap      bool
+	insert        bool
+	cursorVisible bool
+	tabs          []bool
+
+	saved    vtermCursor // ESC 7 / CSI s
+	altSaved vtermCursor // cursor saved on entering the alternate screen
+
+	state    vtermState
+	params   []byte
+	private  byte
+	intermed []byte
+	osc      []byte
+	pending  []byte // partial UTF-8 sequence
+	lastRune rune
+}
+
+type vtermCursor struct {
+	x, y   int
+	style  Style
+	origin bool
+}
+
+type vtermState int
+
+const (
+	vtGround vtermState = iota
+	vtEscape
+	vtEscapeIntermediate
+	vtCSI
+	vtOSC
+	vtOSCEscape
+	vtString // DCS, APC, PM, SOS and screen titles, discarded
+	vtStringEscape
+)
+
+func newVterm(width, height int) *vterm {
+	vt := &vterm{}
+	vt.reset(width, height)
+	return vt
+}
+
+func (vt *vterm) reset(width, height int) {
+	*vt = vterm{
+		width:         max(width, 1),
+		height:        max(height, 1),
+		autowrap:      true,
+		cursorVisible: true,
+	}
+	vt.primary = vt.blankGrid()
+	vt.alternate = vt.blankGrid()
+	vt.grid = vt.primary
+	vt.bottom = vt.height - 1
+	vt.tabs = make([]bool, vt.width)
+	for x := 8; x < vt.width; x += 8 {
+		vt.tabs[x] = true
+	}
+}
+
+func (vt *vterm) blankCell() Cell {
+	return Cell{Rune: ' ', Width: 1, Style: Style{Bg: vt.style.Bg}}
+}
+
+func (vt *vterm) blankRow() []Cell {
+	row := make([]Cell, vt.width)
+	blank := vt.blankCell()
+	for x := range row {
+		row[x] = blank
+	}
+	return row
+}
+
+func (vt *vterm) blankGrid() [][]Cell {
+	grid := make([][]Cell, vt.height)
+	for y := range grid {
+		grid[y] = vt.blankRow()
+	}
+	return grid
+}
+
+// load replaces a grid's contents with a parsed screen.
+func (vt *vterm) load(grid [][]Cell, screen *Screen) {
+	for y := range grid {
+		row := vt.blankRow()
+		if y < len(screen.Rows) {
+			x := 0
+			for _, cell := range screen.Rows[y] {
+				if x+cell.Width > vt.width {
+					break
+				}
+				row[x] = cell
+				if cell.Width == 2 {
+					row[x+1] = Cell{Style: cell.Style}
+				}
+				x += cell.Width
+			}
+		}
+		grid[y] = row
+	}
+}
+
+// screen returns the visible grid with wide character placeholders removed
+// and trailing blanks trimmed, matching capture-pane output.
+func (vt *vterm) screen() *Screen {
+	screen := &Screen{Rows: make([][]Cell, len(vt.grid))}
+	for y, row := range vt.grid {
+		end := len(row)
+		for end > 0 && (row[end-1].Width == 0 || isBlank(row[end-1])) {
+			end--
+		}
+		cells := make([]Cell, 0, end)
+		for _, cell := range row[:end] {
+			if cell.Width == 0 {
+				continue
+			}
+			cell.Combining = append([]rune(nil), cell.Combining...)
+			cells = append(cells, cell)
+		}
+		screen.Rows[y] = cells
+	}
+	return screen
+}
+
+func isBlank(cell Cell) bool {
+	return cell.Rune == ' ' && len(cell.Combining) == 0 && cell.Style == Style{}
+}
+
+// Write feeds pane output to the emulator.
+func (vt *vterm) Write(data []byte) (int, error) {
+	for _, b := range data {
+		vt.feed(b)
+	}
+	return len(data), nil
+}
+
+func (vt *vterm) feed(b byte) {
+	switch vt.state {
+	case vtEscape:
+		vt.escape(b)
+		return
+	case vtEscapeIntermediate:
+		// Charset designations and DEC tests take one more byte.
+		vt.state = vtGround
+		return
+	case vtCSI:
+		vt.csiByte(b)
+		return
+	case vtOSC:
+		switch b {
+		case '\a':
+			vt.oscEnd()
+		case 0x1b:
+			vt.state = vtOSCEscape
+		default:
+			vt.osc = append(vt.osc, b)
+		}
+		return
+	case vtOSCEscape:
+		if b == '\\' {
+			vt.oscEnd()
+			return
+		}
+		vt.osc = append(vt.osc, 0x1b, b)
+		vt.state = vtOSC
+		return
+	case vtString:
+		switch b {
+		case '\a':
+			vt.state = vtGround
+		case 0x1b:
+			vt.state = vtStringEscape
+		}
+		return
+	case vtStringEscape:
+		if b == '\\' {
+			vt.state = vtGround
+		} else {
+			vt.state = vtString
+		}
+		return
+	}
+
+	if b&0xc0 == 0x80 && len(vt.pending) > 0 || b >= 0xc0 && len(vt.pending) == 0 {
+		vt.pending = append(vt.pending, b)
+		if !utf8.FullRune(vt.pending) {
+			return
+		}
+		r, _ := utf8.DecodeRune(vt.pending)
+		vt.pending = vt.pending[:0]
+		if r != utf8.RuneError {
+			vt.print(r)
+		}
+		return
+	}
+	// Like tmux, drop incomplete or invalid UTF-8.
+	vt.pending = vt.pending[:0]
+	if b >= 0x80 {
+		return
+	}
+	switch {
+	case b == 0x1b:
+		vt.state = vtEscape
+		vt.intermed = vt.intermed[:0]
+	case b < 0x20 || b == 0x7f:
+		vt.control(b)
+	default:
+		vt.print(rune(b))
+	}
+}
+
+func (vt *vterm) control(b byte) {
+	switch b {
+	case '\b':
+		if vt.cx > 0 {
+			vt.cx--
+		}
+	case '\t':
+		vt.cx = vt.nextTab(vt.cx)
+	case '\n', '\v', '\f':
+		vt.lineFeed()
+	case '\r':
+		vt.cx = 0
+	}
+}
+
+func (vt *vterm) escape(b byte) {
+	vt.state = vtGround
+	switch b {
+	case '[':
+		vt.state = vtCSI
+		vt.params = vt.params[:0]
+		vt.private = 0
+	case ']':
+		vt.state = vtOSC
+		vt.osc = vt.osc[:0]
+	case 'P', '_', '^', 'X', 'k':
+		vt.state = vtString
+	case '(', ')', '*', '+', '-', '.', '/', '#', '%', ' ':
+		vt.state = vtEscapeIntermediate
+	case '7':
+		vt.saved = vt.saveCursor()
+	case '8':
+		vt.restoreCursor(vt.saved)
+	case 'D':
+		vt.lineFeed()
+	case 'E':
+		vt.cx = 0
+		vt.lineFeed()
+	case 'M':
+		vt.reverseIndex()
+	case 'H':
+		if vt.cx < vt.width {
+			vt.tabs[vt.cx] = true
+		}
+	case 'c':
+		vt.reset(vt.width, vt.height)
+	}
+}
+
+func (vt *vterm) oscEnd() {
+	vt.state = vtGround
+	applyOSC(string(vt.osc), &vt.style)
+}
+
+func (vt *vterm) csiByte(b byte) {
+	switch {
+	case b >= 0x30 && b <= 0x3f:
+		if len(vt.params) == 0 && vt.private == 0 && (b == '?' || b == '>' || b == '<' || b == '=') {
+			vt.private = b
+			return
+		}
+		vt.params = append(vt.params, b)
+	case b >= 0x20 && b <= 0x2f:
+		vt.intermed = append(vt.intermed, b)
+	case b >= 0x40 && b <= 0x7e:
+		vt.state = vtGround
+		if len(vt.intermed) == 0 {
+			vt.csi(b)
+		}
+		vt.intermed = vt.intermed[:0]
+	default:
+		if b == 0x1b {
+			vt.state = vtEscape
+			return
+		}
+		vt.control(b)
+	}
+}
+
+// param returns CSI parameter idx, or def when it is missing or zero.
+func (vt *vterm) param(idx, def int) int {
+	fields := strings.Split(string(vt.params), ";")
+	if idx >= len(fields) {
+		return def
+	}
+	field, _, _ := strings.Cut(fields[idx], ":")
+	n, err := strconv.Atoi(field)
+	if err != nil || n == 0 {
+		return def
+	}
+	return n
+}
+
+func (vt *vterm) csi(final byte) {
+	if vt.private != 0 {
+		if vt.private == '?' && (final == 'h' || final == 'l') {
+			for _, field := range strings.Split(string(vt.params), ";") {
+				mode, _ := strconv.Atoi(field)
+				vt.privateMode(mode, final == 'h')
+			}
+		}
+		return
+	}
+
+	n := vt.param(0, 1)
+	switch final {
+	case '@':
+		vt.insertCells(n)
+	case 'A':
+		vt.moveCursor(vt.cx, vt.cursorUp(n))
+	case 'B', 'e':
+		vt.moveCursor(vt.cx, vt.cursorDown(n))
+	case 'C', 'a':
+		vt.moveCursor(vt.cx+n, vt.cy)
+	case 'D':
+		vt.moveCursor(vt.cx-n, vt.cy)
+	case 'E':
+		vt.moveCursor(0, vt.cursorDown(n))
+	case 'F':
+		vt.moveCursor(0, vt.cursorUp(n))
+	case 'G', '`':
+		vt.moveCursor(n-1, vt.cy)
+	case 'H', 'f':
+		row, column := n-1, vt.param(1, 1)-1
+		if vt.origin {
+			row = min(row+vt.top, vt.bottom)
+		}
+		vt.moveCursor(column, row)
+	case 'd':
+		row := n - 1
+		if vt.origin {
+			row = min(row+vt.top, vt.bottom)
+		}
+		vt.moveCursor(vt.cx, row)
+	case 'I':
+		for ; n > 0; n-- {
+			vt.cx = vt.nextTab(vt.cx)
+		}
+	case 'Z':
+		for ; n > 0 && vt.cx > 0; n-- {
+			vt.cx--
+			for vt.cx > 0 && !vt.tabs[vt.cx] {
+				vt.cx--
+			}
+		}
+	case 'J':
+		vt.eraseDisplay(vt.param(0, 0))
+	case 'K':
+		vt.eraseLine(vt.param(0, 0))
+	case 'L':
+		vt.scrollDown(vt.cy, vt.lineOpBottom(), n)
+		vt.cx = 0
+	case 'M':
+		vt.scrollUp(vt.cy, vt.lineOpBottom(), n)
+		vt.cx = 0
+	case 'P':
+		vt.deleteCells(n)
+	case 'X':
+		vt.eraseCells(vt.cx, min(vt.cx+n, vt.width))
+	case 'S':
+		vt.scrollUp(vt.top, vt.bottom, n)
+	case 'T':
+		vt.scrollDown(vt.top, vt.bottom, n)
+	case 'b':
+		if vt.lastRune != 0 {
+			for ; n > 0; n-- {
+				vt.print(vt.lastRune)
+			}
+		}
+	case 'g':
+		switch vt.param(0, 0) {
+		case 0:
+			if vt.cx < vt.width {
+				vt.tabs[vt.cx] = false
+			}
+		case 3:
+			for x := range vt.tabs {
+				vt.tabs[x] = false
+			}
+		}
+	case 'h', 'l':
+		if vt.param(0, 0) == 4 {
+			vt.insert = final == 'h'
+		}
+	case 'm':
+		applySGR(string(vt.params), &vt.style)
+	case 'r':
+		top, bottom := vt.param(0, 1)-1, vt.param(1, vt.height)-1
+		if bottom >= vt.height {
+			bottom = vt.height - 1
+		}
+		if top < bottom {
+			vt.top, vt.bottom = top, bottom
+			vt.moveCursor(0, vt.homeRow())
+		}
+	case 's':
+		vt.saved = vt.saveCursor()
+	case 'u':
+		vt.restoreCursor(vt.saved)
+	}
+}
+
+func (vt *vterm) privateMode(mode int, set bool) {
+	switch mode {
+	case 6:
+		vt.origin = set
+		vt.moveCursor(0, vt.homeRow())
+	case 7:
+		vt.autowrap = set
+	case 25:
+		vt.cursorVisible = set
+	case 47, 1047:
+		vt.setAlternate(set, false)
+	case 1048:
+		if set {
+			vt.saved = vt.saveCursor()
+		} else {
+			vt.restoreCursor(vt.saved)
+		}
+	case 1049:
+		vt.setAlternate(set, true)
+	}
+}
+
+// setAlternate switches screens. Like tmux, entering the alternate screen
+// clears it.
+func (vt *vterm) setAlternate(on, cursor bool) {
+	if on == vt.altOn {
+		return
+	}
+	vt.altOn = on
+	if on {
+		if cursor {
+			vt.altSaved = vt.saveCursor()
+		}
+		vt.alternate = vt.blankGrid()
+		vt.grid = vt.alternate
+		return
+	}
+	vt.grid = vt.primary
+	if cursor {
+		vt.restoreCursor(vt.altSaved)
+	}
+}
+
+func (vt *vterm) saveCursor() vtermCursor {
+	return vtermCursor{x: vt.cx, y: vt.cy, style: vt.style, origin: vt.origin}
+}
+
+func (vt *vterm) restoreCursor(c vtermCursor) {
+	vt.style = c.style
+	vt.origin = c.origin
+	vt.moveCursor(c.x, c.y)
+}
+
+// lineOpBottom is the last row insert and delete line shift. Unlike xterm,
+// tmux applies them to the whole screen when the cursor is outside the
+// scroll region.
+func (vt *vterm) lineOpBottom() int {
+	if vt.cy < vt.top || vt.cy > vt.bottom {
+		return vt.height - 1
+	}
+	return vt.bottom
+}
+
+func (vt *vterm) homeRow() int {
+	if vt.origin {
+		return vt.top
+	}
+	return 0
+}
+
+func (vt *vterm) moveCursor(x, y int) {
+	vt.cx = max(0, min(x, vt.width-1))
+	vt.cy = max(0, min(y, vt.height-1))
+}
+
+// cursorUp and cursorDown stop at the scroll region's edges when the cursor
+// starts inside it.
+func (vt *vterm) cursorUp(n int) int {
+	limit := 0
+	if vt.cy >= vt.top {
+		limit = vt.top
+	}
+	return max(vt.cy-n, limit)
+}
+
+func (vt *vterm) cursorDown(n int) int {
+	limit := vt.height - 1
+	if vt.cy <= vt.bottom {
+		limit = vt.bottom
+	}
+	return min(vt.cy+n, limit)
+}
+
+func (vt *vterm) nextTab(x int) int {
+	if x >= vt.width-1 {
+		return x
+	}
+	for x++; x < vt.width-1; x++ {
+		if vt.tabs[x] {
+			return x
+		}
+	}
+	return vt.width - 1
+}
+
+func (vt *vterm) print(r rune) {
+	width := runeWidth(r)
+	if r < 0x20 || width > vt.width {
+		return
+	}
+	if width == 0 {
+		vt.combine(r)
+		return
+	}
+	vt.lastRune = r
+
+	if vt.cx > vt.width-width {
+		if !vt.autowrap {
+			return
+		}
+		vt.cx = 0
+		vt.lineFeed()
+	}
+	if vt.insert {
+		vt.insertCells(width)
+	}
+
+	row := vt.grid[vt.cy]
+	vt.clearWide(row, vt.cx)
+	if width == 2 {
+		vt.clearWide(row, vt.cx+1)
+	}
+	row[vt.cx] = Cell{Rune: r, Width: width, Style: vt.style}
+	if width == 2 {
+		row[vt.cx+1] = Cell{Style: vt.style}
+	}
+	vt.cx += width
+}
+
+// combine attaches a zero-width character to the cell before the cursor.
+func (vt *vterm) combine(r rune) {
+	x := vt.cx - 1
+	row := vt.grid[vt.cy]
+	if x > 0 && row[x].Width == 0 {
+		x--
+	}
+	if x < 0 {
+		return
+	}
+	row[x].Combining = append(row[x].Combining, r)
+}
+
+// clearWide blanks both halves of a wide character overlapping column x.
+func (vt *vterm) clearWide(row []Cell, x int) {
+	if x >= len(row) {
+		return
+	}
+	blank := Cell{Rune: ' ', Width: 1, Style: row[x].Style}
+	switch {
+	case row[x].Width == 2 && x+1 < len(row):
+		row[x+1] = blank
+	case row[x].Width == 0 && x > 0:
+		row[x-1] = Cell{Rune: ' ', Width: 1, Style: row[x-1].Style}
+		row[x] = blank
+	}
+}
+
+func (vt *vterm) lineFeed() {
+	if vt.cy == vt.bottom {
+		vt.scrollUp(vt.top, vt.bottom, 1)
+		return
+	}
+	if vt.cy < vt.height-1 {
+		vt.cy++
+	}
+}
+
+func (vt *vterm) reverseIndex() {
+	if vt.cy == vt.top {
+		vt.scrollDown(vt.top, vt.bottom, 1)
+		return
+	}
+	if vt.cy > 0 {
+		vt.cy--
+	}
+}
+
+// scrollUp moves rows top..bottom up by n, filling the bottom with blanks.
+func (vt *vterm) scrollUp(top, bottom, n int) {
+	n = min(n, bottom-top+1)
+	copy(vt.grid[top:bottom+1], vt.grid[top+n:bottom+1])
+	for y := bottom - n + 1; y <= bottom; y++ {
+		vt.grid[y] = vt.blankRow()
+	}
+}
+
+// scrollDown moves rows top..bottom down by n, filling the top with blanks.
+func (vt *vterm) scrollDown(top, bottom, n int) {
+	n = min(n, bottom-top+1)
+	copy(vt.grid[top+n:bottom+1], vt.grid[top:bottom+1-n])
+	for y := top; y < top+n; y++ {
+		vt.grid[y] = vt.blankRow()
+	}
+}
+
+func (vt *vterm) eraseCells(from, to int) {
+	if from >= to {
+		return
+	}
+	row := vt.grid[vt.cy]
+	vt.clearWide(row, from)
+	vt.clearWide(row, to-1)
+	blank := vt.blankCell()
+	for x := from; x < to; x++ {
+		row[x] = blank
+	}
+}
+
+func (vt *vterm) eraseLine(mode int) {
+	switch mode {
+	case 0:
+		vt.eraseCells(vt.cx, vt.width)
+	case 1:
+		vt.eraseCells(0, min(vt.cx+1, vt.width))
+	case 2:
+		vt.eraseCells(0, vt.width)
+	}
+}
+
+func (vt *vterm) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		vt.eraseLine(0)
+		for y := vt.cy + 1; y < vt.height; y++ {
+			vt.grid[y] = vt.blankRow()
+		}
+	case 1:
+		vt.eraseLine(1)
+		for y := 0; y < vt.cy; y++ {
+			vt.grid[y] = vt.blankRow()
+		}
+	case 2:
+		for y := range vt.grid {
+			vt.grid[y] = vt.blankRow()
+		}
+	}
+}
+
+func (vt *vterm) insertCells(n int) {
+	row := vt.grid[vt.cy]
+	n = min(n, vt.width-vt.cx)
+	if n <= 0 {
+		return
+	}
+	vt.clearWide(row, vt.cx)
+	copy(row[vt.cx+n:], row[vt.cx:vt.width-n])
+	blank := vt.blankCell()
+	for x := vt.cx; x < vt.cx+n; x++ {
+		row[x] = blank
+	}
+	vt.clearWide(row, vt.width-1)
+}
+
+func (vt *vterm) deleteCells(n int) {
+	row := vt.grid[vt.cy]
+	n = min(n, vt.width-vt.cx)
+	if n <= 0 {
+		return
+	}
+	vt.clearWide(row, vt.cx)
+	vt.clearWide(row, vt.cx+n)
+	copy(row[vt.cx:], row[vt.cx+n:])
+	blank := vt.blankCell()
+	for x := vt.width - n; x < vt.width; x++ {
+		row[x] = blank
+	}
+}
@@ -0,0 +1,248 @@
+package gotmuxcc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// waitSeq makes the hook and subscription names Wait uses unique.
+var waitSeq atomic.Uint64
+
+var (
+	// deadStatusPoll is how often Wait checks a dead pane whose exit
+	// status tmux has not collected yet.
+	deadStatusPoll = 100 * time.Millisecond
+	// waitRecheck is how long Wait relies on notifications alone. tmux
+	// sends none when a pane is killed in a session this client is not
+	// attached to.
+	waitRecheck = time.Second
+)
+
+// Wait waits for the pane's process to exit and returns its exit status.
+// If the pane is killed instead, Wait returns an error wrapping ErrNotFound.
+//
+// Wait appends a pane-died hook that notifies this client through a
+// subscription. tmux runs only the most specific level of pane-died hooks,
+// so the hook joins those of the pane, its window or its session if any have
+// some, and is otherwise global (-g), where it runs for every pane on the
+// server until Wait returns and removes it. Wait then turns remain-on-exit on
+// for the pane so the status survives, and leaves it on: unless op.Kill or
+// op.Respawn is set, the dead pane remains until it is killed.
+//
+// The hook alone is not enough. tmux sends no notification when the process
+// exited before the hook was added, or when the pane is killed in a session
+// this client is not attached to, so Wait also polls the pane every second,
+// and every 100ms while it is dead but tmux has not collected its status.
+//
+// With op.Kill the dead pane is then killed, and with op.Respawn it is
+// restarted.
+func (p *Pane) Wait(ctx context.Context, op *WaitOptions) (*ExitStatus, error) {
+	if op == nil {
+		op = &WaitOptions{}
+	}
+	if op.Kill && op.Respawn != nil {
+		return nil, invalidOptions("respawn-pane", "Respawn", "cannot be combined with Kill")
+	}
+
+	status, err := p.waitDead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case op.Kill:
+		if err := p.Kill(); err != nil {
+			return status, err
+		}
+	case op.Respawn != nil:
+		if _, err := p.RespawnPane(op.Respawn); err != nil {
+			return status, err
+		}
+	}
+	return status, nil
+}
+
+func (p *Pane) waitDead(ctx context.Context) (*ExitStatus, error) {
+	client, err := p.tmux.clientName()
+	if err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("gotmuxcc-wait-%d-%d", os.Getpid(), waitSeq.Add(1))
+
+	wake := make(chan struct{}, 1)
+	stop := p.tmux.listen(func(evt Event) {
+		switch evt.Name {
+		case "subscription-changed":
+			if len(evt.Fields) == 0 || evt.Fields[0] != name {
+				return
+			}
+		case "layout-change", "window-close", "unlinked-window-close", "sessions-changed", "exit":
+			// The pane may have been killed rather than died.
+		default:
+			return
+		}
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	})
+	defer stop()
+
+	scope, err := p.deathHookScope()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.tmux.addDeathHook(scope, p.Id, client, name); err != nil {
+		return nil, err
+	}
+	defer p.tmux.removeDeathHook(scope, name)
+
+	// With the hook in place, a process that exits from now on is reported;
+	// one that already has was either kept by remain-on-exit or took its
+	// pane with it.
+	_, err = p.tmux.query().
+		cmd("set-option").
+		fargs("-p", "-t", p.Id).
+		pargs("remain-on-exit", "on").
+		run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to set remain-on-exit: %w", lookupError("pane", p.Id, err))
+	}
+
+	for {
+		status, dead, err := p.exitStatus()
+		if err != nil || status != nil {
+			return status, err
+		}
+		// The hook may have fired before it was added, so a dead pane
+		// whose status tmux has yet to collect is checked again shortly.
+		retry := waitRecheck
+		if dead {
+			retry = deadStatusPoll
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-wake:
+		case <-time.After(retry):
+		}
+	}
+}
+
+// exitStatus returns the pane's exit status, or nil while it is alive or
+// tmux has not yet collected the status of its dead process; dead reports
+// the latter.
+func (p *Pane) exitStatus() (status *ExitStatus, dead bool, err error) {
+	output, err := p.tmux.query().
+		cmd("display-message").
+		fargs("-t", p.Id).
+		vars(varPaneId, varPaneDead, varPaneDeadStatus, varPaneDeadSignal, varPaneDeadTime).
+		run()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to query pane %s: %w", p.Id, lookupError("pane", p.Id, err))
+	}
+	r := output.one()
+	if r.get(varPaneId) != p.Id {
+		return nil, false, notFound("pane", p.Id)
+	}
+	if !isOne(r.get(varPaneDead)) {
+		return nil, false, nil
+	}
+	// tmux can see the pane close before it has collected the status.
+	if r.get(varPaneDeadTime) == "" {
+		return nil, true, nil
+	}
+	return &ExitStatus{
+		Code:   atoi(r.get(varPaneDeadStatus)),
+		Signal: atoi(r.get(varPaneDeadSignal)),
+		Time:   parseUnix(r.get(varPaneDeadTime)),
+	}, true, nil
+}
+
+// clientName returns the name tmux gives this control client.
+func (t *Tmux) clientName() (string, error) {
+	output, err := t.query().
+		cmd("display-message").
+		vars(varClientName).
+		run()
+	if err != nil {
+		return "", fmt.Errorf("failed to query client: %w", err)
+	}
+	name := output.one().get(varClientName)
+	if name == "" {
+		return "", errors.New("gotmuxcc: control client has no name")
+	}
+	return name, nil
+}
+
+// deathHookScope returns the set-hook flags for the most specific level
+// with a pane-died hook for the pane: the pane, its window, its session or
+// the global hooks. tmux runs only the hooks at that level.
+func (p *Pane) deathHookScope() ([]string, error) {
+	output, err := p.tmux.query().
+		cmd("display-message").
+		fargs("-t", p.Id).
+		vars(varWindowId, varSessionId).
+		run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pane %s: %w", p.Id, lookupError("pane", p.Id, err))
+	}
+	r := output.one()
+	scopes := [][]string{
+		{"-p", "-t", p.Id},
+		{"-w", "-t", r.get(varWindowId)},
+		{"-t", r.get(varSessionId)},
+	}
+	for _, scope := range scopes {
+		output, err := p.tmux.query().
+			cmd("show-hooks").
+			fargs(scope...).
+			pargs("pane-died").
+			run()
+		if err != nil {
+			return nil, fmt.Errorf("failed to show hooks: %w", err)
+		}
+		if strings.TrimSpace(output.raw()) != "" {
+			return scope, nil
+		}
+	}
+	return []string{"-g"}, nil
+}
+
+// addDeathHook appends a pane-died hook at scope that, when pane dies, adds
+// the subscription name to client. tmux reports a new subscription's value
+// within a second, which delivers %subscription-changed. The hook tests the
+// pane in a format rather than with if-shell, whose commands would send this
+// client output blocks it did not ask for.
+func (t *Tmux) addDeathHook(scope []string, pane, client, name string) error {
+	subscribe := "refresh-client -t " + quoteArgument(client) + " -B " + name + "::1"
+	hook := "run-shell -C " + quoteArgument("#{?#{==:#{hook_pane},"+pane+"},"+subscribe+",}")
+	_, err := t.query().
+		cmd("set-hook").
+		fargs("-a").
+		fargs(scope...).
+		pargs("pane-died", hook).
+		run()
+	if err != nil {
+		return fmt.Errorf("failed to set pane-died hook: %w", err)
+	}
+	return nil
+}
+
+// removeDeathHook removes the hook and subscription addDeathHook added for
+// name. Errors are ignored: the hook is harmless once its pane is gone.
+func (t *Tmux) removeDeathHook(scope []string, name string) {
+	if output, err := t.query().cmd("show-hooks").fargs(scope...).pargs("pane-died").run(); err == nil {
+		for _, line := range output.result.Lines {
+			hook, command, _ := strings.Cut(line, " ")
+			if strings.Contains(command, name) {
+				t.query().cmd("set-hook").fargs("-u").fargs(scope...).pargs(hook).run()
+			}
+		}
+	}
+	t.query().cmd("refresh-client").fargs("-B", name).run()
+}
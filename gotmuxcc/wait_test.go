@@ -0,0 +1,140 @@
+package gotmuxcc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+var exitStatusVars = []string{varPaneId, varPaneDead, varPaneDeadStatus, varPaneDeadSignal, varPaneDeadTime}
+
+func TestPaneWait(t *testing.T) {
+	name := fmt.Sprintf("gotmuxcc-wait-%d-%d", os.Getpid(), waitSeq.Load()+1)
+	ok := []string{"%begin 1 1 0", "%end 1 1 0"}
+	status := func(overrides map[string]string) []string {
+		return []string{"%begin 1 1 0", formatRecord(exitStatusVars, overrides), "%end 1 1 0"}
+	}
+	responses := []scriptedResponse{
+		{match: "display-message -p '#{client_name}'", lines: []string{"%begin 1 1 0", "client-7", "%end 1 1 0"}},
+		{match: "display-message -t %1", lines: []string{"%begin 1 1 0", "@2" + querySeparator + "$3", "%end 1 1 0"}},
+		{match: "show-hooks -p -t %1 -- pane-died", lines: ok},
+		// A window hook hides the global ones, so ours joins it.
+		{match: "show-hooks -w -t @2 -- pane-died", lines: []string{"%begin 1 1 0", "pane-died[0] set-option -g @x 1", "%end 1 1 0"}},
+		{match: "set-hook -a -w -t @2 -- pane-died 'run-shell -C '\\''#{?#{==:#{hook_pane},%1},refresh-client -t client-7 -B " + name + "::1,}'\\'", lines: ok},
+		{match: "set-option -p -t %1 -- remain-on-exit on", lines: ok},
+		// The pane is still running; the subscription wakes Wait.
+		{match: "display-message -t %1", lines: append(status(map[string]string{varPaneId: "%1", varPaneDead: "0"}),
+			"%subscription-changed "+name+" $3 - - - : 1")},
+		{match: "display-message -t %1", lines: status(map[string]string{
+			varPaneId: "%1", varPaneDead: "1", varPaneDeadStatus: "3", varPaneDeadTime: "1700000000",
+		})},
+		{match: "show-hooks -w -t @2 -- pane-died", lines: []string{
+			"%begin 1 1 0",
+			"pane-died[0] set-option -g @x 1",
+			"pane-died[1] run-shell -C \"#{?#{==:#{hook_pane},%1},refresh-client -t client-7 -B " + name + "::1,}\"",
+			"%end 1 1 0",
+		}},
+		{match: "set-hook -u -w -t @2 -- 'pane-died[1]'", lines: ok},
+		{match: "refresh-client -B " + name, lines: ok},
+		{match: "kill-pane -t %1", lines: ok},
+	}
+	tr := newScriptedTransport(responses)
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	st, err := (&Pane{Id: "%1", tmux: tmux}).Wait(context.Background(), &WaitOptions{Kill: true})
+	if err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	if st.Code != 3 || st.Signal != 0 || !st.Time.Equal(time.Unix(1700000000, 0)) {
+		t.Fatalf("unexpected status %+v", st)
+	}
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	if len(tr.sent) != len(responses) {
+		t.Fatalf("expected %d commands, got %v", len(responses), tr.sent)
+	}
+}
+
+func TestPaneWaitKilled(t *testing.T) {
+	ok := []string{"%begin 1 1 0", "%end 1 1 0"}
+	responses := []scriptedResponse{
+		{match: "display-message -p '#{client_name}'", lines: []string{"%begin 1 1 0", "client-7", "%end 1 1 0"}},
+		{match: "display-message -t %1", lines: []string{"%begin 1 1 0", "@2" + querySeparator + "$3", "%end 1 1 0"}},
+		{match: "show-hooks -p -t %1 -- pane-died", lines: ok},
+		{match: "show-hooks -w -t @2 -- pane-died", lines: ok},
+		{match: "show-hooks -t '$3' -- pane-died", lines: ok},
+		{match: "set-hook -a -g -- pane-died", lines: ok},
+		{match: "set-option -p -t %1 -- remain-on-exit on", lines: ok},
+		{match: "display-message -t %1", lines: []string{
+			"%begin 1 1 0", formatRecord(exitStatusVars, map[string]string{varPaneId: "%1"}), "%end 1 1 0",
+			"%layout-change @2 b25d,80x24,0,0,2 b25d,80x24,0,0,2 *",
+		}},
+		{match: "display-message -t %1", lines: []string{"%begin 1 1 0", "can't find pane: %1", "%error 1 1 0"}},
+		{match: "show-hooks -g -- pane-died", lines: ok},
+		{match: "refresh-client -B", lines: ok},
+	}
+	tr := newScriptedTransport(responses)
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	if _, err := (&Pane{Id: "%1", tmux: tmux}).Wait(context.Background(), nil); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestPaneWaitExitedEarly(t *testing.T) {
+	defer func(poll time.Duration) { deadStatusPoll = poll }(deadStatusPoll)
+	deadStatusPoll = time.Millisecond
+
+	ok := []string{"%begin 1 1 0", "%end 1 1 0"}
+	status := func(overrides map[string]string) []string {
+		return []string{"%begin 1 1 0", formatRecord(exitStatusVars, overrides), "%end 1 1 0"}
+	}
+	responses := []scriptedResponse{
+		{match: "display-message -p '#{client_name}'", lines: []string{"%begin 1 1 0", "client-7", "%end 1 1 0"}},
+		{match: "display-message -t %1", lines: []string{"%begin 1 1 0", "@2" + querySeparator + "$3", "%end 1 1 0"}},
+		{match: "show-hooks -p -t %1 -- pane-died", lines: ok},
+		{match: "show-hooks -w -t @2 -- pane-died", lines: ok},
+		{match: "show-hooks -t '$3' -- pane-died", lines: ok},
+		{match: "set-hook -a -g -- pane-died", lines: ok},
+		{match: "set-option -p -t %1 -- remain-on-exit on", lines: ok},
+		// The process died before the hook was added, so no notification
+		// follows; Wait checks again until the status is collected.
+		{match: "display-message -t %1", lines: status(map[string]string{varPaneId: "%1", varPaneDead: "1"})},
+		{match: "display-message -t %1", lines: status(map[string]string{varPaneId: "%1", varPaneDead: "1"})},
+		{match: "display-message -t %1", lines: status(map[string]string{
+			varPaneId: "%1", varPaneDead: "1", varPaneDeadStatus: "127", varPaneDeadTime: "1700000000",
+		})},
+		{match: "show-hooks -g -- pane-died", lines: ok},
+		{match: "refresh-client -B", lines: ok},
+	}
+	tr := newScriptedTransport(responses)
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	st, err := (&Pane{Id: "%1", tmux: tmux}).Wait(ctx, nil)
+	if err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	if st.Code != 127 {
+		t.Fatalf("unexpected status %+v", st)
+	}
+}
+
+func TestPaneWaitInvalid(t *testing.T) {
+	pane := &Pane{Id: "%1", tmux: &Tmux{}}
+	var invalid *InvalidOptionsError
+	op := &WaitOptions{Kill: true, Respawn: &RespawnPaneOptions{}}
+	if _, err := pane.Wait(context.Background(), op); !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidOptionsError, got %v", err)
+	}
+}
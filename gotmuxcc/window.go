@@ -3,7 +3,9 @@ package gotmuxcc
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 )
 
 func (q *query) windowVars() *query {
@@ -83,11 +85,17 @@ func (r queryResult) toWindow(t *Tmux) *Window {
 		VisibleLayout:      r.get(varWindowVisibleLayout),
 		Width:              atoi(r.get(varWindowWidth)),
 		ZoomedFlag:         isOne(r.get(varWindowZoomedFlag)),
+		activity:           parseUnix(r.get(varWindowActivity)),
 		tmux:               t,
 	}
 	return window
 }
 
+// ActivityTime returns the time of the last activity in the window.
+func (w *Window) ActivityTime() time.Time {
+	return w.activity
+}
+
 // ListPanes returns the panes in this window.
 func (w *Window) ListPanes() ([]*Pane, error) {
 	output, err := w.tmux.query().
@@ -128,9 +136,36 @@ func (w *Window) Rename(newName string) error {
 	if err != nil {
 		return fmt.Errorf("failed to rename window: %w", err)
 	}
+	w.Name = newName
 	return nil
 }
 
+// Refresh reloads the window's fields from tmux. It returns an error wrapping
+// ErrNotFound if the window no longer exists.
+func (w *Window) Refresh() error {
+	output, err := w.tmux.query().
+		cmd("display-message").
+		fargs("-t", w.Id).
+		windowVars().
+		run()
+	if err != nil {
+		return fmt.Errorf("failed to refresh window: %w", lookupError("window", w.Id, err))
+	}
+	// tmux expands the format against nothing, rather than failing, for
+	// some targets that no longer exist.
+	refreshed := output.one().toWindow(w.tmux)
+	if refreshed.Id != w.Id {
+		return fmt.Errorf("failed to refresh window: %w", notFound("window", w.Id))
+	}
+	*w = *refreshed
+	return nil
+}
+
+// Exists reports whether the window is still present on the server.
+func (w *Window) Exists() bool {
+	return w.tmux.exists(w.Id, varWindowId)
+}
+
 // Select activates this window.
 func (w *Window) Select() error {
 	_, err := w.tmux.query().
@@ -169,6 +204,208 @@ func (w *Window) Move(targetSession string, targetIdx int) error {
 	return nil
 }
 
+// Swap exchanges the positions of this window and other. Both handles are
+// refreshed and the receiver is returned.
+func (w *Window) Swap(other *Window, op *SwapWindowOptions) (*Window, error) {
+	q := w.tmux.query().
+		cmd("swap-window").
+		fargs("-s", w.Id, "-t", other.Id)
+	if op != nil && op.DoNotSelect {
+		q.fargs("-d")
+	}
+
+	if _, err := q.run(); err != nil {
+		return nil, fmt.Errorf("failed to swap window: %w", err)
+	}
+	if err := other.Refresh(); err != nil {
+		return nil, err
+	}
+	return w.refreshed()
+}
+
+// Link links this window into session, so it appears in both sessions.
+func (w *Window) Link(session *Session, op *LinkWindowOptions) (*Window, error) {
+	if op == nil {
+		op = &LinkWindowOptions{}
+	}
+	if op.After && op.Before {
+		return nil, invalidOptions("link-window", "After", "cannot be combined with Before")
+	}
+
+	target := session.target() + ":"
+	if op.Index != nil {
+		target += strconv.Itoa(*op.Index)
+	}
+
+	q := w.tmux.query().
+		cmd("link-window").
+		fargs("-s", w.Id)
+	if op.After {
+		q.fargs("-a")
+	}
+	if op.Before {
+		q.fargs("-b")
+	}
+	if op.KillExisting {
+		q.fargs("-k")
+	}
+	if op.DoNotSelect {
+		q.fargs("-d")
+	}
+	q.fargs("-t", target)
+
+	if _, err := q.run(); err != nil {
+		return nil, fmt.Errorf("failed to link window: %w", err)
+	}
+	return w.refreshed()
+}
+
+// Unlink removes this window from session. tmux refuses to unlink a window
+// from the only session it is linked to; use Kill instead.
+func (w *Window) Unlink(session *Session) (*Window, error) {
+	_, err := w.tmux.query().
+		cmd("unlink-window").
+		fargs("-t", session.target()+":"+w.Id).
+		run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlink window: %w", err)
+	}
+	return w.refreshed()
+}
+
+// SetSynchronized turns synchronize-panes on or off for the window, so
+// input to any of its panes is sent to all of them. Panes with their own
+// synchronize-panes setting keep it; Pane.Synchronized reports the result.
+func (w *Window) SetSynchronized(on bool) (*Window, error) {
+	value := "off"
+	if on {
+		value = "on"
+	}
+	_, err := w.tmux.query().
+		cmd("set-option").
+		fargs("-w", "-t", w.Id).
+		pargs("synchronize-panes", value).
+		run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to set window synchronization: %w", lookupError("window", w.Id, err))
+	}
+	return w.refreshed()
+}
+
+// Rotate moves the window's panes one position up or down.
+func (w *Window) Rotate(direction RotateDirection) (*Window, error) {
+	q := w.tmux.query().
+		cmd("rotate-window").
+		fargs("-t", w.Id)
+	if direction != "" {
+		q.fargs(string(direction))
+	}
+
+	if _, err := q.run(); err != nil {
+		return nil, fmt.Errorf("failed to rotate window: %w", lookupError("window", w.Id, err))
+	}
+	return w.refreshed()
+}
+
+// Respawn restarts the window with a new command, closing all but one pane.
+func (w *Window) Respawn(op *RespawnWindowOptions) (*Window, error) {
+	if op == nil {
+		op = &RespawnWindowOptions{}
+	}
+	if err := validateEnvironment("respawn-window", op.Environment); err != nil {
+		return nil, err
+	}
+
+	q := w.tmux.query().
+		cmd("respawn-window").
+		fargs("-t", w.Id)
+	if op.Kill {
+		q.fargs("-k")
+	}
+	if op.StartDirectory != "" {
+		q.fargs("-c", op.StartDirectory)
+	}
+	for _, key := range sortedKeys(op.Environment) {
+		q.fargs("-e", key+"="+op.Environment[key])
+	}
+	if op.ShellCommand != "" {
+		q.pargs(op.ShellCommand)
+	}
+
+	if _, err := q.run(); err != nil {
+		return nil, fmt.Errorf("failed to respawn window: %w", lookupError("window", w.Id, err))
+	}
+	return w.refreshed()
+}
+
+// Resize changes the window's size.
+func (w *Window) Resize(op *ResizeWindowOptions) (*Window, error) {
+	if op == nil {
+		return nil, invalidOptions("resize-window", "ResizeWindowOptions", "required")
+	}
+	if op.Largest && op.Smallest {
+		return nil, invalidOptions("resize-window", "Largest", "cannot be combined with Smallest")
+	}
+	if op.Width < 0 || op.Height < 0 {
+		return nil, invalidOptions("resize-window", "Width", "size must not be negative")
+	}
+	if op.Width == 0 && op.Height == 0 && !op.Largest && !op.Smallest {
+		return nil, invalidOptions("resize-window", "ResizeWindowOptions", "no size given")
+	}
+
+	q := w.tmux.query().
+		cmd("resize-window").
+		fargs("-t", w.Id)
+	if op.Largest {
+		q.fargs("-A")
+	}
+	if op.Smallest {
+		q.fargs("-a")
+	}
+	if op.Width > 0 {
+		q.fargs("-x", strconv.Itoa(op.Width))
+	}
+	if op.Height > 0 {
+		q.fargs("-y", strconv.Itoa(op.Height))
+	}
+
+	if _, err := q.run(); err != nil {
+		return nil, fmt.Errorf("failed to resize window: %w", lookupError("window", w.Id, err))
+	}
+	return w.refreshed()
+}
+
+// NextLayout moves the window to the next preset layout.
+func (w *Window) NextLayout() (*Window, error) {
+	_, err := w.tmux.query().
+		cmd("next-layout").
+		fargs("-t", w.Id).
+		run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to select next layout: %w", lookupError("window", w.Id, err))
+	}
+	return w.refreshed()
+}
+
+// PreviousLayout moves the window to the previous preset layout.
+func (w *Window) PreviousLayout() (*Window, error) {
+	_, err := w.tmux.query().
+		cmd("previous-layout").
+		fargs("-t", w.Id).
+		run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to select previous layout: %w", lookupError("window", w.Id, err))
+	}
+	return w.refreshed()
+}
+
+func (w *Window) refreshed() (*Window, error) {
+	if err := w.Refresh(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
 // ListLinkedSessions returns sessions linked to this window.
 func (w *Window) ListLinkedSessions() ([]*Session, error) {
 	sessions := make([]*Session, 0, len(w.LinkedSessionsList))
@@ -314,6 +551,93 @@ func (t *Tmux) listAllPanesDirect() ([]*Pane, error) {
 	return panes, nil
 }
 
+// FindWindows returns the windows with a pane whose content, title or window
+// name matches match, as find-window does.
+func (t *Tmux) FindWindows(match string, op *FindWindowOptions) ([]*Window, error) {
+	output, err := t.query().
+		cmd("list-panes").
+		fargs("-a", "-f", findWindowFilter(match, op)).
+		vars(varWindowId).
+		run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find windows: %w", err)
+	}
+
+	matched := make(map[string]bool)
+	for _, result := range output.collect() {
+		matched[result.get(varWindowId)] = true
+	}
+	if len(matched) == 0 {
+		return []*Window{}, nil
+	}
+
+	windows, err := t.ListAllWindows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find windows: %w", err)
+	}
+	found := make([]*Window, 0, len(matched))
+	for _, window := range windows {
+		if matched[window.Id] {
+			found = append(found, window)
+			delete(matched, window.Id)
+		}
+	}
+	return found, nil
+}
+
+// findWindowFilter builds the same filter find-window uses.
+func findWindowFilter(match string, op *FindWindowOptions) string {
+	if op == nil {
+		op = &FindWindowOptions{}
+	}
+	byName, byContent, byTitle := op.Name, op.Content, op.Title
+	if !byName && !byContent && !byTitle {
+		byName, byContent, byTitle = true, true, true
+	}
+
+	modifier, star := "", "*"
+	if op.Regex {
+		modifier, star = "/r", ""
+	}
+	if op.IgnoreCase {
+		if modifier == "" {
+			modifier = "/"
+		}
+		modifier += "i"
+	}
+	match = escapeFormat(match)
+
+	filters := make([]string, 0, 3)
+	if byContent {
+		filters = append(filters, fmt.Sprintf("#{C%s:%s}", modifier, match))
+	}
+	if byName {
+		filters = append(filters, fmt.Sprintf("#{m%s:%s%s%s,#{window_name}}", modifier, star, match, star))
+	}
+	if byTitle {
+		filters = append(filters, fmt.Sprintf("#{m%s:%s%s%s,#{pane_title}}", modifier, star, match, star))
+	}
+
+	filter := filters[len(filters)-1]
+	for idx := len(filters) - 2; idx >= 0; idx-- {
+		filter = fmt.Sprintf("#{||:%s,%s}", filters[idx], filter)
+	}
+	return filter
+}
+
+// RenumberWindows closes gaps in the session's window indexes (move-window -r)
+// and returns the renumbered windows.
+func (s *Session) RenumberWindows() ([]*Window, error) {
+	_, err := s.tmux.query().
+		cmd("move-window").
+		fargs("-r", "-t", s.target()).
+		run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to renumber windows: %w", lookupError("session", s.target(), err))
+	}
+	return s.ListWindows()
+}
+
 // GetWindowById retrieves a window by its ID.
 func (t *Tmux) GetWindowById(id string) (*Window, error) {
 	windows, err := t.ListAllWindows()
@@ -442,28 +766,87 @@ func (s *Session) GetWindowByIndex(idx int) (*Window, error) {
 
 // NewWindow creates a new window within the session.
 func (s *Session) NewWindow(op *NewWindowOptions) (*Window, error) {
+	if op == nil {
+		op = &NewWindowOptions{}
+	}
+	if err := op.validate(); err != nil {
+		return nil, err
+	}
+
+	target := s.target()
+	if op.Index != nil {
+		target += ":" + strconv.Itoa(*op.Index)
+	}
+
 	q := s.tmux.query().
 		cmd("new-window").
-		fargs("-P", "-t", s.Name).
-		windowVars()
-
-	if op != nil {
-		if op.StartDirectory != "" {
-			q.fargs("-c", op.StartDirectory)
-		}
-		if op.WindowName != "" {
-			q.fargs("-n", op.WindowName)
-		}
-		if op.DoNotAttach {
-			q.fargs("-d")
-		}
+		fargs("-P", "-t", target)
+	if op.StartDirectory != "" {
+		q.fargs("-c", op.StartDirectory)
+	}
+	if op.WindowName != "" {
+		q.fargs("-n", op.WindowName)
+	}
+	if op.DoNotAttach {
+		q.fargs("-d")
+	}
+	if op.After {
+		q.fargs("-a")
+	}
+	if op.Before {
+		q.fargs("-b")
+	}
+	if op.KillExisting {
+		q.fargs("-k")
+	}
+	if op.SelectExisting {
+		q.fargs("-S")
+	}
+	for _, key := range sortedKeys(op.Environment) {
+		q.fargs("-e", key+"="+op.Environment[key])
+	}
+	q.windowVars()
+	if op.ShellCommand != "" {
+		q.pargs(op.ShellCommand)
+		q.pargs(op.Arguments...)
 	}
 
 	output, err := q.run()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create window: %w", err)
 	}
-	return output.one().toWindow(s.tmux), nil
+	window := output.one().toWindow(s.tmux)
+	if window.Id != "" {
+		return window, nil
+	}
+
+	// -S prints nothing when it selects an existing window.
+	if op.SelectExisting {
+		existing, err := s.GetWindowByName(op.WindowName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create window: %w", err)
+		}
+		if existing != nil {
+			return existing, nil
+		}
+	}
+	return nil, errors.New("failed to create window: tmux did not report the new window")
+}
+
+func (op *NewWindowOptions) validate() error {
+	if op.Index != nil && *op.Index < 0 {
+		return invalidOptions("new-window", "Index", "must not be negative")
+	}
+	if op.After && op.Before {
+		return invalidOptions("new-window", "After", "cannot be combined with Before")
+	}
+	if op.SelectExisting && op.WindowName == "" {
+		return invalidOptions("new-window", "SelectExisting", "requires WindowName")
+	}
+	if len(op.Arguments) > 0 && op.ShellCommand == "" {
+		return invalidOptions("new-window", "Arguments", "requires ShellCommand")
+	}
+	return validateEnvironment("new-window", op.Environment)
 }
 
 // New creates a new window with default options.
@@ -475,7 +858,7 @@ func (s *Session) New() (*Window, error) {
 func (s *Session) NextWindow() error {
 	_, err := s.tmux.query().
 		cmd("next-window").
-		fargs("-t", s.Name).
+		fargs("-t", s.target()).
 		run()
 	if err != nil {
 		return fmt.Errorf("failed to select next window: %w", err)
@@ -487,7 +870,7 @@ func (s *Session) NextWindow() error {
 func (s *Session) PreviousWindow() error {
 	_, err := s.tmux.query().
 		cmd("previous-window").
-		fargs("-t", s.Name).
+		fargs("-t", s.target()).
 		run()
 	if err != nil {
 		return fmt.Errorf("failed to select previous window: %w", err)
@@ -1,6 +1,7 @@
 package gotmuxcc
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -161,9 +162,6 @@ func TestPaneCommands(t *testing.T) {
 	if err := pane.SelectPane(nil); err != nil {
 		t.Fatalf("SelectPane error: %v", err)
 	}
-	if err := pane.SplitWindow(nil); err != nil {
-		t.Fatalf("SplitWindow error: %v", err)
-	}
 	if err := pane.ChooseTree(nil); err != nil {
 		t.Fatalf("ChooseTree error: %v", err)
 	}
@@ -174,7 +172,7 @@ func TestPaneCommands(t *testing.T) {
 	tr.sendMu.Lock()
 	joined := strings.Join(tr.sent, "\n")
 	tr.sendMu.Unlock()
-	if !strings.Contains(joined, "send-keys") || !strings.Contains(joined, "split-window") || !strings.Contains(joined, "choose-tree") {
+	if !strings.Contains(joined, "send-keys") || !strings.Contains(joined, "choose-tree") {
 		t.Fatalf("expected pane commands in log: %s", joined)
 	}
 }
@@ -238,7 +236,7 @@ func TestListAllWindowsFallback(t *testing.T) {
 	if !strings.Contains(sent[1], "list-sessions") {
 		t.Fatalf("expected list-sessions fallback command, saw %v", sent)
 	}
-	if !(strings.Contains(sent[2], "list-windows -t popup") || strings.Contains(sent[2], "list-windows -t $1")) {
+	if !(strings.Contains(sent[2], "list-windows -t popup") || strings.Contains(sent[2], "list-windows -t '$1'")) {
 		t.Fatalf("fallback commands were not issued as expected: %v", sent)
 	}
 }
@@ -320,3 +318,399 @@ func TestListAllPanesFallback(t *testing.T) {
 		t.Fatalf("fallback panes command missing: %v", sent)
 	}
 }
+
+func TestWindowOperationsRefreshHandle(t *testing.T) {
+	windowVars := func() []string {
+		q := newQuery(nil)
+		q.windowVars()
+		return append([]string(nil), q.variables...)
+	}()
+	ok := []string{"%begin 1 1 0", "%end 1 1 0"}
+	refreshed := func(index string) []string {
+		return []string{
+			"%begin 1 1 0",
+			formatRecord(windowVars, map[string]string{varWindowId: "@2", varWindowIndex: index, varWindowLinked: "1"}),
+			"%end 1 1 0",
+		}
+	}
+
+	responses := []scriptedResponse{
+		{match: "link-window -s @2 -a -d -t '$1:3'", lines: ok},
+		{match: "display-message -t @2", lines: refreshed("4")},
+		{match: "resize-window -t @2 -A -x 80", lines: ok},
+		{match: "display-message -t @2", lines: refreshed("4")},
+		{match: "rotate-window -t @2 -D", lines: ok},
+		{match: "display-message -t @2", lines: refreshed("4")},
+	}
+	tr := newScriptedTransport(responses)
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	window := &Window{Id: "@2", tmux: tmux}
+	index := 3
+	linked, err := window.Link(&Session{Id: "$1", tmux: tmux}, &LinkWindowOptions{Index: &index, After: true, DoNotSelect: true})
+	if err != nil {
+		t.Fatalf("Link returned error: %v", err)
+	}
+	if linked != window || !window.Linked || window.Index != 4 {
+		t.Fatalf("expected refreshed handle, got %#v", linked)
+	}
+	if _, err := window.Resize(&ResizeWindowOptions{Width: 80, Largest: true}); err != nil {
+		t.Fatalf("Resize returned error: %v", err)
+	}
+	if _, err := window.Rotate(RotateDirectionDown); err != nil {
+		t.Fatalf("Rotate returned error: %v", err)
+	}
+}
+
+func TestWindowOperationsInvalidOptions(t *testing.T) {
+	window := &Window{Id: "@1", tmux: &Tmux{}}
+	var invalid *InvalidOptionsError
+
+	if _, err := window.Resize(&ResizeWindowOptions{Largest: true, Smallest: true}); !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidOptionsError for Largest+Smallest, got %v", err)
+	}
+	if _, err := window.Resize(&ResizeWindowOptions{}); !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidOptionsError for empty resize, got %v", err)
+	}
+	if _, err := window.Link(&Session{Id: "$1"}, &LinkWindowOptions{After: true, Before: true}); !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidOptionsError for After+Before, got %v", err)
+	}
+}
+
+func TestFindWindowFilter(t *testing.T) {
+	cases := []struct {
+		match string
+		op    *FindWindowOptions
+		want  string
+	}{
+		{"vim", &FindWindowOptions{Name: true}, "#{m:*vim*,#{window_name}}"},
+		{"^v", &FindWindowOptions{Title: true, Regex: true, IgnoreCase: true}, "#{m/ri:^v,#{pane_title}}"},
+		{"a,b", &FindWindowOptions{Content: true, IgnoreCase: true}, "#{C/i:a#,b}"},
+		{"x", nil, "#{||:#{C:x},#{||:#{m:*x*,#{window_name}},#{m:*x*,#{pane_title}}}}"},
+	}
+	for _, tc := range cases {
+		if got := findWindowFilter(tc.match, tc.op); got != tc.want {
+			t.Errorf("findWindowFilter(%q) = %q, want %q", tc.match, got, tc.want)
+		}
+	}
+}
+
+func TestNewWindowOptionFlags(t *testing.T) {
+	windowVars := func() []string {
+		q := newQuery(nil)
+		q.windowVars()
+		return append([]string(nil), q.variables...)
+	}()
+
+	responses := []scriptedResponse{
+		{match: "new-window -P -t '$1:3' -n logs -d -a -k -e A=1 -e B=2 -F", lines: []string{
+			"%begin 1 1 0",
+			formatRecord(windowVars, map[string]string{varWindowId: "@5", varWindowIndex: "4"}),
+			"%end 1 1 0",
+		}},
+		{match: "new-window -P -t '$1' -n logs -S -F", lines: []string{"%begin 1 1 0", "%end 1 1 0"}},
+		{match: "list-windows -t '$1'", lines: []string{
+			"%begin 1 1 0",
+			formatRecord(windowVars, map[string]string{varWindowId: "@5", varWindowName: "logs"}),
+			"%end 1 1 0",
+		}},
+	}
+	tr := newScriptedTransport(responses)
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	session := &Session{Id: "$1", Name: "main", tmux: tmux}
+	index := 3
+	window, err := session.NewWindow(&NewWindowOptions{
+		WindowName:   "logs",
+		DoNotAttach:  true,
+		Index:        &index,
+		After:        true,
+		KillExisting: true,
+		Environment:  map[string]string{"B": "2", "A": "1"},
+		ShellCommand: "tail",
+		Arguments:    []string{"-f", "/var/log/app log"},
+	})
+	if err != nil {
+		t.Fatalf("NewWindow returned error: %v", err)
+	}
+	if window.Id != "@5" {
+		t.Fatalf("expected new window @5, got %q", window.Id)
+	}
+
+	tr.mu.Lock()
+	first := tr.sent[0]
+	tr.mu.Unlock()
+	if !strings.HasSuffix(first, " tail -f '/var/log/app log'") {
+		t.Fatalf("expected quoted command arguments, saw %s", first)
+	}
+
+	existing, err := session.NewWindow(&NewWindowOptions{WindowName: "logs", SelectExisting: true})
+	if err != nil {
+		t.Fatalf("NewWindow with SelectExisting returned error: %v", err)
+	}
+	if existing.Id != "@5" {
+		t.Fatalf("expected existing window @5, got %q", existing.Id)
+	}
+}
+
+func TestNewWindowInvalidOptions(t *testing.T) {
+	session := &Session{Id: "$1", tmux: &Tmux{}}
+	var invalid *InvalidOptionsError
+	for name, op := range map[string]*NewWindowOptions{
+		"after and before":        {After: true, Before: true},
+		"select without name":     {SelectExisting: true},
+		"arguments without shell": {Arguments: []string{"x"}},
+		"bad environment":         {Environment: map[string]string{"A=B": "c"}},
+	} {
+		if _, err := session.NewWindow(op); !errors.As(err, &invalid) {
+			t.Errorf("%s: expected InvalidOptionsError, got %v", name, err)
+		}
+	}
+}
+
+func TestPaneStructuralOperations(t *testing.T) {
+	windowVars := func() []string {
+		q := newQuery(nil)
+		q.windowVars()
+		return append([]string(nil), q.variables...)
+	}()
+	paneVars := func() []string {
+		q := newQuery(nil)
+		q.paneVars()
+		return append([]string(nil), q.variables...)
+	}()
+	ok := []string{"%begin 1 1 0", "%end 1 1 0"}
+	pane := func(id string) []string {
+		return []string{
+			"%begin 1 1 0",
+			formatRecord(paneVars, map[string]string{varPaneId: id, varSessionId: "$1"}),
+			"%end 1 1 0",
+		}
+	}
+
+	responses := []scriptedResponse{
+		{match: "split-window -P -t %1 -h -b -f -l 30% -d -c /tmp -e TERM=dumb -F", lines: pane("%2")},
+		{match: "join-pane -s %1 -t @2 -v -d -l 20", lines: ok},
+		{match: "display-message -t %1", lines: pane("%1")},
+		{match: "resize-pane -t %1 -L -- 5", lines: ok},
+		{match: "display-message -t %1", lines: pane("%1")},
+		{match: "break-pane -P -s %1 -t '$1:4' -n logs -d -F", lines: []string{
+			"%begin 1 1 0",
+			formatRecord(windowVars, map[string]string{varWindowId: "@7", varWindowIndex: "4"}),
+			"%end 1 1 0",
+		}},
+	}
+	tr := newScriptedTransport(responses)
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	first := &Pane{Id: "%1", sessionId: "$1", tmux: tmux}
+	split, err := first.SplitWindow(&SplitWindowOptions{
+		SplitDirection: PaneSplitDirectionHorizontal,
+		Before:         true,
+		FullSize:       true,
+		Percentage:     30,
+		StartDirectory: "/tmp",
+		DoNotSelect:    true,
+		Environment:    map[string]string{"TERM": "dumb"},
+		ShellCommand:   "htop",
+	})
+	if err != nil {
+		t.Fatalf("SplitWindow returned error: %v", err)
+	}
+	if split.Id != "%2" {
+		t.Fatalf("expected new pane %%2, got %q", split.Id)
+	}
+
+	if _, err := first.JoinPane(&Window{Id: "@2"}, &JoinPaneOptions{SplitDirection: PaneSplitDirectionVertical, DoNotSelect: true, Size: 20}); err != nil {
+		t.Fatalf("JoinPane returned error: %v", err)
+	}
+	if _, err := first.ResizePane(&ResizePaneOptions{Direction: PanePositionLeft, Adjustment: 5}); err != nil {
+		t.Fatalf("ResizePane returned error: %v", err)
+	}
+	index := 4
+	window, err := first.BreakPane(&BreakPaneOptions{WindowName: "logs", DoNotSelect: true, Index: &index})
+	if err != nil {
+		t.Fatalf("BreakPane returned error: %v", err)
+	}
+	if window.Id != "@7" || window.Index != 4 {
+		t.Fatalf("unexpected window from BreakPane: %#v", window)
+	}
+}
+
+func TestPaneOperationsInvalidOptions(t *testing.T) {
+	pane := &Pane{Id: "%1", tmux: &Tmux{}}
+	var invalid *InvalidOptionsError
+
+	if _, err := pane.SplitWindow(&SplitWindowOptions{Size: 10, Percentage: 50}); !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidOptionsError for size and percentage, got %v", err)
+	}
+	for name, op := range map[string]*ResizePaneOptions{
+		"empty":                  {},
+		"zoom with size":         {Zoom: true, Width: 10},
+		"adjustment without dir": {Adjustment: 3},
+		"percent over 100":       {HeightPercent: 120},
+	} {
+		if _, err := pane.ResizePane(op); !errors.As(err, &invalid) {
+			t.Errorf("%s: expected InvalidOptionsError, got %v", name, err)
+		}
+	}
+	if _, err := pane.BreakPane(&BreakPaneOptions{After: true, Before: true}); !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidOptionsError for After+Before, got %v", err)
+	}
+}
+
+func TestStreamCaptureChunksAndNumbersLines(t *testing.T) {
+	geometry := []string{"%begin 1 1 0", "1500" + querySeparator + "24", "%end 1 1 0"}
+	responses := []scriptedResponse{
+		{match: "display-message -t %1 -p '#{history_size}-:-#{pane_height}'", lines: geometry},
+		{match: "capture-pane -t %1 -p -e -S -1500 -E -501", lines: []string{"%begin 1 2 1", "first", "%end 1 2 1"}},
+		{match: "display-message -t %1", lines: geometry},
+		{match: "capture-pane -t %1 -p -e -S -500 -E 23", lines: []string{"%begin 1 3 1", "%end 1 1 1", "last", "%end 1 3 1"}},
+	}
+	tr := newScriptedTransport(responses)
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	pane := &Pane{Id: "%1", tmux: tmux}
+	lines, err := pane.CaptureLines(&CaptureOptions{EscTxtNBgAttr: true, FromHistoryStart: true})
+	if err != nil {
+		t.Fatalf("CaptureLines returned error: %v", err)
+	}
+	want := []CaptureLine{{0, "first"}, {1000, "%end 1 1 1"}, {1001, "last"}}
+	if len(lines) != len(want) {
+		t.Fatalf("unexpected lines: %#v", lines)
+	}
+	for idx := range want {
+		if lines[idx] != want[idx] {
+			t.Fatalf("line %d: expected %#v, got %#v", idx, want[idx], lines[idx])
+		}
+	}
+}
+
+func TestCaptureRange(t *testing.T) {
+	line := func(n int) *int { return &n }
+	cases := []struct {
+		name        string
+		op          CaptureOptions
+		top, bottom int
+	}{
+		{"visible", CaptureOptions{}, 100, 123},
+		{"history start", CaptureOptions{FromHistoryStart: true}, 0, 123},
+		{"last lines", CaptureOptions{Start: line(-50)}, 50, 123},
+		{"clamped", CaptureOptions{Start: line(-5000), End: line(500)}, 0, 123},
+		{"reversed", CaptureOptions{Start: line(5), End: line(-5)}, 95, 105},
+	}
+	for _, tc := range cases {
+		top, bottom := captureRange(&tc.op, 100, 24)
+		if top != tc.top || bottom != tc.bottom {
+			t.Errorf("%s: expected %d-%d, got %d-%d", tc.name, tc.top, tc.bottom, top, bottom)
+		}
+	}
+}
+
+func TestCapturePaneFlags(t *testing.T) {
+	responses := []scriptedResponse{
+		{match: "capture-pane -t %1 -b snap -a -q -S - -E -10", lines: []string{"%begin 1 1 0", "%end 1 1 0"}},
+	}
+	tr := newScriptedTransport(responses)
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	end := -10
+	pane := &Pane{Id: "%1", tmux: tmux}
+	if _, err := pane.CapturePane(&CaptureOptions{Buffer: "snap", AlternateScreen: true, Quiet: true, FromHistoryStart: true, End: &end}); err != nil {
+		t.Fatalf("CapturePane returned error: %v", err)
+	}
+
+	var invalid *InvalidOptionsError
+	start := 0
+	if _, err := pane.CapturePane(&CaptureOptions{Start: &start, FromHistoryStart: true}); !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidOptionsError for Start and FromHistoryStart, got %v", err)
+	}
+	if _, err := pane.CaptureLines(&CaptureOptions{Buffer: "snap"}); !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidOptionsError for CaptureLines into a buffer, got %v", err)
+	}
+}
+
+func TestPaneTitleMarksAndInput(t *testing.T) {
+	windowVars := func() []string {
+		q := newQuery(nil)
+		q.windowVars()
+		return append([]string(nil), q.variables...)
+	}()
+	paneVars := func() []string {
+		q := newQuery(nil)
+		q.paneVars()
+		return append([]string(nil), q.variables...)
+	}()
+	ok := []string{"%begin 1 1 0", "%end 1 1 0"}
+	pane := func(overrides map[string]string) []string {
+		return []string{"%begin 1 1 0", formatRecord(paneVars, overrides), "%end 1 1 0"}
+	}
+	responses := []scriptedResponse{
+		{match: "select-pane -t %1 -T 'build ##1'", lines: ok},
+		{match: "display-message -t %1", lines: pane(map[string]string{varPaneId: "%1", varPaneTitle: "build #1"})},
+		// Mark skips select-pane -m, which would toggle, for a marked pane.
+		{match: "display-message -t %1", lines: pane(map[string]string{varPaneId: "%1", varPaneMarked: "1"})},
+		{match: "display-message -t '{marked}'", lines: pane(nil)},
+		{match: "select-pane -t %1 -d", lines: ok},
+		{match: "display-message -t %1", lines: pane(map[string]string{varPaneId: "%1", varPaneInputOff: "1"})},
+		{match: "set-option -w -t @1 -- synchronize-panes on", lines: ok},
+		{match: "display-message -t @1", lines: []string{
+			"%begin 1 1 0",
+			formatRecord(windowVars, map[string]string{varWindowId: "@1"}),
+			"%end 1 1 0",
+		}},
+	}
+	tr := newScriptedTransport(responses)
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	p := &Pane{Id: "%1", tmux: tmux}
+	if _, err := p.SetTitle("build #1"); err != nil || p.Title != "build #1" {
+		t.Fatalf("SetTitle: %q, %v", p.Title, err)
+	}
+	if _, err := p.Mark(); err != nil || !p.Marked {
+		t.Fatalf("Mark: %v", err)
+	}
+	if marked, err := tmux.MarkedPane(); err != nil || marked != nil {
+		t.Fatalf("expected no marked pane, got %+v, %v", marked, err)
+	}
+	if _, err := p.DisableInput(); err != nil || !p.InputOff {
+		t.Fatalf("DisableInput: %v", err)
+	}
+	if _, err := (&Window{Id: "@1", tmux: tmux}).SetSynchronized(true); err != nil {
+		t.Fatalf("SetSynchronized: %v", err)
+	}
+}
+
+func TestHandleExists(t *testing.T) {
+	result := func(value string) []string {
+		return []string{"%begin 1 1 0", value, "%end 1 1 0"}
+	}
+	responses := []scriptedResponse{
+		{match: "display-message -t %12 -p '=#{pane_id}'", lines: result("=%12")},
+		// tmux prints an empty expansion for some missing targets.
+		{match: "display-message -t @3 -p '=#{window_id}'", lines: result("=")},
+	}
+	tr := newScriptedTransport(responses)
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	if !(&Pane{Id: "%12", tmux: tmux}).Exists() {
+		t.Fatal("expected pane to exist")
+	}
+	if (&Window{Id: "@3", tmux: tmux}).Exists() {
+		t.Fatal("expected window not to exist")
+	}
+}
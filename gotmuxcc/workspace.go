@@ -0,0 +1,442 @@
+package gotmuxcc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Workspace declares the sessions, windows and panes that should exist on a
+// tmux server. Tmux.Apply converges the server towards it.
+type Workspace struct {
+	Sessions []SessionSpec
+}
+
+// SessionSpec declares a session.
+type SessionSpec struct {
+	Name           string
+	StartDirectory string
+	Options        map[string]string
+	Windows        []WindowSpec
+	// KillUnlisted removes windows, and panes beyond those declared, that are
+	// not part of the spec. By default they are left alone.
+	KillUnlisted bool
+}
+
+// WindowSpec declares a window. Windows are matched by name.
+type WindowSpec struct {
+	Name string
+	// RenameFrom names an existing window to rename to Name when no window
+	// called Name exists yet.
+	RenameFrom     string
+	StartDirectory string
+	// Layout is applied whenever panes are created or removed, or when the
+	// window's current layout no longer matches it. It may be a preset or a
+	// layout string.
+	Layout  WindowLayout
+	Options map[string]string
+	Panes   []PaneSpec
+}
+
+// PaneSpec declares a pane. The first pane of a window is the one tmux creates
+// with the window; later panes are split from the pane before them.
+type PaneSpec struct {
+	StartDirectory string
+	Command        string
+	SplitDirection PaneSplitDirection
+}
+
+// PlanAction identifies the kind of change a PlanStep makes.
+type PlanAction string
+
+const (
+	PlanCreateSession PlanAction = "create-session"
+	PlanCreateWindow  PlanAction = "create-window"
+	PlanRenameWindow  PlanAction = "rename-window"
+	PlanKillWindow    PlanAction = "kill-window"
+	PlanCreatePane    PlanAction = "create-pane"
+	PlanKillPane      PlanAction = "kill-pane"
+	PlanSelectLayout  PlanAction = "select-layout"
+	PlanSetOption     PlanAction = "set-option"
+)
+
+// PlanStep is a single change needed to converge the server on a Workspace.
+type PlanStep struct {
+	Action PlanAction
+	Target string
+	Detail string
+
+	run func() error
+}
+
+func (s *PlanStep) String() string {
+	if s.Detail == "" {
+		return fmt.Sprintf("%s %s", s.Action, s.Target)
+	}
+	return fmt.Sprintf("%s %s: %s", s.Action, s.Target, s.Detail)
+}
+
+// Plan is the ordered list of changes computed by Tmux.Plan.
+type Plan struct {
+	Steps []*PlanStep
+}
+
+// Empty reports whether the server already matches the workspace.
+func (p *Plan) Empty() bool {
+	return len(p.Steps) == 0
+}
+
+func (p *Plan) String() string {
+	if p.Empty() {
+		return "nothing to do"
+	}
+	lines := make([]string, len(p.Steps))
+	for idx, step := range p.Steps {
+		lines[idx] = step.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Execute runs the plan's steps in order, stopping at the first failure.
+func (p *Plan) Execute() error {
+	for _, step := range p.Steps {
+		if err := step.run(); err != nil {
+			return fmt.Errorf("failed to %s: %w", step, err)
+		}
+	}
+	return nil
+}
+
+// Plan compares the workspace with the live server and returns the changes
+// required to converge them without making any.
+func (t *Tmux) Plan(workspace *Workspace) (*Plan, error) {
+	if err := workspace.validate(); err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{}
+	for _, spec := range workspace.Sessions {
+		session, err := t.GetSessionByName(spec.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to plan workspace: %w", err)
+		}
+		if session == nil {
+			t.planNewSession(plan, spec)
+			continue
+		}
+		if err := t.planSession(plan, session, spec); err != nil {
+			return nil, fmt.Errorf("failed to plan session %q: %w", spec.Name, err)
+		}
+	}
+	return plan, nil
+}
+
+// Apply converges the server on the workspace and returns the plan that was
+// executed. Applying the same workspace again yields an empty plan.
+func (t *Tmux) Apply(workspace *Workspace) (*Plan, error) {
+	plan, err := t.Plan(workspace)
+	if err != nil {
+		return nil, err
+	}
+	if err := plan.Execute(); err != nil {
+		return plan, err
+	}
+	return plan, nil
+}
+
+func (w *Workspace) validate() error {
+	if w == nil {
+		return invalidOptions("workspace", "Workspace", "must not be nil")
+	}
+	seen := make(map[string]bool)
+	for _, session := range w.Sessions {
+		if !checkSessionName(session.Name) {
+			return invalidOptions("workspace", "SessionSpec.Name", fmt.Sprintf("invalid tmux session name %q", session.Name))
+		}
+		if seen[session.Name] {
+			return invalidOptions("workspace", "SessionSpec.Name", fmt.Sprintf("duplicate session %q", session.Name))
+		}
+		seen[session.Name] = true
+		if len(session.Windows) == 0 {
+			return invalidOptions("workspace", "SessionSpec.Windows", fmt.Sprintf("session %q declares no windows", session.Name))
+		}
+		windows := make(map[string]bool)
+		for _, window := range session.Windows {
+			if window.Name == "" {
+				return invalidOptions("workspace", "WindowSpec.Name", fmt.Sprintf("unnamed window in session %q", session.Name))
+			}
+			if windows[window.Name] {
+				return invalidOptions("workspace", "WindowSpec.Name", fmt.Sprintf("duplicate window %q in session %q", window.Name, session.Name))
+			}
+			windows[window.Name] = true
+		}
+	}
+	return nil
+}
+
+// sessionRef and windowRef let plan steps refer to a session or window
+// created by an earlier step.
+type sessionRef struct {
+	session *Session
+}
+
+type windowRef struct {
+	window *Window
+}
+
+func (t *Tmux) planNewSession(plan *Plan, spec SessionSpec) {
+	first := spec.Windows[0]
+	dir := firstNonEmpty(first.StartDirectory, spec.StartDirectory)
+	op := &SessionOptions{
+		Name:           spec.Name,
+		WindowName:     first.Name,
+		StartDirectory: firstPaneDirectory(first, dir),
+	}
+	if len(first.Panes) > 0 {
+		op.ShellCommand = first.Panes[0].Command
+	}
+
+	sref, ref := &sessionRef{}, &windowRef{}
+	plan.Steps = append(plan.Steps, &PlanStep{
+		Action: PlanCreateSession,
+		Target: spec.Name,
+		run: func() error {
+			session, err := t.NewSession(op)
+			if err != nil {
+				return err
+			}
+			windows, err := session.ListWindows()
+			if err != nil {
+				return err
+			}
+			if len(windows) == 0 {
+				return fmt.Errorf("session %q has no windows", spec.Name)
+			}
+			sref.session, ref.window = session, windows[0]
+			return nil
+		},
+	})
+	for _, key := range sortedKeys(spec.Options) {
+		plan.addSessionOption(spec.Name, sref, key, spec.Options[key])
+	}
+
+	t.planWindowContents(plan, spec, first, ref, nil, true)
+	for _, window := range spec.Windows[1:] {
+		t.planNewWindow(plan, spec, window)
+	}
+}
+
+func (t *Tmux) planSession(plan *Plan, session *Session, spec SessionSpec) error {
+	for _, key := range sortedKeys(spec.Options) {
+		current, err := session.Option(key)
+		if err != nil || current.Value != spec.Options[key] {
+			plan.addSessionOption(spec.Name, &sessionRef{session: session}, key, spec.Options[key])
+		}
+	}
+
+	windows, err := session.ListWindows()
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]*Window, len(windows))
+	for _, window := range windows {
+		if _, ok := byName[window.Name]; !ok {
+			byName[window.Name] = window
+		}
+	}
+
+	matched := make(map[string]bool)
+	for _, ws := range spec.Windows {
+		window, ok := byName[ws.Name]
+		rename := false
+		if !ok && ws.RenameFrom != "" {
+			window, ok = byName[ws.RenameFrom]
+			rename = ok
+		}
+		if !ok || matched[window.Id] {
+			t.planNewWindow(plan, spec, ws)
+			continue
+		}
+		if rename {
+			renamed, name := window, ws.Name
+			plan.Steps = append(plan.Steps, &PlanStep{
+				Action: PlanRenameWindow,
+				Target: fmt.Sprintf("%s:%s", spec.Name, ws.RenameFrom),
+				Detail: ws.Name,
+				run:    func() error { return renamed.Rename(name) },
+			})
+		}
+		matched[window.Id] = true
+
+		panes, err := window.ListPanes()
+		if err != nil {
+			return err
+		}
+		if err := t.planWindowOptions(plan, spec, ws, window); err != nil {
+			return err
+		}
+		t.planWindowContents(plan, spec, ws, &windowRef{window: window}, panes, false)
+	}
+
+	if spec.KillUnlisted {
+		for _, window := range windows {
+			if matched[window.Id] {
+				continue
+			}
+			killed := window
+			plan.Steps = append(plan.Steps, &PlanStep{
+				Action: PlanKillWindow,
+				Target: fmt.Sprintf("%s:%s", spec.Name, window.Name),
+				run:    killed.Kill,
+			})
+		}
+	}
+	return nil
+}
+
+func (t *Tmux) planNewWindow(plan *Plan, spec SessionSpec, ws WindowSpec) {
+	dir := firstNonEmpty(ws.StartDirectory, spec.StartDirectory)
+	ref := &windowRef{}
+	plan.Steps = append(plan.Steps, &PlanStep{
+		Action: PlanCreateWindow,
+		Target: fmt.Sprintf("%s:%s", spec.Name, ws.Name),
+		run: func() error {
+			session, err := t.GetSessionByName(spec.Name)
+			if err != nil {
+				return err
+			}
+			if session == nil {
+				return fmt.Errorf("session %q does not exist", spec.Name)
+			}
+			op := &NewWindowOptions{
+				WindowName:     ws.Name,
+				StartDirectory: firstPaneDirectory(ws, dir),
+				DoNotAttach:    true,
+			}
+			if len(ws.Panes) > 0 {
+				op.ShellCommand = ws.Panes[0].Command
+			}
+			window, err := session.NewWindow(op)
+			if err != nil {
+				return err
+			}
+			ref.window = window
+			return nil
+		},
+	})
+	t.planWindowContents(plan, spec, ws, ref, nil, true)
+}
+
+// planWindowContents adds the pane, layout and (for new windows) option steps
+// for a window. existing holds the window's current panes; the layout is
+// selected when they change or when ref's current layout differs from the
+// declared one.
+func (t *Tmux) planWindowContents(plan *Plan, spec SessionSpec, ws WindowSpec, ref *windowRef, existing []*Pane, created bool) {
+	target := fmt.Sprintf("%s:%s", spec.Name, ws.Name)
+	dir := firstNonEmpty(ws.StartDirectory, spec.StartDirectory)
+
+	have := len(existing)
+	if created {
+		have = 1
+	}
+	changed := created
+	for idx := have; idx < len(ws.Panes); idx++ {
+		ps := ws.Panes[idx]
+		plan.Steps = append(plan.Steps, &PlanStep{
+			Action: PlanCreatePane,
+			Target: target,
+			Detail: ps.Command,
+			run: func() error {
+				panes, err := ref.window.ListPanes()
+				if err != nil {
+					return err
+				}
+				if len(panes) == 0 {
+					return fmt.Errorf("window %s has no panes", ref.window.Id)
+				}
+				_, err = panes[len(panes)-1].SplitWindow(&SplitWindowOptions{
+					SplitDirection: ps.SplitDirection,
+					StartDirectory: firstNonEmpty(ps.StartDirectory, dir),
+					ShellCommand:   ps.Command,
+				})
+				if err != nil {
+					return err
+				}
+				// Keep panes a usable size; the declared layout follows.
+				return ref.window.SelectLayout(WindowLayoutTiled)
+			},
+		})
+		changed = true
+	}
+	if spec.KillUnlisted && len(ws.Panes) > 0 {
+		for idx := len(ws.Panes); idx < len(existing); idx++ {
+			pane := existing[idx]
+			plan.Steps = append(plan.Steps, &PlanStep{
+				Action: PlanKillPane,
+				Target: target,
+				Detail: pane.Id,
+				run:    pane.Kill,
+			})
+			changed = true
+		}
+	}
+
+	if ws.Layout != "" && (len(ws.Panes) > 1 || len(existing) > 1) && (changed || !layoutMatches(ref.window.Layout, ws.Layout)) {
+		plan.Steps = append(plan.Steps, &PlanStep{
+			Action: PlanSelectLayout,
+			Target: target,
+			Detail: string(ws.Layout),
+			run:    func() error { return ref.window.SelectLayout(ws.Layout) },
+		})
+	}
+
+	if created {
+		for _, key := range sortedKeys(ws.Options) {
+			plan.addWindowOption(target, ref, key, ws.Options[key])
+		}
+	}
+}
+
+func (t *Tmux) planWindowOptions(plan *Plan, spec SessionSpec, ws WindowSpec, window *Window) error {
+	target := fmt.Sprintf("%s:%s", spec.Name, ws.Name)
+	for _, key := range sortedKeys(ws.Options) {
+		current, err := window.Option(key)
+		if err != nil || current.Value != ws.Options[key] {
+			plan.addWindowOption(target, &windowRef{window: window}, key, ws.Options[key])
+		}
+	}
+	return nil
+}
+
+func (p *Plan) addSessionOption(target string, ref *sessionRef, key, value string) {
+	p.Steps = append(p.Steps, &PlanStep{
+		Action: PlanSetOption,
+		Target: target,
+		Detail: fmt.Sprintf("%s=%s", key, value),
+		run:    func() error { return ref.session.SetOption(key, value) },
+	})
+}
+
+func (p *Plan) addWindowOption(target string, ref *windowRef, key, value string) {
+	p.Steps = append(p.Steps, &PlanStep{
+		Action: PlanSetOption,
+		Target: target,
+		Detail: fmt.Sprintf("%s=%s", key, value),
+		run:    func() error { return ref.window.SetOption(key, value) },
+	})
+}
+
+func firstPaneDirectory(ws WindowSpec, fallback string) string {
+	if len(ws.Panes) > 0 {
+		return firstNonEmpty(ws.Panes[0].StartDirectory, fallback)
+	}
+	return fallback
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
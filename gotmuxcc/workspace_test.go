@@ -0,0 +1,260 @@
+package gotmuxcc
+
+import (
+	"errors"
+	"testing"
+)
+
+func workspaceVars() (sessionVars, windowVars, paneVars []string) {
+	q := newQuery(nil)
+	q.sessionVars()
+	sessionVars = append([]string(nil), q.variables...)
+	q = newQuery(nil)
+	q.windowVars()
+	windowVars = append([]string(nil), q.variables...)
+	q = newQuery(nil)
+	q.paneVars()
+	paneVars = append([]string(nil), q.variables...)
+	return sessionVars, windowVars, paneVars
+}
+
+func planActions(plan *Plan) []string {
+	actions := make([]string, len(plan.Steps))
+	for idx, step := range plan.Steps {
+		actions[idx] = step.String()
+	}
+	return actions
+}
+
+func TestWorkspacePlanNewSession(t *testing.T) {
+	responses := []scriptedResponse{
+		{match: "list-sessions", lines: []string{"%begin 1 1 0", "%end 1 1 0"}},
+	}
+	tr := newScriptedTransport(responses)
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	plan, err := tmux.Plan(&Workspace{Sessions: []SessionSpec{{
+		Name:    "dev",
+		Options: map[string]string{"status": "off"},
+		Windows: []WindowSpec{
+			{
+				Name:   "editor",
+				Layout: WindowLayoutEvenHorizontal,
+				Panes:  []PaneSpec{{Command: "vim"}, {Command: "make watch"}},
+			},
+			{Name: "shell"},
+		},
+	}}})
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+
+	want := []string{
+		"create-session dev",
+		"set-option dev: status=off",
+		"create-pane dev:editor: make watch",
+		"select-layout dev:editor: even-horizontal",
+		"create-window dev:shell",
+	}
+	got := planActions(plan)
+	if len(got) != len(want) {
+		t.Fatalf("unexpected plan:\n%s", plan)
+	}
+	for idx := range want {
+		if got[idx] != want[idx] {
+			t.Fatalf("step %d: expected %q, got %q", idx, want[idx], got[idx])
+		}
+	}
+}
+
+func TestWorkspacePlanConverged(t *testing.T) {
+	sessionVars, windowVars, paneVars := workspaceVars()
+	responses := []scriptedResponse{
+		{match: "list-sessions", lines: []string{
+			"%begin 1 1 0",
+			formatRecord(sessionVars, map[string]string{varSessionId: "$1", varSessionName: "dev"}),
+			"%end 1 1 0",
+		}},
+		{match: "show-option -t '$1' -v status", lines: []string{"%begin 1 1 0", "off", "%end 1 1 0"}},
+		{match: "list-windows -t '$1'", lines: []string{
+			"%begin 1 1 0",
+			formatRecord(windowVars, map[string]string{
+				varWindowId: "@1", varWindowName: "editor", varWindowLayout: "9295,80x24,0,0[80x11,0,0,0,80x12,0,12,1]",
+			}),
+			formatRecord(windowVars, map[string]string{varWindowId: "@2", varWindowName: "scratch"}),
+			"%end 1 1 0",
+		}},
+		{match: "list-panes -t @1", lines: []string{
+			"%begin 1 1 0",
+			formatRecord(paneVars, map[string]string{varPaneId: "%1"}),
+			formatRecord(paneVars, map[string]string{varPaneId: "%2"}),
+			"%end 1 1 0",
+		}},
+	}
+	tr := newScriptedTransport(responses)
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	plan, err := tmux.Plan(&Workspace{Sessions: []SessionSpec{{
+		Name:    "dev",
+		Options: map[string]string{"status": "off"},
+		Windows: []WindowSpec{{
+			Name:   "editor",
+			Layout: WindowLayoutTiled,
+			Panes:  []PaneSpec{{}, {}},
+		}},
+	}}})
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+	if !plan.Empty() {
+		t.Fatalf("expected an empty plan, got:\n%s", plan)
+	}
+}
+
+func TestWorkspacePlanLayoutDrift(t *testing.T) {
+	sessionVars, windowVars, paneVars := workspaceVars()
+	ok := []string{"%begin 1 1 0", "%end 1 1 0"}
+	responses := []scriptedResponse{
+		{match: "list-sessions", lines: []string{
+			"%begin 1 1 0",
+			formatRecord(sessionVars, map[string]string{varSessionId: "$1", varSessionName: "dev"}),
+			"%end 1 1 0",
+		}},
+		{match: "show-option -t '$1' -v status", lines: []string{"%begin 1 1 0", "on", "%end 1 1 0"}},
+		// The panes are all there, but side by side rather than stacked.
+		{match: "list-windows -t '$1'", lines: []string{
+			"%begin 1 1 0",
+			formatRecord(windowVars, map[string]string{
+				varWindowId: "@1", varWindowName: "editor", varWindowLayout: "89f5,80x24,0,0{39x24,0,0,0,40x24,40,0,1}",
+			}),
+			"%end 1 1 0",
+		}},
+		{match: "list-panes -t @1", lines: []string{
+			"%begin 1 1 0",
+			formatRecord(paneVars, map[string]string{varPaneId: "%0"}),
+			formatRecord(paneVars, map[string]string{varPaneId: "%1"}),
+			"%end 1 1 0",
+		}},
+		// The session is set by ID, so a rename can't redirect the option.
+		{match: "set-option -t '$1' -- status off", lines: ok},
+		{match: "select-layout -t @1 -- even-vertical", lines: ok},
+	}
+	tr := newScriptedTransport(responses)
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	plan, err := tmux.Apply(&Workspace{Sessions: []SessionSpec{{
+		Name:    "dev",
+		Options: map[string]string{"status": "off"},
+		Windows: []WindowSpec{{
+			Name:   "editor",
+			Layout: WindowLayoutEvenVertical,
+			Panes:  []PaneSpec{{}, {}},
+		}},
+	}}})
+	if err != nil {
+		t.Fatalf("Apply returned error: %v\n%s", err, plan)
+	}
+
+	want := []string{
+		"set-option dev: status=off",
+		"select-layout dev:editor: even-vertical",
+	}
+	got := planActions(plan)
+	if len(got) != len(want) {
+		t.Fatalf("unexpected plan:\n%s", plan)
+	}
+	for idx := range want {
+		if got[idx] != want[idx] {
+			t.Fatalf("step %d: expected %q, got %q", idx, want[idx], got[idx])
+		}
+	}
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	if len(tr.sent) != len(responses) {
+		t.Fatalf("expected %d commands, got %v", len(responses), tr.sent)
+	}
+}
+
+func TestWorkspacePlanKillUnlisted(t *testing.T) {
+	sessionVars, windowVars, paneVars := workspaceVars()
+	responses := []scriptedResponse{
+		{match: "list-sessions", lines: []string{
+			"%begin 1 1 0",
+			formatRecord(sessionVars, map[string]string{varSessionId: "$1", varSessionName: "dev"}),
+			"%end 1 1 0",
+		}},
+		{match: "list-windows -t '$1'", lines: []string{
+			"%begin 1 1 0",
+			formatRecord(windowVars, map[string]string{varWindowId: "@1", varWindowName: "bash"}),
+			formatRecord(windowVars, map[string]string{varWindowId: "@2", varWindowName: "scratch"}),
+			"%end 1 1 0",
+		}},
+		{match: "list-panes -t @1", lines: []string{
+			"%begin 1 1 0",
+			formatRecord(paneVars, map[string]string{varPaneId: "%1"}),
+			formatRecord(paneVars, map[string]string{varPaneId: "%2"}),
+			"%end 1 1 0",
+		}},
+	}
+	tr := newScriptedTransport(responses)
+	tmux := &Tmux{transport: tr}
+	tmux.router = newRouter(tr)
+	defer tmux.Close()
+
+	plan, err := tmux.Plan(&Workspace{Sessions: []SessionSpec{{
+		Name:         "dev",
+		KillUnlisted: true,
+		Windows: []WindowSpec{{
+			Name:       "editor",
+			RenameFrom: "bash",
+			Panes:      []PaneSpec{{}},
+		}},
+	}}})
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+
+	want := []string{
+		"rename-window dev:bash: editor",
+		"kill-pane dev:editor: %2",
+		"kill-window dev:scratch",
+	}
+	got := planActions(plan)
+	if len(got) != len(want) {
+		t.Fatalf("unexpected plan:\n%s", plan)
+	}
+	for idx := range want {
+		if got[idx] != want[idx] {
+			t.Fatalf("step %d: expected %q, got %q", idx, want[idx], got[idx])
+		}
+	}
+}
+
+func TestWorkspaceValidate(t *testing.T) {
+	cases := map[string]*Workspace{
+		"nil":              nil,
+		"no windows":       {Sessions: []SessionSpec{{Name: "dev"}}},
+		"unnamed window":   {Sessions: []SessionSpec{{Name: "dev", Windows: []WindowSpec{{}}}}},
+		"duplicate window": {Sessions: []SessionSpec{{Name: "dev", Windows: []WindowSpec{{Name: "a"}, {Name: "a"}}}}},
+		"duplicate session": {Sessions: []SessionSpec{
+			{Name: "dev", Windows: []WindowSpec{{Name: "a"}}},
+			{Name: "dev", Windows: []WindowSpec{{Name: "a"}}},
+		}},
+	}
+	for name, workspace := range cases {
+		t.Run(name, func(t *testing.T) {
+			tmux := &Tmux{}
+			_, err := tmux.Plan(workspace)
+			var invalid *InvalidOptionsError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("expected InvalidOptionsError, got %v", err)
+			}
+		})
+	}
+}
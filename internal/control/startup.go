@@ -0,0 +1,38 @@
+package control
+
+import "strings"
+
+// startupFilter recognises the startup command's reply: the first %begin frame,
+// if its flags are 0, up to the matching %end or %error.
+type startupFilter struct {
+	active bool
+	inside bool
+}
+
+func newStartupFilter(enabled bool) *startupFilter {
+	return &startupFilter{active: enabled}
+}
+
+// drop reports whether line belongs to the startup reply. The filter gives up
+// at the first %begin that isn't the startup command's.
+func (f *startupFilter) drop(line string) bool {
+	if !f.active {
+		return false
+	}
+	if f.inside {
+		if strings.HasPrefix(line, "%end ") || strings.HasPrefix(line, "%error ") {
+			f.active = false
+		}
+		return true
+	}
+	if !strings.HasPrefix(line, "%begin ") {
+		return false
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 4 && fields[3] == "0" {
+		f.inside = true
+		return true
+	}
+	f.active = false
+	return false
+}
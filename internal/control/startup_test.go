@@ -0,0 +1,117 @@
+package control
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestStartupFilter(t *testing.T) {
+	cases := []struct {
+		name    string
+		enabled bool
+		lines   []string
+		kept    []string
+	}{
+		{
+			name:    "startup reply",
+			enabled: true,
+			lines:   []string{"%begin 1 1 0", "", "%end 1 1 0", "%session-changed $0 main", "%begin 2 2 1", "%end 2 2 1"},
+			kept:    []string{"%session-changed $0 main", "%begin 2 2 1", "%end 2 2 1"},
+		},
+		{
+			name:    "startup error",
+			enabled: true,
+			lines:   []string{"%begin 1 1 0", "no sessions", "%error 1 1 0", "%begin 2 2 1", "%end 2 2 1"},
+			kept:    []string{"%begin 2 2 1", "%end 2 2 1"},
+		},
+		{
+			// Without a startup reply the first frame is a command's, and
+			// later frames with flags 0 (such as hook output) pass too.
+			name:    "command reply first",
+			enabled: true,
+			lines:   []string{"%begin 1 1 1", "out", "%end 1 1 1", "%begin 2 2 0", "%end 2 2 0"},
+			kept:    []string{"%begin 1 1 1", "out", "%end 1 1 1", "%begin 2 2 0", "%end 2 2 0"},
+		},
+		{
+			name:    "disabled",
+			enabled: false,
+			lines:   []string{"%begin 1 1 0", "%end 1 1 0"},
+			kept:    []string{"%begin 1 1 0", "%end 1 1 0"},
+		},
+	}
+	for _, tc := range cases {
+		filter := newStartupFilter(tc.enabled)
+		var kept []string
+		for _, line := range tc.lines {
+			if !filter.drop(line) {
+				kept = append(kept, line)
+			}
+		}
+		if !reflect.DeepEqual(kept, tc.kept) {
+			t.Fatalf("%s: expected %q, got %q", tc.name, tc.kept, kept)
+		}
+	}
+}
+
+func TestTransportDiscardsStartupReply(t *testing.T) {
+	script := `
+printf '%%begin 1 1 0\n'
+printf '%%end 1 1 0\n'
+printf '%%session-changed $0 main\n'
+while IFS= read -r line; do
+	printf '%%begin 2 2 1\n'
+	printf '%s\n' "$line"
+	printf '%%end 2 2 1\n'
+done
+`
+	path := writeFakeTmux(t, script)
+	tr, err := New(context.Background(), Config{
+		TmuxBinary:          path,
+		DiscardStartupReply: true,
+	})
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	defer tr.Close()
+
+	if line := readLine(t, tr.Lines()); line != "%session-changed $0 main" {
+		t.Fatalf("expected startup reply to be dropped, got %q", line)
+	}
+	if err := tr.Send("echo"); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	for _, want := range []string{"%begin 2 2 1", "echo", "%end 2 2 1"} {
+		if line := readLine(t, tr.Lines()); line != want {
+			t.Fatalf("expected %q, got %q", want, line)
+		}
+	}
+}
+
+func TestTransportKeepsFirstCommandReply(t *testing.T) {
+	script := `
+while IFS= read -r line; do
+	printf '%%begin 1 1 1\n'
+	printf '%s\n' "$line"
+	printf '%%end 1 1 1\n'
+done
+`
+	path := writeFakeTmux(t, script)
+	tr, err := New(context.Background(), Config{
+		TmuxBinary:          path,
+		DiscardStartupReply: true,
+	})
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	defer tr.Close()
+
+	if err := tr.Send("echo"); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	for _, want := range []string{"%begin 1 1 1", "echo", "%end 1 1 1"} {
+		if line := readLine(t, tr.Lines()); line != want {
+			t.Fatalf("expected %q, got %q", want, line)
+		}
+	}
+}
@@ -20,6 +20,11 @@ type Config struct {
 	SocketPath string
 	ExtraArgs  []string
 	Env        []string
+	// DiscardStartupReply drops the reply frame to the command tmux runs at
+	// startup (attach-session or the implicit new-session). It carries flags 0
+	// and may arrive after the first command has been sent, so forwarding it
+	// would pair it with that command.
+	DiscardStartupReply bool
 }
 
 // Transport manages a `tmux -C` subprocess and streams its output.
@@ -99,9 +104,13 @@ func New(ctx context.Context, cfg Config) (*Transport, error) {
 		defer wg.Done()
 		scanner := bufio.NewScanner(stdout)
 		scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+		startup := newStartupFilter(cfg.DiscardStartupReply)
 		for scanner.Scan() {
 			text := scanner.Text()
 			trace.Printf("transport", "recv <- %s", trace.FormatControlLine(text))
+			if startup.drop(text) {
+				continue
+			}
 			select {
 			case t.lines <- text:
 			case <-ctx.Done():